@@ -1,7 +1,7 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 	"time"
@@ -12,6 +12,10 @@ import (
 // Config stores all configuration of the application.
 // The values are read by viper from a config file or environment variable.
 type Config struct {
+	// DBDriver selects the database: "mysql" (default, as before DB_DRIVER existed), "postgres" or "sqlite".
+	// For sqlite, DBName is the database file path.
+	DBDriver string `mapstructure:"DB_DRIVER"`
+
 	DBHost         string `mapstructure:"DB_HOST"`
 	DBPort         string `mapstructure:"DB_PORT"`
 	DBUser         string `mapstructure:"DB_USER"`
@@ -19,10 +23,18 @@ type Config struct {
 	DBName         string `mapstructure:"DB_NAME"`
 	DBSslmode      string `mapstructure:"DB_SSLMODE"`
 
+	// DBAutoMigrate runs GORM AutoMigrate after the versioned migrations. Development only.
+	DBAutoMigrate bool `mapstructure:"DB_AUTO_MIGRATE"`
+
 	RedisAddr     string `mapstructure:"REDIS_ADDR"`
 	RedisPassword string `mapstructure:"REDIS_PASSWORD"`
 	RedisDB       int    `mapstructure:"REDIS_DB"`
 
+	// StoreBackend selects where scenario, sequence, resource and rate limit state lives: "redis" or "memory".
+	StoreBackend string `mapstructure:"STORE_BACKEND"`
+	// RedisFallback serves state from memory while Redis is unreachable instead of failing at startup or per request.
+	RedisFallback bool `mapstructure:"REDIS_FALLBACK"`
+
 	JWTSecretKey      string        `mapstructure:"JWT_SECRET_KEY"`
 	JWTExpiration     time.Duration `mapstructure:"JWT_EXPIRATION_HOURS"`
 
@@ -37,7 +49,83 @@ type Config struct {
 	GeminiAPIKey  string `mapstructure:"GEMINI_API_KEY"`
 	GeminiModelName string `mapstructure:"GEMINI_MODEL_NAME"`
 
+	// AIProvider selects the AI backend: "gemini", "openai" (any OpenAI-compatible chat completions
+	// endpoint at AIBaseURL, e.g. Ollama or vLLM) or "stub". Empty means Gemini when GeminiAPIKey is set.
+	// AIModel overrides GeminiModelName, and AIAPIKey overrides GeminiAPIKey for Gemini.
+	AIProvider       string `mapstructure:"AI_PROVIDER"`
+	AIModel          string `mapstructure:"AI_MODEL"`
+	AIBaseURL        string `mapstructure:"AI_BASE_URL"`
+	AIAPIKey         string `mapstructure:"AI_API_KEY"`
+	AITimeoutSeconds int    `mapstructure:"AI_TIMEOUT_SECONDS"` // Bounds each AI call
+
+	// AICacheTTLSeconds keeps AI responses for identical requests in the key-value store; 0 disables the cache.
+	AICacheTTLSeconds int `mapstructure:"AI_CACHE_TTL_SECONDS"`
+	// AIBudgetPeriod is "day" or "month". The budgets limit the AI requests and tokens of each project
+	// and each team per period; 0 means unlimited.
+	AIBudgetPeriod         string `mapstructure:"AI_BUDGET_PERIOD"`
+	AIProjectRequestBudget int64  `mapstructure:"AI_PROJECT_REQUEST_BUDGET"`
+	AIProjectTokenBudget   int64  `mapstructure:"AI_PROJECT_TOKEN_BUDGET"`
+	AITeamRequestBudget    int64  `mapstructure:"AI_TEAM_REQUEST_BUDGET"`
+	AITeamTokenBudget      int64  `mapstructure:"AI_TEAM_TOKEN_BUDGET"`
+
 	NodeJSFakerServiceURL string `mapstructure:"NODEJS_FAKER_SERVICE_URL"`
+
+	// ScenarioSessionHeader names the request header that separates scenario state between clients.
+	ScenarioSessionHeader string `mapstructure:"SCENARIO_SESSION_HEADER"`
+
+	// MocksDir points at a directory of YAML/JSON project definitions that are
+	// reconciled into the database at startup and via the reload endpoint.
+	MocksDir string `mapstructure:"MOCKS_DIR"`
+	// MocksReadOnly rejects API writes to projects that are managed by MocksDir.
+	MocksReadOnly bool `mapstructure:"MOCKS_READ_ONLY"`
+
+	// MockCacheTTLSeconds is how long resolved projects and URLs of mock requests stay cached
+	// in the key-value store. 0 disables the cache.
+	MockCacheTTLSeconds int `mapstructure:"MOCK_CACHE_TTL_SECONDS"`
+
+	// Request logs and counters are written in the background through a queue of RequestLogQueueSize
+	// entries (0 writes them synchronously), in batches of RequestLogBatchSize at least every
+	// RequestLogFlushIntervalMs. RequestLogDropPolicy decides what happens when the queue is full.
+	RequestLogQueueSize       int    `mapstructure:"REQUEST_LOG_QUEUE_SIZE"`
+	RequestLogBatchSize       int    `mapstructure:"REQUEST_LOG_BATCH_SIZE"`
+	RequestLogFlushIntervalMs int    `mapstructure:"REQUEST_LOG_FLUSH_INTERVAL_MS"`
+	RequestLogDropPolicy      string `mapstructure:"REQUEST_LOG_DROP_POLICY"`
+
+	// Request logs older than RequestLogRetentionHours, or beyond the newest RequestLogMaxRowsPerProject
+	// of a project, are purged (0 disables either limit; projects can override both). A janitor purges
+	// every RequestLogJanitorIntervalSeconds (0 disables it), deleting RequestLogPurgeBatchSize rows at a time.
+	RequestLogRetentionHours         int `mapstructure:"REQUEST_LOG_RETENTION_HOURS"`
+	RequestLogMaxRowsPerProject      int `mapstructure:"REQUEST_LOG_MAX_ROWS_PER_PROJECT"`
+	RequestLogJanitorIntervalSeconds int `mapstructure:"REQUEST_LOG_JANITOR_INTERVAL_SECONDS"`
+	RequestLogPurgeBatchSize         int `mapstructure:"REQUEST_LOG_PURGE_BATCH_SIZE"`
+
+	// Free projects expire FreeProjectTTLHours after creation (0 keeps them forever) unless they are
+	// extended or claimed into a team. Expired projects are deleted every ProjectCleanupIntervalSeconds (0 disables it).
+	FreeProjectTTLHours           int `mapstructure:"FREE_PROJECT_TTL_HOURS"`
+	ProjectCleanupIntervalSeconds int `mapstructure:"PROJECT_CLEANUP_INTERVAL_SECONDS"`
+
+	// HealthCheckTimeoutMs bounds each dependency check of /readyz. FakerRequired makes /readyz fail
+	// while the Faker DSL service is unreachable; otherwise it is only reported.
+	HealthCheckTimeoutMs int  `mapstructure:"HEALTH_CHECK_TIMEOUT_MS"`
+	FakerRequired        bool `mapstructure:"FAKER_REQUIRED"`
+
+	// LogLevel is the lowest level written to the JSON logs: debug, info (default), warn or error.
+	// Debug also logs every SQL query.
+	LogLevel string `mapstructure:"LOG_LEVEL"`
+
+	// MetricsEnabled exposes Prometheus metrics on /metrics.
+	MetricsEnabled bool `mapstructure:"METRICS_ENABLED"`
+	// MetricsProjects lists the projects, as comma-separated "team/project" slugs, whose served mocks are
+	// labelled by name; every other project is counted as "other".
+	MetricsProjects string `mapstructure:"METRICS_PROJECTS"`
+
+	// Tracing: TracingExporter is "none" (default), "stdout" for local use or "otlp", which sends
+	// spans over OTLP/HTTP to TracingOTLPEndpoint (host:port, default localhost:4318).
+	TracingExporter     string  `mapstructure:"TRACING_EXPORTER"`
+	TracingOTLPEndpoint string  `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	TracingOTLPInsecure bool    `mapstructure:"TRACING_OTLP_INSECURE"`
+	TracingServiceName  string  `mapstructure:"TRACING_SERVICE_NAME"`
+	TracingSampleRatio  float64 `mapstructure:"TRACING_SAMPLE_RATIO"` // Share of new traces that are recorded
 }
 
 // LoadConfig reads configuration from file or environment variables.
@@ -50,10 +138,10 @@ func LoadConfig(path string) (config Config, err error) {
 
 	// If a config file is found, read it in.
 	if err = viper.ReadInConfig(); err == nil {
-		log.Printf("Using config file: %s", viper.ConfigFileUsed())
+		slog.Info("Using config file", "path", viper.ConfigFileUsed())
 	} else if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 		// Config file not found; ignore error if desired
-		log.Println("Config file not found, relying on environment variables.")
+		slog.Info("Config file not found, relying on environment variables")
 		// Create a dummy .env file if it doesn't exist to prevent viper error on subsequent calls in some setups
 		// Though, for .env type, this might not be strictly necessary if AutomaticEnv is working.
 		if _, err := os.Stat(path + "/.env"); os.IsNotExist(err) {
@@ -62,14 +150,14 @@ func LoadConfig(path string) (config Config, err error) {
 		}
 	} else {
 		// Config file was found but another error was produced
-		log.Printf("Error reading config file: %s", err)
+		slog.Error("Failed to read config file", "error", err)
 		return
 	}
 
 	// Unmarshal the config into the Config struct
 	err = viper.Unmarshal(&config)
 	if err != nil {
-		log.Printf("Unable to decode into struct, %v", err)
+		slog.Error("Failed to decode config", "error", err)
 		return
 	}
 
@@ -80,7 +168,7 @@ func LoadConfig(path string) (config Config, err error) {
 	} else {
 		// Default if not set or invalid
 		config.JWTExpiration = 72 * time.Hour
-		log.Printf("Invalid or missing JWT_EXPIRATION_HOURS, defaulting to %v", config.JWTExpiration)
+		slog.Info("Invalid or missing JWT_EXPIRATION_HOURS, using the default", "jwt_expiration", config.JWTExpiration.String())
 	}
 
     if config.ServerPort == "" {
@@ -91,9 +179,87 @@ func LoadConfig(path string) (config Config, err error) {
 		config.GeminiModelName = "gemini-1.5-flash-latest" // Default model
 	}
 
+	if config.AITimeoutSeconds <= 0 {
+		config.AITimeoutSeconds = 60
+	}
+	if !viper.IsSet("AI_CACHE_TTL_SECONDS") {
+		config.AICacheTTLSeconds = 3600
+	}
+	if config.AIBudgetPeriod == "" {
+		config.AIBudgetPeriod = "month"
+	}
+
 	if config.NodeJSFakerServiceURL == "" {
 		config.NodeJSFakerServiceURL = "http://localhost:3001" // Default Node.js Faker service URL
-		log.Printf("NODEJS_FAKER_SERVICE_URL not set, defaulting to %s", config.NodeJSFakerServiceURL)
+		slog.Info("NODEJS_FAKER_SERVICE_URL not set, using the default", "url", config.NodeJSFakerServiceURL)
+	}
+
+	if config.DBDriver == "" {
+		config.DBDriver = "mysql" // Deployments from before DB_DRIVER existed use MySQL
+	}
+
+	if config.StoreBackend == "" {
+		config.StoreBackend = "redis"
+	}
+
+	if !viper.IsSet("MOCK_CACHE_TTL_SECONDS") {
+		config.MockCacheTTLSeconds = 60
+	}
+
+	if !viper.IsSet("REQUEST_LOG_QUEUE_SIZE") {
+		config.RequestLogQueueSize = 10000
+	}
+	if config.RequestLogBatchSize <= 0 {
+		config.RequestLogBatchSize = 500
+	}
+	if config.RequestLogFlushIntervalMs <= 0 {
+		config.RequestLogFlushIntervalMs = 1000
+	}
+	if config.RequestLogDropPolicy == "" {
+		config.RequestLogDropPolicy = "drop_newest"
+	}
+
+	if !viper.IsSet("REQUEST_LOG_RETENTION_HOURS") {
+		config.RequestLogRetentionHours = 720 // 30 days
+	}
+	if !viper.IsSet("REQUEST_LOG_MAX_ROWS_PER_PROJECT") {
+		config.RequestLogMaxRowsPerProject = 10000
+	}
+	if !viper.IsSet("REQUEST_LOG_JANITOR_INTERVAL_SECONDS") {
+		config.RequestLogJanitorIntervalSeconds = 300
+	}
+	if config.RequestLogPurgeBatchSize <= 0 {
+		config.RequestLogPurgeBatchSize = 1000
+	}
+
+	if !viper.IsSet("FREE_PROJECT_TTL_HOURS") {
+		config.FreeProjectTTLHours = 168 // 7 days
+	}
+	if !viper.IsSet("PROJECT_CLEANUP_INTERVAL_SECONDS") {
+		config.ProjectCleanupIntervalSeconds = 600
+	}
+	if config.HealthCheckTimeoutMs <= 0 {
+		config.HealthCheckTimeoutMs = 2000
+	}
+	if config.LogLevel == "" {
+		config.LogLevel = "info"
+	}
+	if !viper.IsSet("METRICS_ENABLED") {
+		config.MetricsEnabled = true
+	}
+
+	if config.TracingExporter == "" {
+		config.TracingExporter = "none"
+	}
+	if config.TracingServiceName == "" {
+		config.TracingServiceName = "mockapi"
+	}
+	if !viper.IsSet("TRACING_SAMPLE_RATIO") {
+		config.TracingSampleRatio = 1
+	}
+
+	if config.ScenarioSessionHeader == "" {
+		config.ScenarioSessionHeader = "X-Mock-Session"
 	}
 
 	return
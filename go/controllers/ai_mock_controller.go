@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"mockapi/dtos"
+	"mockapi/models"
+	"mockapi/services"
+	"mockapi/utils"
+)
+
+// AIMockController creates mocks with the AI model.
+type AIMockController struct {
+	projectService services.ProjectServiceInterface
+	urlService     services.URLServiceInterface
+	aiMockService  services.AIMockServiceInterface
+}
+
+// NewAIMockController creates a new AIMockController.
+func NewAIMockController(ps services.ProjectServiceInterface, us services.URLServiceInterface, ams services.AIMockServiceInterface) *AIMockController {
+	return &AIMockController{projectService: ps, urlService: us, aiMockService: ams}
+}
+
+// GenerateMock handles POST /mock/:projectSlug/ai-generate
+// It creates a URL at the given path whose mock contents are generated from a natural-language description.
+func (amc *AIMockController) GenerateMock(c *gin.Context) {
+	projectSlug := c.Param("projectSlug")
+
+	var dto dtos.AIGenerateMockDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	project, ok := amc.writableProject(c, projectSlug)
+	if !ok {
+		return
+	}
+
+	existingURL, err := amc.urlService.FindByProjectIDAndURL(project.ID, dto.Path)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Error checking for existing URL: "+err.Error())
+		return
+	}
+	if existingURL != nil {
+		utils.ErrorResponse(c, http.StatusConflict, fmt.Sprintf("URL path '%s' already exists for this project.", dto.Path))
+		return
+	}
+
+	ctx := services.WithAIScope(c.Request.Context(), services.AIScope{ProjectID: project.ID, TeamID: project.TeamID})
+	url, err := amc.aiMockService.GenerateMock(ctx, project.ID, dto)
+	if err != nil {
+		amc.generationError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, gin.H{"url": url})
+}
+
+// GenerateSchemaVariants handles POST /url/:urlId/ai-variants
+// It adds variants whose bodies are generated to conform to the given JSON Schema.
+func (amc *AIMockController) GenerateSchemaVariants(c *gin.Context) {
+	var dto dtos.AISchemaVariantsDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	url, ctx, ok := amc.writableURL(c)
+	if !ok {
+		return
+	}
+
+	contents, err := amc.aiMockService.GenerateSchemaVariants(ctx, url, dto)
+	if err != nil {
+		amc.generationError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, gin.H{"mock_contents": contents})
+}
+
+// GenerateEdgeCases handles POST /url/:urlId/ai-edge-cases
+// It adds edge-case variants derived from one of the URL's mock contents.
+func (amc *AIMockController) GenerateEdgeCases(c *gin.Context) {
+	var dto dtos.AIEdgeCasesDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	url, ctx, ok := amc.writableURL(c)
+	if !ok {
+		return
+	}
+	var source *models.MockContent
+	for i := range url.MockContents {
+		if url.MockContents[i].ID == dto.MockContentID {
+			source = &url.MockContents[i]
+		}
+	}
+	if source == nil {
+		utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Mock content with ID %d not found on URL %d.", dto.MockContentID, url.ID))
+		return
+	}
+
+	contents, err := amc.aiMockService.GenerateEdgeCases(ctx, url, source, dto)
+	if err != nil {
+		amc.generationError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, gin.H{"mock_contents": contents})
+}
+
+// generationError writes the response for a failed generation.
+func (amc *AIMockController) generationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAIInvalidOutput):
+		utils.ErrorResponse(c, http.StatusBadGateway, "The AI model returned an unusable response, please try again: "+err.Error())
+	case errors.Is(err, services.ErrInvalidJSONSchema), errors.Is(err, services.ErrMockContentNotJSON):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		utils.ErrorResponse(c, aiErrorStatus(err), "Failed to generate mock: "+err.Error())
+	}
+}
+
+// writableURL finds the URL of the request with its mock contents and checks that its project can be
+// changed through the API, writing the error response otherwise. The returned context charges AI usage
+// to the project.
+func (amc *AIMockController) writableURL(c *gin.Context) (*models.Url, context.Context, bool) {
+	urlID, err := strconv.ParseUint(c.Param("urlId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid URL ID format.")
+		return nil, nil, false
+	}
+	url, err := amc.urlService.GetURLByID(uint(urlID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("URL with ID %d not found.", urlID))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve URL: "+err.Error())
+		}
+		return nil, nil, false
+	}
+	project, err := amc.projectService.GetProjectByID(url.ProjectID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Error finding project: "+err.Error())
+		return nil, nil, false
+	}
+	if amc.projectService.IsReadOnly(project) {
+		utils.ErrorResponse(c, http.StatusForbidden, fmt.Sprintf("Project '%s' is managed by mock definition files and is read-only.", project.Slug))
+		return nil, nil, false
+	}
+	ctx := services.WithAIScope(c.Request.Context(), services.AIScope{ProjectID: project.ID, TeamID: project.TeamID})
+	return url, ctx, true
+}
+
+// writableProject finds the project and checks that it can be changed through the API, writing
+// the error response otherwise.
+func (amc *AIMockController) writableProject(c *gin.Context, projectSlug string) (*models.Project, bool) {
+	project, err := amc.projectService.GetProjectBySlug(projectSlug)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project with slug '%s' not found.", projectSlug))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Error finding project: "+err.Error())
+		}
+		return nil, false
+	}
+	if amc.projectService.IsReadOnly(project) {
+		utils.ErrorResponse(c, http.StatusForbidden, fmt.Sprintf("Project '%s' is managed by mock definition files and is read-only.", projectSlug))
+		return nil, false
+	}
+	return project, true
+}
@@ -1,10 +1,15 @@
 package controllers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 	// "log" // For potential detailed error logging
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	// "google.golang.org/genai" // To check for genai.APIError, if needed
 
 	"mockapi/dtos"
@@ -12,18 +17,55 @@ import (
 	"mockapi/utils" // For response helpers
 )
 
+// DefaultMaxAIStreamDuration bounds a streamed generation, whatever the AI timeout.
+const DefaultMaxAIStreamDuration = 10 * time.Minute
+
+// aiStreamWriteGrace is how long the final event of a stream may take to write after the stream ended.
+const aiStreamWriteGrace = 5 * time.Second
+
 // AIPromptController handles API endpoints related to AI prompting.
 type AIPromptController struct {
 	aiPromptService services.AIPromptServiceInterface // Use the interface type
+	projectService  services.ProjectServiceInterface  // Resolves the project a prompt is charged to
+
+	// MaxStreamDuration ends streams that are still running after it, with an "error" event.
+	MaxStreamDuration time.Duration
 }
 
 // NewAIPromptController creates a new AIPromptController.
-func NewAIPromptController(service services.AIPromptServiceInterface) *AIPromptController { // Accept interface type
+func NewAIPromptController(service services.AIPromptServiceInterface, ps services.ProjectServiceInterface) *AIPromptController { // Accept interface type
 	if service == nil {
 		// Or panic, depending on desired strictness for dependency injection
 		panic("AIPromptServiceInterface cannot be nil in NewAIPromptController")
 	}
-	return &AIPromptController{aiPromptService: service}
+	return &AIPromptController{aiPromptService: service, projectService: ps, MaxStreamDuration: DefaultMaxAIStreamDuration}
+}
+
+// aiContext returns the request context, charged to the AI budgets of the named project and its team
+// when a project is given. It writes the error response when the project cannot be found.
+func (controller *AIPromptController) aiContext(c *gin.Context, projectSlug string) (context.Context, bool) {
+	ctx := c.Request.Context()
+	if projectSlug == "" {
+		return ctx, true
+	}
+	project, err := controller.projectService.GetProjectBySlug(projectSlug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project with slug '%s' not found.", projectSlug))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Error finding project: "+err.Error())
+		}
+		return nil, false
+	}
+	return services.WithAIScope(ctx, services.AIScope{ProjectID: project.ID, TeamID: project.TeamID}), true
+}
+
+// aiErrorStatus is the HTTP status for an error of the AI services.
+func aiErrorStatus(err error) int {
+	if errors.Is(err, services.ErrAIBudgetExceeded) {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
 }
 
 // HandleAIPrompt is the Gin handler for AI prompt requests.
@@ -47,8 +89,13 @@ func (controller *AIPromptController) HandleAIPrompt(c *gin.Context) {
 	// }
 
 
-	// Call the service method, passing c.Request.Context() for context propagation.
-	aiResponse, err := controller.aiPromptService.GetAIResponse(c.Request.Context(), reqDTO.Prompt)
+	ctx, ok := controller.aiContext(c, reqDTO.Project)
+	if !ok {
+		return
+	}
+
+	// Call the service method, passing the request context for context propagation.
+	aiResponse, err := controller.aiPromptService.GetAIResponse(ctx, reqDTO.Prompt)
 	if err != nil {
 		// Log the detailed error for server-side observability
 		// log.Printf("Error from AIPromptService.GetAIResponse: %v", err)
@@ -63,11 +110,62 @@ func (controller *AIPromptController) HandleAIPrompt(c *gin.Context) {
 		// } else {
 		//     utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get AI response.")
 		// }
-		// For now, a generic error message is returned.
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get AI response: "+err.Error())
+		// For now, a generic error message is returned; exhausted budgets answer 429.
+		utils.ErrorResponse(c, aiErrorStatus(err), "Failed to get AI response: "+err.Error())
 		return
 	}
 
 	// Return the successful response.
 	utils.SuccessResponse(c, http.StatusOK, aiResponse)
 }
+
+// HandleAIPromptStream is the Gin handler for streamed AI prompt requests.
+// It relays the generated text as server-sent "chunk" events and ends with a "done" event carrying the
+// finish reason and token usage, or an "error" event. A client that disconnects cancels the generation, and
+// a generation still running after MaxStreamDuration is cut off. An exhausted budget is answered with 429
+// before the stream starts.
+func (controller *AIPromptController) HandleAIPromptStream(c *gin.Context) {
+	var reqDTO dtos.AIPromptRequestDTO
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+	ctx, ok := controller.aiContext(c, reqDTO.Project)
+	if !ok {
+		return
+	}
+	if err := controller.aiPromptService.CheckBudget(ctx); err != nil {
+		utils.ErrorResponse(c, aiErrorStatus(err), "Failed to get AI response: "+err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Keep reverse proxies from buffering the stream
+	c.Status(http.StatusOK)
+	// Generations outlast the server's write timeout, so the stream is bounded by its own maximum
+	// duration instead, which holds even when the AI timeout is off
+	ctx, cancel := context.WithTimeout(ctx, controller.MaxStreamDuration)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(deadline.Add(aiStreamWriteGrace))
+
+	// The request context ends when the client disconnects, which stops the upstream generation
+	resp, err := controller.aiPromptService.StreamAIResponse(ctx, reqDTO.Prompt, func(text string) error {
+		c.SSEvent("chunk", gin.H{"text": text})
+		c.Writer.Flush()
+		return ctx.Err()
+	})
+	if c.Request.Context().Err() != nil {
+		return // Nobody is listening anymore
+	}
+	if err != nil {
+		c.SSEvent("error", gin.H{"message": "Failed to get AI response: " + err.Error()})
+		c.Writer.Flush()
+		return
+	}
+	c.SSEvent("done", gin.H{"finish_reason": resp.FinishReason, "usage": resp.Usage})
+	c.Writer.Flush()
+}
+
@@ -5,16 +5,20 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
 	"mockapi/controllers"
 	"mockapi/dtos"
+	"mockapi/models"
 	"mockapi/services" // For the interface
 	// "mockapi/utils" // Not directly used by test functions
 )
@@ -23,7 +27,10 @@ var _ services.AIPromptServiceInterface // Force services import usage
 
 // MockAIPromptService implements the AIPromptServiceInterface for testing.
 type MockAIPromptService struct {
-	GetAIResponseFunc func(ctx context.Context, prompt string) (map[string]interface{}, error)
+	GetAIResponseFunc    func(ctx context.Context, prompt string) (map[string]interface{}, error)
+	GenerateJSONFunc     func(ctx context.Context, systemInstruction, prompt string, schema *services.AISchema) (string, error)
+	StreamAIResponseFunc func(ctx context.Context, prompt string, emit func(text string) error) (*services.AIResponse, error)
+	CheckBudgetFunc      func(ctx context.Context) error
 	// CloseFunc is not needed as the interface no longer has Close()
 }
 
@@ -34,6 +41,27 @@ func (m *MockAIPromptService) GetAIResponse(ctx context.Context, prompt string)
 	return nil, errors.New("MockAIPromptService.GetAIResponseFunc not implemented")
 }
 
+func (m *MockAIPromptService) GenerateJSON(ctx context.Context, systemInstruction, prompt string, schema *services.AISchema) (string, error) {
+	if m.GenerateJSONFunc != nil {
+		return m.GenerateJSONFunc(ctx, systemInstruction, prompt, schema)
+	}
+	return "", errors.New("MockAIPromptService.GenerateJSONFunc not implemented")
+}
+
+func (m *MockAIPromptService) StreamAIResponse(ctx context.Context, prompt string, emit func(text string) error) (*services.AIResponse, error) {
+	if m.StreamAIResponseFunc != nil {
+		return m.StreamAIResponseFunc(ctx, prompt, emit)
+	}
+	return nil, errors.New("MockAIPromptService.StreamAIResponseFunc not implemented")
+}
+
+func (m *MockAIPromptService) CheckBudget(ctx context.Context) error {
+	if m.CheckBudgetFunc != nil {
+		return m.CheckBudgetFunc(ctx)
+	}
+	return nil
+}
+
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New() // Use gin.New() instead of gin.Default() for tests to avoid default middleware
@@ -48,7 +76,7 @@ func TestAIPromptController_HandleAIPrompt(t *testing.T) {
 				return map[string]interface{}{"text": "AI response to " + prompt}, nil
 			},
 		}
-		controller := controllers.NewAIPromptController(mockService)
+		controller := controllers.NewAIPromptController(mockService, &services.MockProjectService{})
 
 		router := setupTestRouter()
 		router.POST("/ai/prompt", controller.HandleAIPrompt)
@@ -78,7 +106,7 @@ func TestAIPromptController_HandleAIPrompt(t *testing.T) {
 	t.Run("invalid_request_bad_json", func(t *testing.T) {
 		// Provide a valid, non-nil mockService, even if its methods won't be called.
 		mockService := &MockAIPromptService{}
-		controller := controllers.NewAIPromptController(mockService)
+		controller := controllers.NewAIPromptController(mockService, &services.MockProjectService{})
 
 		router := setupTestRouter()
 		router.POST("/ai/prompt", controller.HandleAIPrompt)
@@ -101,7 +129,7 @@ func TestAIPromptController_HandleAIPrompt(t *testing.T) {
 	t.Run("invalid_request_empty_prompt", func(t *testing.T) {
 		// Provide a valid, non-nil mockService.
 		mockService := &MockAIPromptService{}
-		controller := controllers.NewAIPromptController(mockService)
+		controller := controllers.NewAIPromptController(mockService, &services.MockProjectService{})
 
 		router := setupTestRouter()
 		router.POST("/ai/prompt", controller.HandleAIPrompt)
@@ -131,7 +159,7 @@ func TestAIPromptController_HandleAIPrompt(t *testing.T) {
 				return nil, errors.New("mock service error")
 			},
 		}
-		controller := controllers.NewAIPromptController(mockService)
+		controller := controllers.NewAIPromptController(mockService, &services.MockProjectService{})
 
 		router := setupTestRouter()
 		router.POST("/ai/prompt", controller.HandleAIPrompt)
@@ -154,10 +182,134 @@ func TestAIPromptController_HandleAIPrompt(t *testing.T) {
 		assert.Contains(t, errResp["message"], "Failed to get AI response: mock service error")
 	})
 
+	t.Run("charged_to_project", func(t *testing.T) {
+		mockService := &MockAIPromptService{
+			GetAIResponseFunc: func(ctx context.Context, prompt string) (map[string]interface{}, error) {
+				assert.Equal(t, services.AIScope{ProjectID: 7, TeamID: 3}, services.AIScopeFromContext(ctx))
+				return map[string]interface{}{"text": "ok"}, nil
+			},
+		}
+		projectService := &services.MockProjectService{
+			GetProjectBySlugFunc: func(slug string) (*models.Project, error) {
+				if slug != "my-project" {
+					return nil, gorm.ErrRecordNotFound
+				}
+				return &models.Project{BaseModel: models.BaseModel{ID: 7}, TeamID: 3}, nil
+			},
+		}
+		router := setupTestRouter()
+		router.POST("/ai/prompt", controllers.NewAIPromptController(mockService, projectService).HandleAIPrompt)
+
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/ai/prompt", bytes.NewBufferString(`{"prompt": "test prompt", "project": "my-project"}`))
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodPost, "/ai/prompt", bytes.NewBufferString(`{"prompt": "test prompt", "project": "unknown"}`))
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("budget_exceeded_case", func(t *testing.T) {
+		mockService := &MockAIPromptService{
+			GetAIResponseFunc: func(ctx context.Context, prompt string) (map[string]interface{}, error) {
+				return nil, fmt.Errorf("%w: the project has used 10 of 10 requests", services.ErrAIBudgetExceeded)
+			},
+		}
+		router := setupTestRouter()
+		router.POST("/ai/prompt", controllers.NewAIPromptController(mockService, &services.MockProjectService{}).HandleAIPrompt)
+
+		req, _ := http.NewRequest(http.MethodPost, "/ai/prompt", bytes.NewBufferString(`{"prompt": "test prompt"}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Contains(t, rr.Body.String(), "AI usage budget exceeded")
+	})
+
 	t.Run("nil_service_in_controller_constructor_panic", func(t *testing.T) {
 		// The constructor NewAIPromptController now panics if service is nil.
 		assert.PanicsWithValue(t, "AIPromptServiceInterface cannot be nil in NewAIPromptController", func() {
-			controllers.NewAIPromptController(nil)
+			controllers.NewAIPromptController(nil, nil)
 		}, "Constructor should panic if service is nil")
 	})
 }
+
+func TestAIPromptController_HandleAIPromptStream(t *testing.T) {
+	streamRequest := func(t *testing.T, mockService *MockAIPromptService) *httptest.ResponseRecorder {
+		controller := controllers.NewAIPromptController(mockService, &services.MockProjectService{})
+		router := setupTestRouter()
+		router.POST("/ai/prompt/stream", controller.HandleAIPromptStream)
+
+		req, _ := http.NewRequest(http.MethodPost, "/ai/prompt/stream", bytes.NewBufferString(`{"prompt": "test prompt"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("success_case", func(t *testing.T) {
+		rr := streamRequest(t, &MockAIPromptService{
+			StreamAIResponseFunc: func(ctx context.Context, prompt string, emit func(text string) error) (*services.AIResponse, error) {
+				require.NoError(t, emit("Hello"))
+				require.NoError(t, emit(" world"))
+				return &services.AIResponse{FinishReason: "STOP", Usage: services.AIUsage{PromptTokens: 2, CompletionTokens: 2, TotalTokens: 4}}, nil
+			},
+		})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Header().Get("Content-Type"), "text/event-stream")
+		body := rr.Body.String()
+		assert.Contains(t, body, "event:chunk\ndata:{\"text\":\"Hello\"}\n\n")
+		assert.Contains(t, body, "event:chunk\ndata:{\"text\":\" world\"}\n\n")
+		assert.Contains(t, body, "event:done\ndata:{\"finish_reason\":\"STOP\",\"usage\":{\"prompt_tokens\":2,\"completion_tokens\":2,\"total_tokens\":4}}\n\n")
+	})
+
+	t.Run("service_error_case", func(t *testing.T) {
+		rr := streamRequest(t, &MockAIPromptService{
+			StreamAIResponseFunc: func(ctx context.Context, prompt string, emit func(text string) error) (*services.AIResponse, error) {
+				return nil, errors.New("mock service error")
+			},
+		})
+
+		assert.Contains(t, rr.Body.String(), "event:error\ndata:{\"message\":\"Failed to get AI response: mock service error\"}")
+		assert.NotContains(t, rr.Body.String(), "event:done")
+	})
+
+	t.Run("max_duration_case", func(t *testing.T) {
+		controller := controllers.NewAIPromptController(&MockAIPromptService{
+			StreamAIResponseFunc: func(ctx context.Context, prompt string, emit func(text string) error) (*services.AIResponse, error) {
+				require.NoError(t, emit("Hello"))
+				<-ctx.Done() // The generation never finishes on its own
+				return nil, ctx.Err()
+			},
+		}, &services.MockProjectService{})
+		controller.MaxStreamDuration = 20 * time.Millisecond
+		router := setupTestRouter()
+		router.POST("/ai/prompt/stream", controller.HandleAIPromptStream)
+
+		req, _ := http.NewRequest(http.MethodPost, "/ai/prompt/stream", bytes.NewBufferString(`{"prompt": "test prompt"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Contains(t, rr.Body.String(), "event:chunk")
+		assert.Contains(t, rr.Body.String(), "event:error\ndata:{\"message\":\"Failed to get AI response: context deadline exceeded\"}")
+	})
+
+	t.Run("budget_exceeded_case", func(t *testing.T) {
+		rr := streamRequest(t, &MockAIPromptService{
+			CheckBudgetFunc: func(ctx context.Context) error {
+				return services.ErrAIBudgetExceeded
+			},
+			StreamAIResponseFunc: func(ctx context.Context, prompt string, emit func(text string) error) (*services.AIResponse, error) {
+				t.Fatal("the stream must not start once the budget is used up")
+				return nil, nil
+			},
+		})
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.NotContains(t, rr.Header().Get("Content-Type"), "text/event-stream")
+	})
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"mockapi/services"
+	"mockapi/utils"
+)
+
+// AIUsageController reports the AI usage of projects against their budgets.
+type AIUsageController struct {
+	projectService services.ProjectServiceInterface
+	aiUsageService services.AIUsageServiceInterface
+}
+
+// NewAIUsageController creates a new AIUsageController.
+func NewAIUsageController(ps services.ProjectServiceInterface, aus services.AIUsageServiceInterface) *AIUsageController {
+	return &AIUsageController{projectService: ps, aiUsageService: aus}
+}
+
+// GetUsage handles GET /project/:projectSlug/ai-usage
+// It returns the AI requests and tokens used by the project and its team in the current period.
+func (auc *AIUsageController) GetUsage(c *gin.Context) {
+	projectSlug := c.Param("projectSlug")
+	project, err := auc.projectService.GetProjectBySlug(projectSlug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project with slug '%s' not found.", projectSlug))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Error finding project: "+err.Error())
+		}
+		return
+	}
+
+	report, err := auc.aiUsageService.Usage(c.Request.Context(), services.AIScope{ProjectID: project.ID, TeamID: project.TeamID})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to read AI usage: "+err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, report)
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"mockapi/services"
+)
+
+// HealthController serves the liveness and readiness probes. They answer with plain JSON rather than
+// the API envelope so orchestrators can read them directly.
+type HealthController struct {
+	healthService services.HealthServiceInterface
+}
+
+// NewHealthController creates a new HealthController.
+func NewHealthController(hs services.HealthServiceInterface) *HealthController {
+	return &HealthController{healthService: hs}
+}
+
+// Livez handles GET /livez
+// It only reports that the process serves HTTP, so a dependency outage never gets the instance restarted.
+func (hc *HealthController) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": services.HealthStatusUp, "timestamp": time.Now()})
+}
+
+// Readyz handles GET /readyz
+// It checks every dependency and answers 503 when a required one is down, so traffic is routed elsewhere.
+func (hc *HealthController) Readyz(c *gin.Context) {
+	report := hc.healthService.Readiness(c.Request.Context())
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
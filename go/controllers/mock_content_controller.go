@@ -2,50 +2,69 @@ package controllers
 
 import (
 	// "bytes" // Removed unused import
+	"context"
+	"crypto/sha256"
 	"database/sql" // Added for requestLog.UrlID
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	// "github.com/golang-jwt/jwt/v4" // Not directly used in controller if middleware handles it
 	"gorm.io/gorm"
 
 	"mockapi/config"
 	"mockapi/dtos"
+	"mockapi/logging"
+	"mockapi/metrics"
 	"mockapi/models"
 	"mockapi/services"
+	"mockapi/tracing"
 	"mockapi/utils"
 )
 
 // MockContentController handles API endpoints related to creating and serving mock content.
 type MockContentController struct {
-	projectService     *services.ProjectService
-	mockContentService *services.MockContentService
-	urlService         *services.URLService
-	requestLogService  *services.RequestLogService
-	redisService       *services.RedisService
-	proxyService       *services.ProxyService // Added proxyService
-	fakerService       *services.FakerService // Added FakerService
+	projectService     services.ProjectServiceInterface
+	mockContentService services.MockContentServiceInterface
+	urlService         services.URLServiceInterface
+	requestLogService  services.RequestLogServiceInterface
+	store              services.KeyValueStore
+	proxyService       services.ProxyServiceInterface // Added proxyService
+	fakerService       services.FakerServiceInterface // Added FakerService
+	scenarioService    services.ScenarioServiceInterface
+	sequenceService    services.SequenceServiceInterface
+	resourceService    services.ResourceServiceInterface
+	rateLimitService   services.RateLimitServiceInterface
 	jwtSecret          string
 	config             config.Config
 }
 
 // NewMockContentController creates a new MockContentController.
 func NewMockContentController(
-	projService *services.ProjectService,
-	mcService *services.MockContentService,
-	uService *services.URLService,
-	rlService *services.RequestLogService,
-	rService *services.RedisService,
-	pService *services.ProxyService, // Added proxyService
-	fService *services.FakerService, // Added FakerService
+	projService services.ProjectServiceInterface,
+	mcService services.MockContentServiceInterface,
+	uService services.URLServiceInterface,
+	rlService services.RequestLogServiceInterface,
+	store services.KeyValueStore,
+	pService services.ProxyServiceInterface, // Added proxyService
+	fService services.FakerServiceInterface, // Added FakerService
+	sService services.ScenarioServiceInterface,
+	seqService services.SequenceServiceInterface,
+	resService services.ResourceServiceInterface,
+	rlimService services.RateLimitServiceInterface,
 	cfg config.Config,
 ) *MockContentController {
 	return &MockContentController{
@@ -53,9 +72,13 @@ func NewMockContentController(
 		mockContentService: mcService,
 		urlService:         uService,
 		requestLogService:  rlService,
-		redisService:       rService,
+		store:              store,
 		proxyService:       pService,         // Added proxyService
 		fakerService:       fService,         // Added FakerService
+		scenarioService:    sService,
+		sequenceService:    seqService,
+		resourceService:    resService,
+		rateLimitService:   rlimService,
 		jwtSecret:          cfg.JWTSecretKey, // Store JWT secret from config
 		config:             cfg,
 	}
@@ -80,6 +103,10 @@ func (mcc *MockContentController) SaveMockContent(c *gin.Context) {
 		}
 		return
 	}
+	if mcc.projectService.IsReadOnly(project) {
+		utils.ErrorResponse(c, http.StatusForbidden, fmt.Sprintf("Project '%s' is managed by mock definition files and is read-only.", projectSlug))
+		return
+	}
 
 	existingURL, err := mcc.urlService.FindByProjectIDAndURL(project.ID, dto.URLData.URL)
 	if err != nil && err != gorm.ErrRecordNotFound {
@@ -98,25 +125,54 @@ func (mcc *MockContentController) SaveMockContent(c *gin.Context) {
 		URL:         dto.URLData.URL,
 		Status:      dto.URLData.Status,
 	}
+	if dto.URLData.ResponseMode != nil {
+		newURL.ResponseMode = *dto.URLData.ResponseMode
+	}
+	if dto.URLData.SequenceEnd != nil {
+		newURL.SequenceEnd = *dto.URLData.SequenceEnd
+	}
+	if dto.URLData.SequencePerClient != nil {
+		newURL.SequencePerClient = *dto.URLData.SequencePerClient
+	}
+	if dto.URLData.RateLimit != nil {
+		if err := services.ApplyRateLimitDTO(&newURL.RateLimit, *dto.URLData.RateLimit); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
-	if err := mcc.urlService.CreateURL(newURL, project.ID); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create URL: "+err.Error())
+	if err := services.ApplyResponseSchema(newURL, dto.URLData.ResponseSchema); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	if dto.URLData.StrictJSON != nil {
+		newURL.StrictJSON = *dto.URLData.StrictJSON
+	}
 
 	var mockContentsToSave []models.MockContent
 	for _, mcDto := range dto.MockContentList {
 		content := models.MockContent{
-			UrlID:       newURL.ID,
 			Name:        mcDto.Name,
 			Description: utils.StringPointerToString(mcDto.Description),
 			// Data will be set based on DslData or static Data
-			Randomness: utils.Int64PointerToInt64(mcDto.Randomness),
-			Latency:    utils.Int64PointerToInt64(mcDto.Latency),
+			Randomness:    utils.Int64PointerToInt64(mcDto.Randomness),
+			Latency:       utils.Int64PointerToInt64(mcDto.Latency),
+			ScenarioName:  utils.StringPointerToString(mcDto.ScenarioName),
+			RequiredState: utils.StringPointerToString(mcDto.RequiredState),
+			NewState:      utils.StringPointerToString(mcDto.NewState),
+		}
+		if mcDto.Status != nil {
+			content.Status = *mcDto.Status
+		}
+		matcher, err := services.ValidateRequestMatcher(mcDto.Matcher)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Mock content '%s': %s", content.Name, err.Error()))
+			return
 		}
+		content.Matcher = matcher
 
 		if mcDto.DslData != nil && *mcDto.DslData != "" {
-			processedData, err := mcc.fakerService.ProcessDSL(*mcDto.DslData)
+			processedData, err := mcc.fakerService.ProcessDSL(c.Request.Context(), *mcDto.DslData)
 			if err != nil {
 				utils.ErrorResponse(c, http.StatusInternalServerError, fmt.Sprintf("Failed to process DSL '%s': %s", *mcDto.DslData, err.Error()))
 				return // Important to stop processing for this request
@@ -129,14 +185,13 @@ func (mcc *MockContentController) SaveMockContent(c *gin.Context) {
 		mockContentsToSave = append(mockContentsToSave, content)
 	}
 
-	if len(mockContentsToSave) > 0 {
-		savedMCs, err := mcc.mockContentService.SaveMockContentList(mockContentsToSave, newURL.ID)
-		if err != nil {
-			_ = mcc.urlService.DeleteURL(newURL.ID) // Attempt to clean up, ignore error for now
-			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to save mock contents: "+err.Error())
-			return
+	// The URL is created only once every DSL template processed, so a bad template leaves nothing behind.
+	// The service validates the variants and saves them with the URL in one transaction.
+	if err := mcc.urlService.CreateURLWithMockContents(newURL, project.ID, mockContentsToSave); err != nil {
+		if !mockContentValidationFailed(c, err) {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create URL: "+err.Error())
 		}
-		newURL.MockContents = savedMCs
+		return
 	}
 
 	responseDTO := struct {
@@ -172,6 +227,10 @@ func (mcc *MockContentController) UpdateMockContent(c *gin.Context) {
 		}
 		return
 	}
+	if mcc.projectService.IsReadOnly(project) {
+		utils.ErrorResponse(c, http.StatusForbidden, fmt.Sprintf("Project '%s' is managed by mock definition files and is read-only.", projectSlug))
+		return
+	}
 
 	urlToUpdate, err := mcc.urlService.GetURLByID(uint(urlID))
 	if err != nil {
@@ -194,16 +253,28 @@ func (mcc *MockContentController) UpdateMockContent(c *gin.Context) {
 			Name:        utils.StringPointerToString(mcDto.Name),
 			Description: utils.StringPointerToString(mcDto.Description),
 			// Data will be set based on DslData or static Data
-			Randomness: utils.Int64PointerToInt64(mcDto.Randomness),
-			Latency:    utils.Int64PointerToInt64(mcDto.Latency),
+			Randomness:    utils.Int64PointerToInt64(mcDto.Randomness),
+			Latency:       utils.Int64PointerToInt64(mcDto.Latency),
+			ScenarioName:  utils.StringPointerToString(mcDto.ScenarioName),
+			RequiredState: utils.StringPointerToString(mcDto.RequiredState),
+			NewState:      utils.StringPointerToString(mcDto.NewState),
+		}
+		if mcDto.Status != nil {
+			content.Status = *mcDto.Status
 		}
+		matcher, err := services.ValidateRequestMatcher(mcDto.Matcher)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Mock content '%s': %s", content.Name, err.Error()))
+			return
+		}
+		content.Matcher = matcher
 		if mcDto.ID != nil {
 			content.ID = *mcDto.ID
 			content.BaseModel.ID = *mcDto.ID
 		}
 
 		if mcDto.DslData != nil && *mcDto.DslData != "" {
-			processedData, err := mcc.fakerService.ProcessDSL(*mcDto.DslData)
+			processedData, err := mcc.fakerService.ProcessDSL(c.Request.Context(), *mcDto.DslData)
 			if err != nil {
 				utils.ErrorResponse(c, http.StatusInternalServerError, fmt.Sprintf("Failed to process DSL for update '%s': %s", *mcDto.DslData, err.Error()))
 				return
@@ -223,7 +294,9 @@ func (mcc *MockContentController) UpdateMockContent(c *gin.Context) {
 
 	updatedMCs, err := mcc.mockContentService.UpdateMockContentList(mockContentsToUpdate, uint(urlID))
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update mock contents: "+err.Error())
+		if !mockContentValidationFailed(c, err) {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update mock contents: "+err.Error())
+		}
 		return
 	}
 
@@ -231,7 +304,29 @@ func (mcc *MockContentController) UpdateMockContent(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, urlToUpdate)
 }
 
-// GetMockedJSON handles GET /mock/:teamSlug/:projectSlug/*wildcardPath
+// mockContentValidationFailed answers with 422 and the violations of every variant when err reports
+// invalid mock contents, or with 400 when the URL's schema is unusable. It returns false for other errors.
+func mockContentValidationFailed(c *gin.Context, err error) bool {
+	var validationErr *services.MockContentValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		utils.CustomResponse(c, http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "Mock contents do not match the URL's response schema or strict JSON mode.",
+			"errors":  validationErr.Variants,
+		})
+		return true
+	case errors.Is(err, services.ErrInvalidJSONSchema):
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return true
+	}
+	return false
+}
+
+// GetMockedJSON handles GET /api/v1/mock/:teamSlug/:projectSlug?url=<path> and any method on
+// /api/v1/serve/:teamSlug/:projectSlug/*wildcardPath
+// Paths without a mocked URL fall back to the project's in-memory CRUD resources, which also accept
+// POST, PUT, PATCH and DELETE.
 func (mcc *MockContentController) GetMockedJSON(c *gin.Context) {
 	teamSlug := c.Param("teamSlug")
 	projectSlug := c.Param("projectSlug")
@@ -250,8 +345,13 @@ func (mcc *MockContentController) GetMockedJSON(c *gin.Context) {
 		Method:    c.Request.Method,
 		URL:       c.Request.URL.String(),
 		IsProxied: false,
+		RequestID: logging.RequestID(c.Request.Context()),
 		CreatedAt: time.Now(),
 	}
+	var teamLabel, projectLabel string // Set once the project is resolved, so unknown slugs never become label values
+	defer func() { metrics.SetMockLabels(c, teamLabel, projectLabel, requestLog.IsProxied) }()
+	// Each stage of serving gets its own span under the request's span, to show where the time goes
+	ctx := c.Request.Context()
 
 	var actualPath string
 	var decodedParams dtos.GetMockedJSONParamsDTO
@@ -261,7 +361,7 @@ func (mcc *MockContentController) GetMockedJSON(c *gin.Context) {
 		decodedBytes, err := base64.URLEncoding.DecodeString(encodedPathParams)
 		if err == nil {
 			if errJson := json.Unmarshal(decodedBytes, &decodedParams); errJson != nil {
-				log.Printf("WARN: Failed to unmarshal decoded base64 params: %v. Raw: %s", errJson, string(decodedBytes))
+				slog.WarnContext(c.Request.Context(), "Failed to unmarshal decoded base64 params", "error", errJson, "raw", string(decodedBytes))
 			}
 			// Use the wildcard path from the URL
 			wildcardPath := c.Param("wildcardPath")
@@ -289,39 +389,58 @@ func (mcc *MockContentController) GetMockedJSON(c *gin.Context) {
 
 	requestLog.URL = actualPath
 
-	globalRateLimitKey := mcc.redisService.CreateRedisKey("ratelimit:global", c.ClientIP())
-	isGloballyLimited, rlErr := mcc.redisService.RateLimit(globalRateLimitKey, mcc.config.GlobalMaxAllowedRequests, int64(mcc.config.GlobalTimeWindowSeconds))
+	spanCtx, span := tracing.Start(ctx, "mock.rate_limit", attribute.String("mock.rate_limit.scope", "global"))
+	globalRateLimitKey := mcc.store.CreateRedisKey("ratelimit:global", c.ClientIP())
+	isGloballyLimited, rlErr := mcc.store.RateLimit(spanCtx, globalRateLimitKey, mcc.config.GlobalMaxAllowedRequests, int64(mcc.config.GlobalTimeWindowSeconds))
+	tracing.End(span, rlErr)
 	if rlErr != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Error checking global rate limit.")
-		mcc.finalizeRequestLog(requestLog, http.StatusInternalServerError, 0, 0)
+		mcc.finalizeRequestLog(ctx, requestLog, http.StatusInternalServerError, 0, 0)
 		return
 	}
 	if isGloballyLimited {
+		metrics.IncRateLimitRejection("global")
 		utils.ErrorResponse(c, http.StatusTooManyRequests, "Global rate limit exceeded.")
-		mcc.finalizeRequestLog(requestLog, http.StatusTooManyRequests, 0, 0)
+		mcc.finalizeRequestLog(ctx, requestLog, http.StatusTooManyRequests, 0, 0)
 		return
 	}
 
-	project, err := mcc.projectService.GetProjectByTeamSlugAndProjectSlug(teamSlug, projectSlug)
+	spanCtx, span = tracing.Start(ctx, "mock.resolve_project")
+	project, err := mcc.projectService.GetProjectByTeamSlugAndProjectSlug(spanCtx, teamSlug, projectSlug)
+	tracing.End(span, err)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		if err == gorm.ErrRecordNotFound {
 			statusCode = http.StatusNotFound
 		}
 		utils.ErrorResponse(c, statusCode, "Project not found or error fetching project: "+err.Error())
-		mcc.finalizeRequestLog(requestLog, statusCode, 0, 0)
+		mcc.finalizeRequestLog(ctx, requestLog, statusCode, 0, 0)
 		return
 	}
 	requestLog.ProjectID = project.ID
+	teamLabel, projectLabel = teamSlug, project.Slug
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("mock.project", project.Slug))
+	if project.IsExpired(time.Now()) {
+		// Expired free projects stop serving right away, even before the cleanup job removes them
+		utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project '%s' has expired.", project.Slug))
+		mcc.finalizeRequestLog(ctx, requestLog, http.StatusNotFound, 0, 0)
+		return
+	}
+
+	projectRateLimit, limited := mcc.enforceRateLimit(c, "project", project.ID, project.RateLimit, nil)
+	if limited {
+		mcc.finalizeRequestLog(ctx, requestLog, http.StatusTooManyRequests, project.ID, 0)
+		return
+	}
 
 	isForwardCall := decodedParams.IsForwardCall != nil && *decodedParams.IsForwardCall
 	userWantsForward := decodedParams.Forward != nil && *decodedParams.Forward
 
 	if project.IsForwardProxyActive && userWantsForward && !isForwardCall {
-		proxySettings, err := mcc.proxyService.GetForwardProxyByProjectID(project.ID)
+		proxySettings, err := mcc.proxyService.GetForwardProxyByProjectID(ctx, project.ID)
 		if err != nil {
 			utils.ErrorResponse(c, http.StatusInternalServerError, "Error fetching proxy settings.")
-			mcc.finalizeRequestLog(requestLog, http.StatusInternalServerError, project.ID, 0)
+			mcc.finalizeRequestLog(ctx, requestLog, http.StatusInternalServerError, project.ID, 0)
 			return
 		}
 		if proxySettings != nil && proxySettings.Domain != "" {
@@ -334,74 +453,289 @@ func (mcc *MockContentController) GetMockedJSON(c *gin.Context) {
 			newBase64Params := base64.URLEncoding.EncodeToString(newParamsBytes)
 			proxiedPath := fmt.Sprintf("/mock/%s/%s/%s%s", teamSlug, projectSlug, newBase64Params, actualPath)
 
-			httpClient := &http.Client{Timeout: 10 * time.Second}
-			req, err := http.NewRequest(c.Request.Method, targetURL+proxiedPath, c.Request.Body)
+			// The traced transport replaces the client's traceparent with the proxy span's, so the upstream joins this trace
+			proxyCtx, span := tracing.Start(ctx, "mock.proxy", attribute.String("mock.proxy.domain", proxySettings.Domain))
+			defer span.End()
+			httpClient := &http.Client{Timeout: 10 * time.Second, Transport: tracing.HTTPTransport(nil)}
+			req, err := http.NewRequestWithContext(proxyCtx, c.Request.Method, targetURL+proxiedPath, c.Request.Body)
 			if err != nil {
 				utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create proxy request: "+err.Error())
-				mcc.finalizeRequestLog(requestLog, http.StatusInternalServerError, project.ID, 0)
+				mcc.finalizeRequestLog(ctx, requestLog, http.StatusInternalServerError, project.ID, 0)
 				return
 			}
 			req.Header = c.Request.Header.Clone()
 
 			resp, err := httpClient.Do(req)
 			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 				utils.ErrorResponse(c, http.StatusBadGateway, "Failed to execute proxy request: "+err.Error())
-				mcc.finalizeRequestLog(requestLog, http.StatusBadGateway, project.ID, 0)
+				mcc.finalizeRequestLog(ctx, requestLog, http.StatusBadGateway, project.ID, 0)
 				return
 			}
 			defer resp.Body.Close()
 
 			for key, values := range resp.Header {
+				if key == "X-Request-Id" {
+					continue // The response already carries this request's ID
+				}
 				for _, value := range values {
 					c.Writer.Header().Add(key, value)
 				}
 			}
 			c.Writer.WriteHeader(resp.StatusCode)
 			_, _ = io.Copy(c.Writer, resp.Body)
-			mcc.finalizeRequestLog(requestLog, resp.StatusCode, project.ID, 0)
+			mcc.finalizeRequestLog(ctx, requestLog, resp.StatusCode, project.ID, 0)
 			return
 		}
 	}
 
-	urlData, err := mcc.urlService.GetURLByTeamSlugProjectSlugAndPath(teamSlug, projectSlug, actualPath)
+	spanCtx, span = tracing.Start(ctx, "mock.resolve_url")
+	urlData, err := mcc.urlService.ResolveURL(spanCtx, teamSlug, projectSlug, actualPath)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		tracing.End(span, nil) // Not an error: the path may still be served by a resource
+	} else {
+		tracing.End(span, err)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) && mcc.serveResource(c, project, actualPath, requestLog) {
+		return
+	}
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if err == gorm.ErrRecordNotFound {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
 			statusCode = http.StatusNotFound
 		}
 		utils.ErrorResponse(c, statusCode, "URL not found or error fetching URL: "+err.Error())
-		mcc.finalizeRequestLog(requestLog, statusCode, project.ID, 0)
+		mcc.finalizeRequestLog(ctx, requestLog, statusCode, project.ID, 0)
 		return
 	}
 	requestLog.UrlID = sql.NullInt64{Int64: int64(urlData.ID), Valid: true}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("mock.url_id", int64(urlData.ID)))
+
+	if _, limited := mcc.enforceRateLimit(c, "url", urlData.ID, urlData.RateLimit, projectRateLimit); limited {
+		mcc.finalizeRequestLog(ctx, requestLog, http.StatusTooManyRequests, project.ID, urlData.ID)
+		return
+	}
 
 	if len(urlData.MockContents) == 0 {
 		utils.ErrorResponse(c, http.StatusNotFound, "No mock content available for this URL.")
-		mcc.finalizeRequestLog(requestLog, http.StatusNotFound, project.ID, urlData.ID)
+		mcc.finalizeRequestLog(ctx, requestLog, http.StatusNotFound, project.ID, urlData.ID)
+		return
+	}
+
+	spanCtx, span = tracing.Start(ctx, "mock.select_content")
+	defer span.End() // Ends the span on the error returns below; ending it again is a no-op
+	sessionKey := ScenarioSessionKey(c, mcc.config)
+	candidates, err := services.FilterMockContentsByRequest(urlData.MockContents, c.Request)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Error reading request: "+err.Error())
+		mcc.finalizeRequestLog(ctx, requestLog, http.StatusBadRequest, project.ID, urlData.ID)
+		return
+	}
+	if len(candidates) == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "No mock content matches the request.")
+		mcc.finalizeRequestLog(ctx, requestLog, http.StatusNotFound, project.ID, urlData.ID)
+		return
+	}
+	candidates, err = mcc.scenarioService.SelectCandidates(spanCtx, project.ID, sessionKey, candidates)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Error reading scenario state: "+err.Error())
+		mcc.finalizeRequestLog(ctx, requestLog, http.StatusInternalServerError, project.ID, urlData.ID)
+		return
+	}
+	if len(candidates) == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "No mock content matches the current scenario state.")
+		mcc.finalizeRequestLog(ctx, requestLog, http.StatusNotFound, project.ID, urlData.ID)
 		return
 	}
-	selectedMock := mcc.mockContentService.SelectRandomMockContent(urlData.MockContents)
+
+	var selectedMock *models.MockContent
+	if urlData.ResponseMode == models.ResponseModeSequence {
+		clientKey := ""
+		if urlData.SequencePerClient {
+			clientKey = sessionKey
+		}
+		selectedMock, err = mcc.sequenceService.NextMockContent(spanCtx, urlData, clientKey, candidates)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Error advancing response sequence: "+err.Error())
+			mcc.finalizeRequestLog(ctx, requestLog, http.StatusInternalServerError, project.ID, urlData.ID)
+			return
+		}
+	} else {
+		selectedMock = mcc.mockContentService.SelectRandomMockContent(candidates)
+	}
 	if selectedMock == nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to select mock content.")
-		mcc.finalizeRequestLog(requestLog, http.StatusInternalServerError, project.ID, urlData.ID)
+		mcc.finalizeRequestLog(ctx, requestLog, http.StatusInternalServerError, project.ID, urlData.ID)
 		return
 	}
+	if err := mcc.scenarioService.ApplyTransition(spanCtx, project.ID, sessionKey, selectedMock); err != nil {
+		slog.ErrorContext(ctx, "Failed to transition scenario", "scenario", selectedMock.ScenarioName, "error", err)
+	}
+
+	span.End()
 
+	_, span = tracing.Start(ctx, "mock.simulate_latency", attribute.Int64("mock.latency_ms", selectedMock.Latency))
 	mcc.mockContentService.SimulateLatency(selectedMock.Latency)
-	_ = mcc.urlService.IncrementRequestStats(urlData.ID)
+	span.End()
+	_ = mcc.urlService.IncrementRequestStats(ctx, urlData.ID)
 
 	var jsonOutput interface{}
-	responseStatusCode := mcc.getStatusCodeInt(urlData.Status) // Use helper for status code
+	responseStatus := urlData.Status
+	if selectedMock.Status != "" {
+		responseStatus = selectedMock.Status // Per-variant override
+	}
+	responseStatusCode := mcc.getStatusCodeInt(responseStatus) // Use helper for status code
 
 	if err := json.Unmarshal([]byte(selectedMock.Data), &jsonOutput); err != nil {
 		c.Data(responseStatusCode, "text/plain; charset=utf-8", []byte(selectedMock.Data)) // Corrected charset
 	} else {
 		c.JSON(responseStatusCode, jsonOutput)
 	}
-	mcc.finalizeRequestLog(requestLog, responseStatusCode, project.ID, urlData.ID)
+	mcc.finalizeRequestLog(ctx, requestLog, responseStatusCode, project.ID, urlData.ID)
+}
+
+// enforceRateLimit counts the request against a project or URL rate limit and sets the X-RateLimit-* headers,
+// reporting whichever of this result and previous is more restrictive. When the limit is exceeded it writes
+// a 429 response with Retry-After and returns true.
+func (mcc *MockContentController) enforceRateLimit(c *gin.Context, scope string, scopeID uint, policy models.RateLimitPolicy, previous *services.RateLimitResult) (*services.RateLimitResult, bool) {
+	spanCtx, span := tracing.Start(c.Request.Context(), "mock.rate_limit", attribute.String("mock.rate_limit.scope", scope))
+	result, err := mcc.rateLimitService.Check(spanCtx, scope, scopeID, policy, rateLimitClientKey(c, policy))
+	tracing.End(span, err)
+	if err != nil {
+		// Fail open: a broken limiter should not take the mocks down
+		slog.ErrorContext(c.Request.Context(), "Failed to check rate limit", "scope", scope, "error", err)
+		return previous, false
+	}
+	reported := services.MostRestrictiveRateLimit(previous, result)
+	if reported == nil {
+		return nil, false
+	}
+
+	resetSeconds := int64(math.Ceil(reported.ResetAfter.Seconds()))
+	c.Header("X-RateLimit-Limit", strconv.Itoa(reported.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(reported.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix()+resetSeconds, 10))
+	if result != nil && result.Limited {
+		metrics.IncRateLimitRejection(scope)
+		c.Header("Retry-After", strconv.FormatInt(resetSeconds, 10))
+		utils.ErrorResponse(c, http.StatusTooManyRequests, fmt.Sprintf("Rate limit of %d requests per %d seconds exceeded for this %s.", policy.Limit, policy.WindowSeconds, scope))
+		return reported, true
+	}
+	return reported, false
+}
+
+// rateLimitClientKey identifies the client a rate limit is counted for.
+// Header and token keys fall back to the client IP when the request does not carry them.
+func rateLimitClientKey(c *gin.Context, policy models.RateLimitPolicy) string {
+	switch policy.KeyBy {
+	case models.RateLimitKeyByHeader:
+		if value := c.GetHeader(policy.KeyHeader); value != "" {
+			return "header:" + value
+		}
+	case models.RateLimitKeyByToken:
+		token := c.Query("token")
+		if token == "" {
+			token = strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+		}
+		if token != "" {
+			sum := sha256.Sum256([]byte(token)) // Keeps long JWTs out of the Redis key
+			return "token:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// resourceReservedQueryParams are query parameters of the mock endpoint itself, never resource filters.
+var resourceReservedQueryParams = []string{"url", "ip", "token"}
+
+// serveResource answers a request from the project's in-memory CRUD resources.
+// It returns false, without writing a response, when no resource serves the path.
+func (mcc *MockContentController) serveResource(c *gin.Context, project *models.Project, path string, requestLog *models.RequestLog) bool {
+	resource, itemID, err := mcc.resourceService.MatchResource(c.Request.Context(), project.ID, path)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.ErrorContext(c.Request.Context(), "Failed to match resources", "project_id", project.ID, "error", err)
+		}
+		return false
+	}
+
+	status, body, err := mcc.handleResourceRequest(c, resource, itemID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrResourceItemNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrResourceItemExists):
+			status = http.StatusConflict
+		case status == 0:
+			status = http.StatusInternalServerError
+		}
+		utils.ErrorResponse(c, status, err.Error())
+	} else {
+		c.JSON(status, body)
+	}
+	mcc.finalizeRequestLog(c.Request.Context(), requestLog, status, project.ID, 0)
+	return true
+}
+
+// handleResourceRequest maps the HTTP method to a collection or item operation, json-server style.
+// On failure it may return a status code for the error; 0 means it is derived from the error.
+func (mcc *MockContentController) handleResourceRequest(c *gin.Context, resource *models.Resource, itemID string) (int, interface{}, error) {
+	readItem := func() (services.ResourceItem, error) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		return services.DecodeResourceItem(body)
+	}
+
+	if itemID == "" {
+		switch c.Request.Method {
+		case http.MethodGet:
+			query := services.ParseResourceQuery(c.Request.URL.Query(), resourceReservedQueryParams...)
+			items, total, err := mcc.resourceService.ListItems(c.Request.Context(), resource, query)
+			if err != nil {
+				return 0, nil, err
+			}
+			c.Header("X-Total-Count", strconv.Itoa(total))
+			return http.StatusOK, items, nil
+		case http.MethodPost:
+			item, err := readItem()
+			if err != nil {
+				return http.StatusBadRequest, nil, err
+			}
+			created, err := mcc.resourceService.CreateItem(c.Request.Context(), resource, item)
+			return http.StatusCreated, created, err
+		}
+		return http.StatusMethodNotAllowed, nil, fmt.Errorf("method %s is not allowed on collection '%s'", c.Request.Method, resource.Path)
+	}
+
+	switch c.Request.Method {
+	case http.MethodGet:
+		item, err := mcc.resourceService.GetItem(c.Request.Context(), resource, itemID)
+		return http.StatusOK, item, err
+	case http.MethodPut, http.MethodPatch:
+		item, err := readItem()
+		if err != nil {
+			return http.StatusBadRequest, nil, err
+		}
+		var updated services.ResourceItem
+		if c.Request.Method == http.MethodPut {
+			updated, err = mcc.resourceService.ReplaceItem(c.Request.Context(), resource, itemID, item)
+		} else {
+			updated, err = mcc.resourceService.PatchItem(c.Request.Context(), resource, itemID, item)
+		}
+		return http.StatusOK, updated, err
+	case http.MethodDelete:
+		err := mcc.resourceService.DeleteItem(c.Request.Context(), resource, itemID)
+		return http.StatusOK, gin.H{}, err
+	}
+	return http.StatusMethodNotAllowed, nil, fmt.Errorf("method %s is not allowed on item '%s/%s'", c.Request.Method, resource.Path, itemID)
 }
 
-func (mcc *MockContentController) finalizeRequestLog(logEntry *models.RequestLog, statusCode int, projectID uint, urlID uint) {
+// finalizeRequestLog records the outcome of a served request. The log is written under the request's
+// trace but is not cancelled with it, so clients that disconnect early are still logged.
+func (mcc *MockContentController) finalizeRequestLog(ctx context.Context, logEntry *models.RequestLog, statusCode int, projectID uint, urlID uint) {
 	logEntry.Status = statusCode
 	if projectID != 0 {
 		logEntry.ProjectID = projectID
@@ -410,8 +744,8 @@ func (mcc *MockContentController) finalizeRequestLog(logEntry *models.RequestLog
 		logEntry.UrlID = sql.NullInt64{Int64: int64(urlID), Valid: true}
 	}
 
-	if err := mcc.requestLogService.SaveRequestLog(logEntry); err != nil {
-		log.Printf("ERROR: Failed to save request log: %v. Entry: %+v", err, logEntry)
+	if err := mcc.requestLogService.SaveRequestLog(context.WithoutCancel(ctx), logEntry); err != nil {
+		slog.ErrorContext(ctx, "Failed to save request log", "request_id", logEntry.RequestID, "project_id", logEntry.ProjectID, "error", err)
 	}
 }
 
@@ -500,7 +834,7 @@ func (mcc *MockContentController) getStatusCodeInt(statusCode models.StatusCode)
 	}
 	val, ok := statusMapping[statusCode]
 	if !ok {
-		log.Printf("Warning: Unmapped StatusCode '%s', defaulting to 200 OK", statusCode)
+		slog.Warn("Unmapped StatusCode, defaulting to 200 OK", "status_code", statusCode)
 		return http.StatusOK // Default if not found
 	}
 	return val
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"mockapi/services"
+	"mockapi/utils"
+)
+
+// MockDefinitionController exposes on-demand reconciliation of the mocks-as-code directory.
+type MockDefinitionController struct {
+	mockDefinitionService services.MockDefinitionServiceInterface
+}
+
+// NewMockDefinitionController creates a new MockDefinitionController.
+func NewMockDefinitionController(mds services.MockDefinitionServiceInterface) *MockDefinitionController {
+	return &MockDefinitionController{mockDefinitionService: mds}
+}
+
+// ReloadMockDefinitions handles POST /mocks/reload
+func (mdc *MockDefinitionController) ReloadMockDefinitions(c *gin.Context) {
+	if mdc.mockDefinitionService == nil || !mdc.mockDefinitionService.Configured() {
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, "Mock definitions directory (MOCKS_DIR) is not configured.")
+		return
+	}
+
+	summary, err := mdc.mockDefinitionService.Reconcile()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reconcile mock definitions: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, summary)
+}
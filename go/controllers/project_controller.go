@@ -1,11 +1,14 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"mockapi/dtos"
+	"mockapi/middleware"
 	"mockapi/models"
 	"mockapi/services"
 	"mockapi/utils"
@@ -16,18 +19,18 @@ import (
 
 // ProjectController handles project-related API endpoints.
 type ProjectController struct {
-	projectService     *services.ProjectService
-	randomWordsService *services.RandomWordsService
-	requestLogService  *services.RequestLogService
-	teamService        *services.TeamService // Added for potential default team logic
+	projectService     services.ProjectServiceInterface
+	randomWordsService services.RandomWordsServiceInterface
+	requestLogService  services.RequestLogServiceInterface
+	teamService        services.TeamServiceInterface // Added for potential default team logic
 }
 
 // NewProjectController creates a new ProjectController.
 func NewProjectController(
-	ps *services.ProjectService,
-	rws *services.RandomWordsService,
-	rls *services.RequestLogService,
-	ts *services.TeamService,
+	ps services.ProjectServiceInterface,
+	rws services.RandomWordsServiceInterface,
+	rls services.RequestLogServiceInterface,
+	ts services.TeamServiceInterface,
 ) *ProjectController {
 	return &ProjectController{
 		projectService:     ps,
@@ -101,6 +104,7 @@ func (pc *ProjectController) CreateFreeProject(c *gin.Context) {
 		Slug:        slug,
 		Description: description,
 		TeamID:      teamID, // Assign to the determined team
+		ExpiresAt:   pc.projectService.FreeProjectExpiry(),
 		// ChannelID will be auto-generated by service if not set
 	}
 
@@ -128,9 +132,10 @@ func (pc *ProjectController) CreateFreeFastForwardProject(c *gin.Context) {
 	// }
 
 	project := &models.Project{
-		Name:   projectName,
-		Slug:   slug,
-		TeamID: teamID, // Assign to the default team
+		Name:      projectName,
+		Slug:      slug,
+		TeamID:    teamID, // Assign to the default team
+		ExpiresAt: pc.projectService.FreeProjectExpiry(),
 		// ChannelID will be auto-generated by service
 	}
 
@@ -142,6 +147,63 @@ func (pc *ProjectController) CreateFreeFastForwardProject(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusCreated, project)
 }
 
+// CloneProject handles POST /project/:projectSlug/clone
+func (pc *ProjectController) CloneProject(c *gin.Context) {
+	projectSlug := c.Param("projectSlug")
+
+	// The body is optional; an empty request clones into a randomly generated slug.
+	var dto dtos.CloneProjectDTO
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+			return
+		}
+	}
+
+	source, err := pc.projectService.GetProjectBySlug(projectSlug)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project with slug '%s' not found.", projectSlug))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Error finding project: "+err.Error())
+		}
+		return
+	}
+
+	var slug string
+	if dto.Slug != nil && *dto.Slug != "" {
+		slug = strings.ToLower(*dto.Slug)
+		if pc.randomWordsService.IsSlugDisallowed(slug) {
+			utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Project slug '%s' is disallowed.", slug))
+			return
+		}
+		existingProject, err := pc.projectService.GetProjectBySlug(slug)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Error checking for existing project: "+err.Error())
+			return
+		}
+		if existingProject != nil {
+			utils.ErrorResponse(c, http.StatusConflict, fmt.Sprintf("Project with slug '%s' already exists.", slug))
+			return
+		}
+	} else {
+		slug = pc.randomWordsService.GetRandomSlug()
+	}
+
+	projectName := utils.Unslug(slug)
+	if dto.Name != nil && *dto.Name != "" {
+		projectName = *dto.Name
+	}
+
+	clone, err := pc.projectService.CloneProject(source.ID, slug, projectName)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to clone project: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, clone)
+}
+
 // GetProjectBySlug handles GET /project/:projectSlug
 func (pc *ProjectController) GetProjectBySlug(c *gin.Context) {
 	projectSlug := c.Param("projectSlug")
@@ -170,3 +232,169 @@ func (pc *ProjectController) GetProjectBySlug(c *gin.Context) {
 	// In a real app, you'd structure the response DTO.
 	utils.SuccessResponse(c, http.StatusOK, project)
 }
+
+// ExtendProject handles POST /project/:projectSlug/extend
+// It restarts the lifetime of a free project that has not expired yet.
+func (pc *ProjectController) ExtendProject(c *gin.Context) {
+	project, ok := pc.expiringProject(c)
+	if !ok {
+		return
+	}
+
+	if err := pc.projectService.ExtendProject(project, middleware.CallerFromContext(c)); err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			utils.ErrorResponse(c, http.StatusForbidden, "You may not extend this project.")
+		} else if errors.Is(err, services.ErrFreeProjectsDoNotExpire) {
+			utils.ErrorResponse(c, http.StatusConflict, "Free projects do not expire, so they cannot be extended.")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to extend project: "+err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, project)
+}
+
+// ClaimProject handles POST /project/:projectSlug/claim
+// It moves a free project that has not expired yet into an existing team, after which it no longer expires.
+func (pc *ProjectController) ClaimProject(c *gin.Context) {
+	var dto dtos.ClaimProjectDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	project, ok := pc.expiringProject(c)
+	if !ok {
+		return
+	}
+
+	if err := pc.projectService.ClaimProject(project, dto.TeamSlug, middleware.CallerFromContext(c)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Team with slug '%s' not found.", dto.TeamSlug))
+		} else if errors.Is(err, services.ErrForbidden) {
+			utils.ErrorResponse(c, http.StatusForbidden, fmt.Sprintf("You may not claim projects into team '%s'.", dto.TeamSlug))
+		} else if errors.Is(err, services.ErrProjectSlugTaken) {
+			utils.ErrorResponse(c, http.StatusConflict, fmt.Sprintf("Team '%s' already has a project with slug '%s'.", dto.TeamSlug, project.Slug))
+		} else if errors.Is(err, services.ErrProjectDoesNotExpire) {
+			utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Project '%s' does not expire.", project.Slug))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to claim project: "+err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, project)
+}
+
+// expiringProject loads the project named in the path and checks that it is a free project that has not
+// expired yet. It writes the error response and returns false otherwise.
+func (pc *ProjectController) expiringProject(c *gin.Context) (*models.Project, bool) {
+	projectSlug := c.Param("projectSlug")
+	project, err := pc.projectService.GetProjectBySlug(projectSlug)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project with slug '%s' not found.", projectSlug))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve project: "+err.Error())
+		}
+		return nil, false
+	}
+	if project.ExpiresAt == nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("Project '%s' does not expire.", project.Slug))
+		return nil, false
+	}
+	if project.IsExpired(time.Now()) {
+		utils.ErrorResponse(c, http.StatusGone, fmt.Sprintf("Project '%s' has expired.", project.Slug))
+		return nil, false
+	}
+	return project, true
+}
+
+// authorizeProject checks that the authenticated caller may manage the project.
+// It writes the error response and returns false otherwise.
+func (pc *ProjectController) authorizeProject(c *gin.Context, project *models.Project) bool {
+	if err := pc.projectService.AuthorizeProject(middleware.CallerFromContext(c), project); err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			utils.ErrorResponse(c, http.StatusForbidden, fmt.Sprintf("You may not manage project '%s'.", project.Slug))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to authorize request: "+err.Error())
+		}
+		return false
+	}
+	return true
+}
+
+// UpdateProjectLogRetention handles PUT /project/:projectSlug/log-retention
+func (pc *ProjectController) UpdateProjectLogRetention(c *gin.Context) {
+	projectSlug := c.Param("projectSlug")
+
+	var dto dtos.LogRetentionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	project, err := pc.projectService.GetProjectBySlug(projectSlug)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project with slug '%s' not found.", projectSlug))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve project: "+err.Error())
+		}
+		return
+	}
+	if !pc.authorizeProject(c, project) {
+		return
+	}
+	if pc.projectService.IsReadOnly(project) {
+		utils.ErrorResponse(c, http.StatusForbidden, fmt.Sprintf("Project '%s' is managed by mock definition files and is read-only.", project.Slug))
+		return
+	}
+
+	if err := pc.projectService.UpdateLogRetention(project, dto); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update log retention: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, project.LogRetention)
+}
+
+// UpdateProjectRateLimit handles PATCH /project/:projectSlug/rate-limit
+func (pc *ProjectController) UpdateProjectRateLimit(c *gin.Context) {
+	projectSlug := c.Param("projectSlug")
+
+	var dto dtos.RateLimitDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	project, err := pc.projectService.GetProjectBySlug(projectSlug)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project with slug '%s' not found.", projectSlug))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve project: "+err.Error())
+		}
+		return
+	}
+	if !pc.authorizeProject(c, project) {
+		return
+	}
+	if pc.projectService.IsReadOnly(project) {
+		utils.ErrorResponse(c, http.StatusForbidden, fmt.Sprintf("Project '%s' is managed by mock definition files and is read-only.", project.Slug))
+		return
+	}
+
+	if err := pc.projectService.UpdateRateLimit(project, dto); err != nil {
+		if errors.Is(err, services.ErrInvalidRateLimit) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update rate limit: "+err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, project.RateLimit)
+}
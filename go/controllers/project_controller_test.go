@@ -0,0 +1,64 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"mockapi/controllers"
+	"mockapi/models"
+	"mockapi/services"
+)
+
+func TestClaimProjectErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	soon := time.Now().Add(time.Hour)
+	project := &models.Project{Name: "Shop", Slug: "shop", ExpiresAt: &soon}
+	projectService := &services.MockProjectService{
+		GetProjectBySlugFunc: func(slug string) (*models.Project, error) {
+			return project, nil
+		},
+	}
+	controller := controllers.NewProjectController(projectService, nil, nil, nil)
+	router := gin.New()
+	router.POST("/project/:projectSlug/claim", controller.ClaimProject)
+	claim := func() *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/project/shop/claim", strings.NewReader(`{"team_slug": "acme"}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	testCases := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{"slug_taken", fmt.Errorf("claim: %w", services.ErrProjectSlugTaken), http.StatusConflict},
+		{"forbidden", fmt.Errorf("%w: not a member", services.ErrForbidden), http.StatusForbidden},
+		{"does_not_expire", fmt.Errorf("claim: %w", services.ErrProjectDoesNotExpire), http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			projectService.ClaimProjectFunc = func(*models.Project, string, services.Caller) error {
+				return tc.err
+			}
+			resp := claim()
+			assert.Equal(t, tc.expectedCode, resp.Code, resp.Body.String())
+		})
+	}
+
+	t.Run("permanent_project", func(t *testing.T) {
+		project.ExpiresAt = nil
+		defer func() { project.ExpiresAt = &soon }()
+		resp := claim()
+		assert.Equal(t, http.StatusBadRequest, resp.Code, resp.Body.String())
+	})
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -15,12 +16,12 @@ import (
 
 // ProxyController handles forward proxy related API endpoints.
 type ProxyController struct {
-	proxyService   *services.ProxyService
-	projectService *services.ProjectService
+	proxyService   services.ProxyServiceInterface
+	projectService services.ProjectServiceInterface
 }
 
 // NewProxyController creates a new ProxyController.
-func NewProxyController(ps *services.ProxyService, prjService *services.ProjectService) *ProxyController {
+func NewProxyController(ps services.ProxyServiceInterface, prjService services.ProjectServiceInterface) *ProxyController {
 	return &ProxyController{proxyService: ps, projectService: prjService}
 }
 
@@ -34,15 +35,19 @@ func (pc *ProxyController) SaveForwardProxy(c *gin.Context) {
 	}
 
 	// Validate that the project exists
-	_, err := pc.projectService.GetProjectByID(dto.ProjectID)
+	project, err := pc.projectService.GetProjectByID(dto.ProjectID)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project with ID %d not found.", dto.ProjectID))
 		} else {
 			utils.ErrorResponse(c, http.StatusInternalServerError, "Error verifying project: "+err.Error())
 		}
 		return
 	}
+	if pc.projectService.IsReadOnly(project) {
+		utils.ErrorResponse(c, http.StatusForbidden, fmt.Sprintf("Project '%s' is managed by mock definition files and is read-only.", project.Slug))
+		return
+	}
 
 	proxyModel := &models.ForwardProxy{
 		ProjectID: dto.ProjectID,
@@ -81,15 +86,19 @@ func (pc *ProxyController) UpdateForwardProxyActiveStatus(c *gin.Context) {
 	}
 
 	// Check if project exists
-	_, err = pc.projectService.GetProjectByID(uint(projectID))
+	project, err := pc.projectService.GetProjectByID(uint(projectID))
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project with ID %d not found.", projectID))
 		} else {
 			utils.ErrorResponse(c, http.StatusInternalServerError, "Error verifying project: "+err.Error())
 		}
 		return
 	}
+	if pc.projectService.IsReadOnly(project) {
+		utils.ErrorResponse(c, http.StatusForbidden, fmt.Sprintf("Project '%s' is managed by mock definition files and is read-only.", project.Slug))
+		return
+	}
 
 	// Check if forward proxy is configured for the project.
 	// It might not be an error if it's not, just that the active status cannot be set.
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"mockapi/services"
+	"mockapi/utils"
+)
+
+// RequestLogController exposes administrative operations on request logs.
+type RequestLogController struct {
+	projectService      services.ProjectServiceInterface
+	logRetentionService services.LogRetentionServiceInterface
+}
+
+// NewRequestLogController creates a new RequestLogController.
+func NewRequestLogController(ps services.ProjectServiceInterface, lrs services.LogRetentionServiceInterface) *RequestLogController {
+	return &RequestLogController{projectService: ps, logRetentionService: lrs}
+}
+
+// PurgeRequestLogs handles POST /admin/request-logs/purge
+// It applies the retention settings right away, to every project or only to the one named by ?project=slug,
+// and reports how many logs were removed.
+func (rlc *RequestLogController) PurgeRequestLogs(c *gin.Context) {
+	var (
+		summary *services.PurgeSummary
+		err     error
+	)
+	if projectSlug := c.Query("project"); projectSlug != "" {
+		project, findErr := rlc.projectService.GetProjectBySlug(projectSlug)
+		if findErr != nil {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project with slug '%s' not found.", projectSlug))
+			return
+		}
+		summary, err = rlc.logRetentionService.PurgeProject(project.ID)
+	} else {
+		summary, err = rlc.logRetentionService.Purge()
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to purge request logs: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, summary)
+}
@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"mockapi/dtos"
+	"mockapi/models"
+	"mockapi/services"
+	"mockapi/utils"
+)
+
+// ResourceController manages the in-memory CRUD resources of a project.
+type ResourceController struct {
+	projectService  services.ProjectServiceInterface
+	resourceService services.ResourceServiceInterface
+}
+
+// NewResourceController creates a new ResourceController.
+func NewResourceController(ps services.ProjectServiceInterface, rs services.ResourceServiceInterface) *ResourceController {
+	return &ResourceController{projectService: ps, resourceService: rs}
+}
+
+// GetResources handles GET /project/:projectSlug/resources
+func (rc *ResourceController) GetResources(c *gin.Context) {
+	project, ok := rc.findProject(c)
+	if !ok {
+		return
+	}
+
+	resources, err := rc.resourceService.GetResourcesByProjectID(c.Request.Context(), project.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list resources: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, resources)
+}
+
+// CreateResource handles POST /project/:projectSlug/resources
+func (rc *ResourceController) CreateResource(c *gin.Context) {
+	var dto dtos.ResourceCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	project, ok := rc.findWritableProject(c)
+	if !ok {
+		return
+	}
+
+	resource := &models.Resource{
+		Path:     dto.Path,
+		Name:     utils.StringPointerToString(dto.Name),
+		IDField:  utils.StringPointerToString(dto.IDField),
+		SeedData: string(dto.SeedData),
+	}
+	if _, err := services.ParseResourceSeed(resource.SeedData); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !rc.ensurePathAvailable(c, project.ID, 0, resource.Path) {
+		return
+	}
+
+	if err := rc.resourceService.CreateResource(resource, project.ID); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create resource: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, resource)
+}
+
+// UpdateResource handles PATCH /project/:projectSlug/resources/:resourceId
+func (rc *ResourceController) UpdateResource(c *gin.Context) {
+	var dto dtos.ResourceUpdateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	project, ok := rc.findWritableProject(c)
+	if !ok {
+		return
+	}
+	resource, ok := rc.findResource(c, project)
+	if !ok {
+		return
+	}
+
+	if dto.Path != nil {
+		resource.Path = *dto.Path
+	}
+	if dto.Name != nil {
+		resource.Name = *dto.Name
+	}
+	if dto.IDField != nil {
+		resource.IDField = *dto.IDField
+	}
+	if dto.SeedData != nil {
+		resource.SeedData = string(dto.SeedData)
+	}
+	if _, err := services.ParseResourceSeed(resource.SeedData); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dto.Path != nil && !rc.ensurePathAvailable(c, project.ID, resource.ID, resource.Path) {
+		return
+	}
+
+	if err := rc.resourceService.UpdateResource(resource); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update resource: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, resource)
+}
+
+// DeleteResource handles DELETE /project/:projectSlug/resources/:resourceId
+func (rc *ResourceController) DeleteResource(c *gin.Context) {
+	project, ok := rc.findWritableProject(c)
+	if !ok {
+		return
+	}
+	resource, ok := rc.findResource(c, project)
+	if !ok {
+		return
+	}
+
+	if err := rc.resourceService.DeleteResource(c.Request.Context(), resource); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete resource: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"deleted": resource.ID})
+}
+
+// ResetResources handles POST /project/:projectSlug/resources/reset and
+// POST /project/:projectSlug/resources/:resourceId/reset.
+// Resetting restores the collections to their seed data.
+func (rc *ResourceController) ResetResources(c *gin.Context) {
+	project, ok := rc.findProject(c)
+	if !ok {
+		return
+	}
+
+	var resourceID uint
+	if c.Param("resourceId") != "" {
+		resource, ok := rc.findResource(c, project)
+		if !ok {
+			return
+		}
+		resourceID = resource.ID
+	}
+
+	cleared, err := rc.resourceService.ResetResources(c.Request.Context(), project.ID, resourceID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reset resources: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"cleared": cleared})
+}
+
+func (rc *ResourceController) findProject(c *gin.Context) (*models.Project, bool) {
+	projectSlug := c.Param("projectSlug")
+	project, err := rc.projectService.GetProjectBySlug(projectSlug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project with slug '%s' not found.", projectSlug))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Error finding project: "+err.Error())
+		}
+		return nil, false
+	}
+	return project, true
+}
+
+func (rc *ResourceController) findWritableProject(c *gin.Context) (*models.Project, bool) {
+	project, ok := rc.findProject(c)
+	if !ok {
+		return nil, false
+	}
+	if rc.projectService.IsReadOnly(project) {
+		utils.ErrorResponse(c, http.StatusForbidden, fmt.Sprintf("Project '%s' is managed by mock definition files and is read-only.", project.Slug))
+		return nil, false
+	}
+	return project, true
+}
+
+// ensurePathAvailable rejects a collection path that another resource of the project already serves.
+// It returns false if a response has already been written.
+func (rc *ResourceController) ensurePathAvailable(c *gin.Context, projectID, resourceID uint, path string) bool {
+	existing, itemID, err := rc.resourceService.MatchResource(c.Request.Context(), projectID, path)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Error checking resource path: "+err.Error())
+		return false
+	}
+	if itemID == "" && existing.ID != resourceID {
+		utils.ErrorResponse(c, http.StatusConflict, fmt.Sprintf("A resource already serves path '%s'.", existing.Path))
+		return false
+	}
+	return true
+}
+
+func (rc *ResourceController) findResource(c *gin.Context, project *models.Project) (*models.Resource, bool) {
+	resourceID, err := strconv.ParseUint(c.Param("resourceId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid resource ID format.")
+		return nil, false
+	}
+	resource, err := rc.resourceService.GetResourceByID(project.ID, uint(resourceID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Resource with ID %d not found.", resourceID))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Error finding resource: "+err.Error())
+		}
+		return nil, false
+	}
+	return resource, true
+}
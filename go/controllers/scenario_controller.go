@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"mockapi/config"
+	"mockapi/services"
+	"mockapi/utils"
+)
+
+// ScenarioController exposes inspection and reset of stateful mock scenarios.
+type ScenarioController struct {
+	projectService  services.ProjectServiceInterface
+	scenarioService services.ScenarioServiceInterface
+}
+
+// NewScenarioController creates a new ScenarioController.
+func NewScenarioController(ps services.ProjectServiceInterface, ss services.ScenarioServiceInterface) *ScenarioController {
+	return &ScenarioController{projectService: ps, scenarioService: ss}
+}
+
+// ScenarioSessionKey returns the client session key used to separate scenario state.
+// It is read from the configured session header and falls back to a shared default session.
+func ScenarioSessionKey(c *gin.Context, cfg config.Config) string {
+	if key := c.GetHeader(cfg.ScenarioSessionHeader); key != "" {
+		return key
+	}
+	return services.DefaultScenarioSessionKey
+}
+
+// GetScenarios handles GET /project/:projectSlug/scenarios?session=<key>
+func (sc *ScenarioController) GetScenarios(c *gin.Context) {
+	projectSlug := c.Param("projectSlug")
+	project, err := sc.projectService.GetProjectBySlug(projectSlug)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project with slug '%s' not found.", projectSlug))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Error finding project: "+err.Error())
+		}
+		return
+	}
+
+	sessionKey := c.DefaultQuery("session", services.DefaultScenarioSessionKey)
+	scenarios, err := sc.scenarioService.ListScenarios(c.Request.Context(), project.ID, sessionKey)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list scenarios: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"session": sessionKey, "scenarios": scenarios})
+}
+
+// ResetScenarios handles DELETE /project/:projectSlug/scenarios and
+// DELETE /project/:projectSlug/scenarios/:scenarioName.
+// Without a session query parameter, the scenarios are reset for every session.
+func (sc *ScenarioController) ResetScenarios(c *gin.Context) {
+	projectSlug := c.Param("projectSlug")
+	project, err := sc.projectService.GetProjectBySlug(projectSlug)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("Project with slug '%s' not found.", projectSlug))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Error finding project: "+err.Error())
+		}
+		return
+	}
+
+	sessionKey := c.Query("session")
+	scenarioName := c.Param("scenarioName")
+	cleared, err := sc.scenarioService.ResetScenarios(c.Request.Context(), project.ID, sessionKey, scenarioName)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reset scenarios: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"cleared": cleared})
+}
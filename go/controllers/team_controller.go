@@ -10,11 +10,11 @@ import (
 
 // TeamController handles routes related to teams.
 type TeamController struct {
-	teamService *services.TeamService // Pointer to the service
+	teamService services.TeamServiceInterface
 }
 
 // NewTeamController creates a new TeamController.
-func NewTeamController(ts *services.TeamService) *TeamController {
+func NewTeamController(ts services.TeamServiceInterface) *TeamController {
 	return &TeamController{teamService: ts}
 }
 
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -14,12 +15,14 @@ import (
 
 // URLController handles URL-related API endpoints.
 type URLController struct {
-	urlService *services.URLService
+	urlService      services.URLServiceInterface
+	projectService  services.ProjectServiceInterface
+	sequenceService services.SequenceServiceInterface
 }
 
 // NewURLController creates a new URLController.
-func NewURLController(us *services.URLService) *URLController {
-	return &URLController{urlService: us}
+func NewURLController(us services.URLServiceInterface, ps services.ProjectServiceInterface, seqs services.SequenceServiceInterface) *URLController {
+	return &URLController{urlService: us, projectService: ps, sequenceService: seqs}
 }
 
 // UpdateURLInfo handles PATCH /url/:urlId
@@ -37,14 +40,20 @@ func (uc *URLController) UpdateURLInfo(c *gin.Context) {
 		return
 	}
 
+	if !uc.ensureURLWritable(c, uint(urlID)) {
+		return
+	}
+
 	// Ensure at least one field is being updated, or handle empty DTO.
 	// For now, service layer's UpdateURL will fetch the URL and update fields present in DTO.
 
 	updatedURL, err := uc.urlService.UpdateURL(uint(urlID), dto)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("URL with ID %d not found.", urlID))
-		} else {
+		} else if errors.Is(err, services.ErrInvalidRateLimit) {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		} else if !mockContentValidationFailed(c, err) {
 			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update URL: "+err.Error())
 		}
 		return
@@ -76,3 +85,55 @@ func (uc *URLController) GetURLDetails(c *gin.Context) {
 	// For now, returning the model directly.
 	utils.SuccessResponse(c, http.StatusOK, urlDetails)
 }
+
+// ResetURLSequence handles DELETE /url/:urlId/sequence?session=<key>
+// Without a session query parameter, the cursors of every client are reset.
+func (uc *URLController) ResetURLSequence(c *gin.Context) {
+	urlIDStr := c.Param("urlId")
+	urlID, err := strconv.ParseUint(urlIDStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid URL ID format.")
+		return
+	}
+
+	if _, err := uc.urlService.GetURLByID(uint(urlID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("URL with ID %d not found.", urlID))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve URL: "+err.Error())
+		}
+		return
+	}
+
+	cleared, err := uc.sequenceService.ResetSequence(c.Request.Context(), uint(urlID), c.Query("session"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reset sequence: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"cleared": cleared})
+}
+
+// ensureURLWritable rejects the request when the URL belongs to a read-only, file-managed project.
+// It returns false if a response has already been written.
+func (uc *URLController) ensureURLWritable(c *gin.Context, urlID uint) bool {
+	existingURL, err := uc.urlService.GetURLByID(urlID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, fmt.Sprintf("URL with ID %d not found.", urlID))
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve URL: "+err.Error())
+		}
+		return false
+	}
+	project, err := uc.projectService.GetProjectByID(existingURL.ProjectID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Error finding project: "+err.Error())
+		return false
+	}
+	if uc.projectService.IsReadOnly(project) {
+		utils.ErrorResponse(c, http.StatusForbidden, fmt.Sprintf("Project '%s' is managed by mock definition files and is read-only.", project.Slug))
+		return false
+	}
+	return true
+}
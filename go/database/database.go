@@ -2,36 +2,67 @@ package database
 
 import (
     "fmt"
-    "log"
+    "log/slog"
+    "net/url"
+    "strings"
+    "time"
+
+    "github.com/glebarez/sqlite"
     "gorm.io/driver/mysql"
+    "gorm.io/driver/postgres"
     "gorm.io/gorm"
     "gorm.io/gorm/logger"
+    otelgorm "gorm.io/plugin/opentelemetry/tracing"
     "mockapi/config"  // Assuming module name is mockapi
+    "mockapi/database/migrations"
+    "mockapi/logging"
     "mockapi/models"  // Assuming module name is mockapi
+    "mockapi/tracing"
+)
+
+// Supported values of DB_DRIVER.
+const (
+    DriverPostgres = "postgres"
+    DriverMySQL    = "mysql"
+    DriverSQLite   = "sqlite"
 )
 
+// DefaultSQLiteFile is the database file used by the sqlite driver when DB_NAME is empty.
+const DefaultSQLiteFile = "mockapi.db"
+
 var DB *gorm.DB
 
 // ConnectDB connects to the database using the provided configuration
-// and runs AutoMigrate.
+// and applies pending migrations. With DB_AUTO_MIGRATE set it also runs
+// AutoMigrate on the current models, which is meant for development only.
 func ConnectDB(cfg config.Config) {
     var err error
 
-    // MySQL DSN format: user:password@tcp(host:port)/dbname?parseTime=true
-    dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
-        cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
+    DB, err = Open(cfg)
+    if err != nil {
+        logging.Fatal("Failed to connect to database", "error", err)
+    }
+
+    slog.Info("Database connection established", "driver", DB.Dialector.Name())
 
-    DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-        Logger: logger.Default.LogMode(logger.Info),
-    })
+    if tracing.Enabled(cfg) {
+        // Query values are left out of the spans, they may contain mock payloads or client data
+        if err = DB.Use(otelgorm.NewPlugin(otelgorm.WithDBName(cfg.DBName), otelgorm.WithoutQueryVariables(), otelgorm.WithoutMetrics())); err != nil {
+            logging.Fatal("Failed to enable database tracing", "error", err)
+        }
+    }
 
-    if err != nil {
-        log.Fatalf("Failed to connect to database: %v", err)
+    if _, err = migrations.Up(DB); err != nil {
+        logging.Fatal("Failed to migrate database", "error", err)
     }
+    slog.Info("Database migrated")
 
-    log.Println("✅ Database connection established.")
+    if !cfg.DBAutoMigrate {
+        return
+    }
 
-    // Auto-migrate models
+    // Auto-migrate models (development only: it cannot drop, rename or backfill columns)
+    slog.Warn("DB_AUTO_MIGRATE is enabled; schema changes must still be added as migrations")
     err = DB.AutoMigrate(
         &models.Team{},
         &models.Project{},
@@ -39,11 +70,119 @@ func ConnectDB(cfg config.Config) {
         &models.Url{},
         &models.MockContent{},
         &models.RequestLog{},
+        &models.Resource{},
     )
     if err != nil {
-        log.Fatalf("Failed to auto-migrate database: %v", err)
+        logging.Fatal("Failed to auto-migrate database", "error", err)
+    }
+}
+
+// Open connects to the database selected by the configuration without migrating it.
+func Open(cfg config.Config) (*gorm.DB, error) {
+    dialector, err := Dialector(cfg)
+    if err != nil {
+        return nil, err
+    }
+    return gorm.Open(dialector, &gorm.Config{
+        Logger: logging.NewGormLogger(200 * time.Millisecond),
+    })
+}
+
+// OpenInMemory returns a migrated, throwaway sqlite database that lives in memory. It backs the real
+// services in tests, so the HTTP layer can be exercised without a database server.
+func OpenInMemory() (*gorm.DB, error) {
+    db, err := gorm.Open(sqlite.Open(":memory:?_pragma=foreign_keys(1)"), &gorm.Config{
+        Logger: logger.Default.LogMode(logger.Silent),
+    })
+    if err != nil {
+        return nil, err
+    }
+    sqlDB, err := db.DB()
+    if err != nil {
+        return nil, err
+    }
+    sqlDB.SetMaxOpenConns(1) // Every connection to :memory: would open a separate database
+    if _, err := migrations.Up(db); err != nil {
+        return nil, fmt.Errorf("failed to migrate in-memory database: %w", err)
+    }
+    return db, nil
+}
+
+// Dialector returns the GORM dialector for cfg.DBDriver with its DSN built from the DB_* settings.
+func Dialector(cfg config.Config) (gorm.Dialector, error) {
+    switch cfg.DBDriver {
+    case DriverPostgres:
+        return postgres.Open(PostgresDSN(cfg)), nil
+    case DriverMySQL:
+        dsn, err := MySQLDSN(cfg)
+        if err != nil {
+            return nil, err
+        }
+        return mysql.Open(dsn), nil
+    case DriverSQLite:
+        return sqlite.Open(SQLiteDSN(cfg)), nil
+    default:
+        return nil, fmt.Errorf("unsupported DB_DRIVER '%s' (use %s, %s or %s)", cfg.DBDriver, DriverPostgres, DriverMySQL, DriverSQLite)
+    }
+}
+
+// PostgresDSN builds a key/value DSN (host=... port=... user=... dbname=... sslmode=...).
+// Empty settings are left out so the driver's defaults apply.
+func PostgresDSN(cfg config.Config) string {
+    sslmode := cfg.DBSslmode
+    if sslmode == "" {
+        sslmode = "disable"
+    }
+    settings := [][2]string{
+        {"host", cfg.DBHost}, {"port", cfg.DBPort}, {"user", cfg.DBUser},
+        {"password", cfg.DBPassword}, {"dbname", cfg.DBName}, {"sslmode", sslmode},
+    }
+    parts := make([]string, 0, len(settings)+1)
+    for _, setting := range settings {
+        if setting[1] != "" {
+            parts = append(parts, setting[0]+"="+postgresValue(setting[1]))
+        }
+    }
+    return strings.Join(append(parts, "TimeZone=UTC"), " ")
+}
+
+// postgresValue quotes a DSN value so values with spaces or quotes survive parsing.
+func postgresValue(value string) string {
+    return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// MySQLDSN builds a DSN of the form user:password@tcp(host:port)/dbname?parseTime=true.
+// DB_SSLMODE is mapped onto the driver's tls parameter: disable (or empty) turns TLS off, allow/prefer
+// use TLS when the server offers it, require encrypts without verifying the certificate and
+// verify-ca/verify-full verify it.
+func MySQLDSN(cfg config.Config) (string, error) {
+    params := url.Values{}
+    params.Set("parseTime", "true")
+    params.Set("charset", "utf8mb4")
+    params.Set("loc", "UTC")
+    switch cfg.DBSslmode {
+    case "", "disable":
+    case "allow", "prefer":
+        params.Set("tls", "preferred")
+    case "require":
+        params.Set("tls", "skip-verify")
+    case "verify-ca", "verify-full":
+        params.Set("tls", "true")
+    default:
+        return "", fmt.Errorf("unsupported DB_SSLMODE '%s' for mysql", cfg.DBSslmode)
+    }
+    return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?%s",
+        cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName, params.Encode()), nil
+}
+
+// SQLiteDSN uses DB_NAME as the database file (":memory:" for a throwaway database) and enables
+// foreign keys and a busy timeout so concurrent requests wait for the write lock instead of failing.
+func SQLiteDSN(cfg config.Config) string {
+    file := cfg.DBName
+    if file == "" {
+        file = DefaultSQLiteFile
     }
-    log.Println("✅ Database migrated successfully.")
+    return file + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
 }
 
 // GetDB returns the current database instance.
@@ -0,0 +1,49 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"mockapi/config"
+	"mockapi/database"
+	"mockapi/models"
+)
+
+func TestPostgresDSN(t *testing.T) {
+	cfg := config.Config{DBHost: "db", DBPort: "5432", DBUser: "mock", DBPassword: "it's secret", DBName: "mockapi", DBSslmode: "require"}
+	assert.Equal(t, `host='db' port='5432' user='mock' password='it\'s secret' dbname='mockapi' sslmode='require' TimeZone=UTC`, database.PostgresDSN(cfg))
+
+	assert.Equal(t, "host='db' sslmode='disable' TimeZone=UTC", database.PostgresDSN(config.Config{DBHost: "db"}),
+		"empty settings are left to the driver's defaults")
+}
+
+func TestMySQLDSN(t *testing.T) {
+	cfg := config.Config{DBHost: "db", DBPort: "3306", DBUser: "mock", DBPassword: "secret", DBName: "mockapi"}
+	dsn, err := database.MySQLDSN(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "mock:secret@tcp(db:3306)/mockapi?charset=utf8mb4&loc=UTC&parseTime=true", dsn)
+
+	cfg.DBSslmode = "verify-full"
+	dsn, err = database.MySQLDSN(cfg)
+	require.NoError(t, err)
+	assert.Contains(t, dsn, "tls=true")
+
+	cfg.DBSslmode = "sometimes"
+	_, err = database.MySQLDSN(cfg)
+	assert.Error(t, err)
+}
+
+func TestDialector(t *testing.T) {
+	_, err := database.Dialector(config.Config{DBDriver: "oracle"})
+	assert.Error(t, err)
+
+	dialector, err := database.Dialector(config.Config{DBDriver: database.DriverSQLite, DBName: ":memory:"})
+	require.NoError(t, err)
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Team{}, &models.Project{}))
+	require.NoError(t, db.Create(&models.Team{Name: "Team", Slug: "team"}).Error)
+}
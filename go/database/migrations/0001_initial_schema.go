@@ -0,0 +1,153 @@
+package migrations
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration 1 creates the schema as AutoMigrate left it before versioned migrations were introduced.
+// It uses frozen copies of the models rather than the models package, so later model changes do not
+// alter what this migration does. On a database that was previously auto-migrated it only fills in
+// whatever is missing, which lets existing deployments adopt versioned migrations.
+// The types are exported only because GORM cannot resolve relations between unexported structs.
+
+type V1BaseModel struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+type V1RateLimitPolicy struct {
+	Limit         int    `gorm:"default:0;not null"`
+	WindowSeconds int64  `gorm:"default:60;not null"`
+	KeyBy         string `gorm:"type:varchar(20);default:ip;not null"`
+	KeyHeader     string `gorm:"type:varchar(100)"`
+	Algorithm     string `gorm:"type:varchar(20);default:fixed_window;not null"`
+}
+
+type V1Team struct {
+	V1BaseModel
+	Name     string      `gorm:"unique;not null"`
+	Slug     string      `gorm:"unique;not null"`
+	Projects []V1Project `gorm:"foreignKey:TeamID"`
+}
+
+func (V1Team) TableName() string { return "teams" }
+
+type V1Project struct {
+	V1BaseModel
+	Name                 string `gorm:"not null"`
+	Slug                 string `gorm:"unique;not null"`
+	ChannelID            string `gorm:"not null"`
+	Description          string
+	IsForwardProxyActive bool `gorm:"default:false"`
+	TeamID               uint
+	Team                 V1Team
+	ForwardProxy         *V1ForwardProxy   `gorm:"foreignKey:ProjectID"`
+	ManagedByFile        bool              `gorm:"default:false"`
+	RateLimit            V1RateLimitPolicy `gorm:"embedded;embeddedPrefix:rate_limit_"`
+}
+
+func (V1Project) TableName() string { return "projects" }
+
+type V1ForwardProxy struct {
+	V1BaseModel
+	Domain    string
+	ProjectID uint `gorm:"unique;not null"`
+	Project   V1Project
+}
+
+func (V1ForwardProxy) TableName() string { return "forward_proxies" }
+
+type V1Url struct {
+	V1BaseModel
+	Description       string
+	Name              string `gorm:"not null"`
+	Requests          sql.NullInt64
+	Time              sql.NullInt64
+	URL               string `gorm:"not null;index:idx_url_project,unique"`
+	Status            string `gorm:"type:varchar(50);not null"`
+	ProjectID         uint   `gorm:"index:idx_url_project,unique"`
+	Project           V1Project
+	MockContents      []V1MockContent   `gorm:"foreignKey:UrlID"`
+	ResponseMode      string            `gorm:"type:varchar(20);default:random;not null"`
+	SequenceEnd       string            `gorm:"type:varchar(20);default:repeat_last;not null"`
+	SequencePerClient bool              `gorm:"default:false"`
+	RateLimit         V1RateLimitPolicy `gorm:"embedded;embeddedPrefix:rate_limit_"`
+}
+
+func (V1Url) TableName() string { return "urls" }
+
+type V1MockContent struct {
+	V1BaseModel
+	Randomness    int64 `gorm:"default:0;not null"`
+	Latency       int64 `gorm:"default:0;not null"`
+	Description   string
+	Name          string `gorm:"not null"`
+	Data          string `gorm:"type:text;not null"`
+	UrlID         uint   `gorm:"not null"`
+	URL           V1Url
+	Status        string `gorm:"type:varchar(50)"`
+	ScenarioName  string `gorm:"index"`
+	RequiredState string
+	NewState      string
+}
+
+func (V1MockContent) TableName() string { return "mock_contents" }
+
+type V1RequestLog struct {
+	ID        uint `gorm:"primaryKey"`
+	IPAddress string
+	Timestamp time.Time
+	UrlID     sql.NullInt64
+	ProjectID uint
+	Method    string
+	Status    int
+	URL       string
+	IsProxied bool
+	CreatedAt time.Time
+}
+
+func (V1RequestLog) TableName() string { return "request_logs" }
+
+type V1Resource struct {
+	V1BaseModel
+	Name      string `gorm:"not null"`
+	Path      string `gorm:"not null;index:idx_resource_project,unique"`
+	IDField   string `gorm:"type:varchar(100);default:id;not null"`
+	SeedData  string `gorm:"type:text"`
+	ProjectID uint   `gorm:"index:idx_resource_project,unique"`
+	Project   V1Project
+}
+
+func (V1Resource) TableName() string { return "resources" }
+
+var initialSchema = Migration{
+	Version: 1,
+	Name:    "initial_schema",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(
+			&V1Team{},
+			&V1Project{},
+			&V1ForwardProxy{},
+			&V1Url{},
+			&V1MockContent{},
+			&V1RequestLog{},
+			&V1Resource{},
+		)
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(
+			&V1Resource{},
+			&V1RequestLog{},
+			&V1MockContent{},
+			&V1Url{},
+			&V1ForwardProxy{},
+			&V1Project{},
+			&V1Team{},
+		)
+	},
+}
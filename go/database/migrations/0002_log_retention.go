@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration 2 adds the per-project request log retention overrides and an index that lets the
+// janitor find a project's old logs without scanning the whole table.
+
+type V2Project struct {
+	LogRetentionMaxAgeHours *int
+	LogRetentionMaxRows     *int
+}
+
+func (V2Project) TableName() string { return "projects" }
+
+type V2RequestLog struct {
+	ProjectID uint      `gorm:"index:idx_request_logs_project_created,priority:1"`
+	CreatedAt time.Time `gorm:"index:idx_request_logs_project_created,priority:2"`
+}
+
+func (V2RequestLog) TableName() string { return "request_logs" }
+
+var logRetention = Migration{
+	Version: 2,
+	Name:    "log_retention",
+	Up: func(tx *gorm.DB) error {
+		for _, field := range []string{"LogRetentionMaxAgeHours", "LogRetentionMaxRows"} {
+			if tx.Migrator().HasColumn(&V2Project{}, field) {
+				continue // Added by AutoMigrate in development
+			}
+			if err := tx.Migrator().AddColumn(&V2Project{}, field); err != nil {
+				return err
+			}
+		}
+		if tx.Migrator().HasIndex(&V2RequestLog{}, "idx_request_logs_project_created") {
+			return nil
+		}
+		return tx.Migrator().CreateIndex(&V2RequestLog{}, "idx_request_logs_project_created")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&V2RequestLog{}, "idx_request_logs_project_created"); err != nil {
+			return err
+		}
+		for _, field := range []string{"LogRetentionMaxAgeHours", "LogRetentionMaxRows"} {
+			if err := tx.Migrator().DropColumn(&V2Project{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
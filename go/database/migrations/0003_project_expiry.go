@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration 3 adds the expiry of free projects, indexed so the cleanup job can find expired
+// projects without scanning the table.
+
+type V3Project struct {
+	ExpiresAt *time.Time `gorm:"index"`
+}
+
+func (V3Project) TableName() string { return "projects" }
+
+var projectExpiry = Migration{
+	Version: 3,
+	Name:    "project_expiry",
+	Up: func(tx *gorm.DB) error {
+		if !tx.Migrator().HasColumn(&V3Project{}, "ExpiresAt") { // Otherwise added by AutoMigrate in development
+			if err := tx.Migrator().AddColumn(&V3Project{}, "ExpiresAt"); err != nil {
+				return err
+			}
+		}
+		if tx.Migrator().HasIndex(&V3Project{}, "ExpiresAt") {
+			return nil
+		}
+		return tx.Migrator().CreateIndex(&V3Project{}, "ExpiresAt")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&V3Project{}, "ExpiresAt"); err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&V3Project{}, "ExpiresAt")
+	},
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// Migration 4 stores the X-Request-ID of each request log, indexed so a log line can be traced
+// back to its request.
+
+type V4RequestLog struct {
+	RequestID string `gorm:"index"`
+}
+
+func (V4RequestLog) TableName() string { return "request_logs" }
+
+var requestLogRequestID = Migration{
+	Version: 4,
+	Name:    "request_log_request_id",
+	Up: func(tx *gorm.DB) error {
+		if !tx.Migrator().HasColumn(&V4RequestLog{}, "RequestID") { // Otherwise added by AutoMigrate in development
+			if err := tx.Migrator().AddColumn(&V4RequestLog{}, "RequestID"); err != nil {
+				return err
+			}
+		}
+		if tx.Migrator().HasIndex(&V4RequestLog{}, "RequestID") {
+			return nil
+		}
+		return tx.Migrator().CreateIndex(&V4RequestLog{}, "RequestID")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&V4RequestLog{}, "RequestID"); err != nil {
+			return err
+		}
+		// Not Migrator().DropColumn: on SQLite it rebuilds the table and loses the index of migration 2
+		return tx.Exec("ALTER TABLE request_logs DROP COLUMN request_id").Error
+	},
+}
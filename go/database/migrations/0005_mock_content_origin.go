@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// Migration 5 records where a mock content came from: written by hand, or generated by the AI
+// model, in which case edge-case variants also point at the variant they were derived from.
+
+type V5MockContent struct {
+	Origin              string `gorm:"type:varchar(50)"`
+	SourceMockContentID *uint
+}
+
+func (V5MockContent) TableName() string { return "mock_contents" }
+
+var mockContentOrigin = Migration{
+	Version: 5,
+	Name:    "mock_content_origin",
+	Up: func(tx *gorm.DB) error {
+		for _, field := range []string{"Origin", "SourceMockContentID"} {
+			if tx.Migrator().HasColumn(&V5MockContent{}, field) {
+				continue // Added by AutoMigrate in development
+			}
+			if err := tx.Migrator().AddColumn(&V5MockContent{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		for _, column := range []string{"source_mock_content_id", "origin"} {
+			// Not Migrator().DropColumn: on SQLite it rebuilds the table and loses the index of scenario_name
+			if err := tx.Exec("ALTER TABLE mock_contents DROP COLUMN " + column).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// Migration 6 lets a URL carry a JSON Schema for the data of its variants, and a strict mode that
+// rejects variants whose data is not JSON.
+
+type V6Url struct {
+	ResponseSchema string `gorm:"type:text"`
+	StrictJSON     bool   `gorm:"default:false;not null"`
+}
+
+func (V6Url) TableName() string { return "urls" }
+
+var urlResponseSchema = Migration{
+	Version: 6,
+	Name:    "url_response_schema",
+	Up: func(tx *gorm.DB) error {
+		for _, field := range []string{"ResponseSchema", "StrictJSON"} {
+			if tx.Migrator().HasColumn(&V6Url{}, field) {
+				continue // Added by AutoMigrate in development
+			}
+			if err := tx.Migrator().AddColumn(&V6Url{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		for _, column := range []string{"strict_json", "response_schema"} {
+			// Not Migrator().DropColumn: on SQLite it rebuilds the table and loses the unique index of the URL paths
+			if err := tx.Exec("ALTER TABLE urls DROP COLUMN " + column).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
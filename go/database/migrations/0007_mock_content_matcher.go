@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// Migration 7 lets a variant carry a request matcher, stored as JSON.
+
+type V7MockContent struct {
+	Matcher string `gorm:"type:text"`
+}
+
+func (V7MockContent) TableName() string { return "mock_contents" }
+
+var mockContentMatcher = Migration{
+	Version: 7,
+	Name:    "mock_content_matcher",
+	Up: func(tx *gorm.DB) error {
+		if tx.Migrator().HasColumn(&V7MockContent{}, "Matcher") {
+			return nil // Added by AutoMigrate in development
+		}
+		return tx.Migrator().AddColumn(&V7MockContent{}, "Matcher")
+	},
+	Down: func(tx *gorm.DB) error {
+		// Not Migrator().DropColumn: on SQLite it rebuilds the table and loses the index of scenario_name
+		return tx.Exec("ALTER TABLE mock_contents DROP COLUMN matcher").Error
+	},
+}
@@ -0,0 +1,155 @@
+// Package migrations holds the versioned database schema changes and the runner that applies them.
+package migrations
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one versioned schema change. Up and Down run in a transaction together with the
+// schema_migrations bookkeeping, so a failed step leaves no trace (on databases with transactional
+// DDL; MySQL commits schema changes immediately).
+type Migration struct {
+	Version int64 // Strictly increasing, e.g. 1, 2, 3
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration.
+type SchemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName keeps the conventional table name instead of GORM's pluralized struct name.
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// State describes a known migration and whether it has been applied.
+type State struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// All is the ordered list of schema changes. New migrations are appended with the next version;
+// released migrations must never be edited, since databases that already applied them will not rerun them.
+var All = []Migration{
+	initialSchema,
+	logRetention,
+	projectExpiry,
+	requestLogRequestID,
+	mockContentOrigin,
+	urlResponseSchema,
+	mockContentMatcher,
+}
+
+// Up applies all pending migrations in order and returns the ones it applied.
+func Up(db *gorm.DB) ([]Migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	latest := latestMigration()
+	for version := range applied {
+		if version > latest {
+			return nil, fmt.Errorf("database has migration %d applied, but the latest known migration is %d; upgrade the binary", version, latest)
+		}
+	}
+
+	var done []Migration
+	for _, m := range All {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("failed to apply migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		slog.Info("Applied migration", "version", m.Version, "name", m.Name)
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down rolls back the last steps applied migrations, newest first, and returns the ones it rolled back.
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be at least 1")
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(All) - 1; i >= 0 && len(done) < steps; i-- {
+		m := All[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("failed to roll back migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		slog.Info("Rolled back migration", "version", m.Version, "name", m.Name)
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Status lists every known migration with the time it was applied, if it was.
+func Status(db *gorm.DB) ([]State, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]State, 0, len(All))
+	for _, m := range All {
+		state := State{Migration: m}
+		if record, ok := applied[m.Version]; ok {
+			appliedAt := record.AppliedAt
+			state.AppliedAt = &appliedAt
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// appliedMigrations creates schema_migrations if needed and returns its rows by version.
+func appliedMigrations(db *gorm.DB) (map[int64]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	var records []SchemaMigration
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	applied := make(map[int64]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+func latestMigration() int64 {
+	if len(All) == 0 {
+		return 0
+	}
+	return All[len(All)-1].Version
+}
@@ -0,0 +1,86 @@
+package migrations_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"mockapi/database/migrations"
+	"mockapi/models"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection to :memory: would open a separate database
+	return db
+}
+
+func TestUpAndDown(t *testing.T) {
+	db := newTestDB(t)
+
+	applied, err := migrations.Up(db)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrations.All))
+	assert.True(t, db.Migrator().HasTable(&models.Project{}))
+	assert.True(t, db.Migrator().HasColumn(&models.Url{}, "rate_limit_algorithm"))
+	assert.True(t, db.Migrator().HasColumn(&models.Project{}, "log_retention_max_rows"))
+	assert.True(t, db.Migrator().HasColumn(&models.MockContent{}, "matcher"))
+
+	applied, err = migrations.Up(db)
+	require.NoError(t, err)
+	assert.Empty(t, applied, "applied migrations are not rerun")
+
+	states, err := migrations.Status(db)
+	require.NoError(t, err)
+	for _, state := range states {
+		assert.NotNil(t, state.AppliedAt, "migration %d", state.Version)
+	}
+
+	rolledBack, err := migrations.Down(db, len(migrations.All))
+	require.NoError(t, err)
+	assert.Len(t, rolledBack, len(migrations.All))
+	assert.False(t, db.Migrator().HasTable(&models.Project{}))
+
+	states, err = migrations.Status(db)
+	require.NoError(t, err)
+	for _, state := range states {
+		assert.Nil(t, state.AppliedAt)
+	}
+
+	_, err = migrations.Down(db, 0)
+	assert.Error(t, err)
+}
+
+func TestUpAdoptsAutoMigratedDatabase(t *testing.T) {
+	db := newTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Team{}, &models.Project{}, &models.ForwardProxy{}, &models.Url{},
+		&models.MockContent{}, &models.RequestLog{}, &models.Resource{}))
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+
+	_, err := migrations.Up(db)
+	require.NoError(t, err)
+
+	var count int64
+	require.NoError(t, db.Model(&models.Team{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "existing data is kept")
+}
+
+func TestUpRejectsNewerSchema(t *testing.T) {
+	db := newTestDB(t)
+	_, err := migrations.Up(db)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&migrations.SchemaMigration{Version: 9999, Name: "future", AppliedAt: time.Now()}).Error)
+
+	_, err = migrations.Up(db)
+	assert.ErrorContains(t, err, "upgrade the binary")
+}
@@ -1,6 +1,42 @@
 package dtos
 
+import "encoding/json"
+
 // AIPromptRequestDTO defines the structure for AI prompt requests.
 type AIPromptRequestDTO struct {
 	Prompt string `json:"prompt" binding:"required,min=1"` // Added min=1 to ensure not just empty string after trim
+	// Project is the optional slug of the project whose AI budget, and its team's, the call is charged to
+	Project string `json:"project"`
+}
+
+// AIGenerateMockDTO describes a URL whose mock contents are generated by the AI model.
+type AIGenerateMockDTO struct {
+	Description string `json:"description" binding:"required,min=1"` // e.g. "paginated list of 20 orders with customer and line items"
+	Path        string `json:"path" binding:"required"`
+	Method      string `json:"method" binding:"omitempty,oneof=GET POST PUT PATCH DELETE"` // Shapes the response; variants only answer it when set
+	Name        string `json:"name"`                                                       // URL name; generated when empty
+	// Variants is the number of successful variants to generate (default 1)
+	Variants int `json:"variants" binding:"omitempty,min=1,max=5"`
+	// IncludeErrorVariant adds a variant with an error status and body
+	IncludeErrorVariant bool `json:"include_error_variant"`
+}
+
+// AISchemaVariantsDTO asks for mock contents whose bodies conform to a JSON Schema.
+type AISchemaVariantsDTO struct {
+	Schema json.RawMessage `json:"schema" binding:"required"` // JSON Schema of the response body
+	// Variants is the number of variants to generate (default 3)
+	Variants int `json:"variants" binding:"omitempty,min=1,max=10"`
+	// Instructions steer the generated data, e.g. "orders of German customers"
+	Instructions string `json:"instructions"`
+	// Weight of each variant (default 10)
+	Weight int64 `json:"weight" binding:"omitempty,min=1"`
+}
+
+// AIEdgeCasesDTO asks for edge-case variants derived from an existing mock content.
+type AIEdgeCasesDTO struct {
+	MockContentID uint `json:"mock_content_id" binding:"required"`
+	// Categories of edge cases, one variant each (default all)
+	Categories []string `json:"categories" binding:"omitempty,dive,oneof=empty_lists nulls unicode long_strings boundary_numbers"`
+	// Weight of each variant (default 1, so edge cases are served now and then)
+	Weight int64 `json:"weight" binding:"omitempty,min=1"`
 }
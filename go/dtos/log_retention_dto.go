@@ -0,0 +1,8 @@
+package dtos
+
+// LogRetentionDTO replaces a project's request log retention overrides.
+// Omitted or null fields fall back to the global settings; 0 keeps logs forever or without a row limit.
+type LogRetentionDTO struct {
+	MaxAgeHours *int `json:"max_age_hours" binding:"omitempty,min=0"`
+	MaxRows     *int `json:"max_rows" binding:"omitempty,min=0"`
+}
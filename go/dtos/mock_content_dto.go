@@ -1,6 +1,10 @@
 package dtos
 
-import "mockapi/models" // For models.StatusCode
+import (
+	"encoding/json"
+
+	"mockapi/models" // For models.StatusCode
+)
 
 // MockContentCreateDTO is used for creating a new mock content item.
 // Omits ID, CreatedAt, UpdatedAt, DeletedAt, UrlID (set by service).
@@ -11,6 +15,14 @@ type MockContentCreateDTO struct {
 	DslData     *string `json:"dsl_data,omitempty"`
 	Randomness  *int64  `json:"randomness,omitempty"`    // Use omitempty for optional fields with defaults
 	Latency     *int64  `json:"latency,omitempty"`
+	// Optional per-variant status, overriding the URL status
+	Status *models.StatusCode `json:"status,omitempty"`
+	// Optional scenario binding, see models.MockContent
+	ScenarioName  *string `json:"scenario_name,omitempty"`
+	RequiredState *string `json:"required_state,omitempty"`
+	NewState      *string `json:"new_state,omitempty"`
+	// Optional request matcher, see models.RequestMatcher
+	Matcher *models.RequestMatcher `json:"matcher,omitempty"`
 }
 
 // MockContentUpdateDTO is used for updating an existing mock content item.
@@ -23,6 +35,13 @@ type MockContentUpdateDTO struct {
 	DslData     *string `json:"dsl_data,omitempty"`
 	Randomness  *int64  `json:"randomness"`
 	Latency     *int64  `json:"latency"`
+
+	ScenarioName  *string `json:"scenario_name"`
+	RequiredState *string `json:"required_state"`
+	NewState      *string `json:"new_state"`
+
+	Status  *models.StatusCode     `json:"status"`
+	Matcher *models.RequestMatcher `json:"matcher"`
 }
 
 // MockContentUrlDTO is used for creating a URL along with its mock contents.
@@ -35,6 +54,16 @@ type MockContentUrlDTO struct {
 		URL         string            `json:"url" binding:"required"` // The path for the URL
 		Status      models.StatusCode `json:"status" binding:"required"`
 		// Requests and Time are usually not set at creation, but managed by system.
+
+		ResponseMode      *models.ResponseMode `json:"response_mode,omitempty" binding:"omitempty,oneof=random sequence"`
+		SequenceEnd       *models.SequenceEnd  `json:"sequence_end,omitempty" binding:"omitempty,oneof=repeat_last wrap"`
+		SequencePerClient *bool                `json:"sequence_per_client,omitempty"`
+
+		RateLimit *RateLimitDTO `json:"rate_limit,omitempty"`
+
+		// Optional JSON Schema the data of every variant must satisfy, and whether the data must be JSON
+		ResponseSchema json.RawMessage `json:"response_schema,omitempty"`
+		StrictJSON     *bool           `json:"strict_json,omitempty"`
 	} `json:"url_data" binding:"required"`
 
 	MockContentList []MockContentCreateDTO `json:"mock_content_list" binding:"required,dive"` // dive validates each element in slice
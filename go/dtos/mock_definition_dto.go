@@ -0,0 +1,81 @@
+package dtos
+
+import "mockapi/models"
+
+// MockDefinitionFileDTO describes one project as stored in a mocks-as-code file.
+// The same shape is accepted as YAML (.yaml/.yml) and JSON (.json).
+type MockDefinitionFileDTO struct {
+	Slug         string                  `json:"slug" yaml:"slug"`
+	Name         string                  `json:"name" yaml:"name"`
+	Description  string                  `json:"description" yaml:"description"`
+	TeamID       *uint                   `json:"team_id" yaml:"team_id"` // Defaults to team 1, like the free project endpoints
+	ForwardProxy *MockDefinitionProxyDTO `json:"forward_proxy" yaml:"forward_proxy"`
+	URLs         []MockDefinitionURLDTO  `json:"urls" yaml:"urls"`
+
+	Resources []MockDefinitionResourceDTO `json:"resources" yaml:"resources"`
+	RateLimit *MockDefinitionRateLimitDTO `json:"rate_limit" yaml:"rate_limit"`
+}
+
+// MockDefinitionProxyDTO holds the forward proxy settings of a file-defined project.
+type MockDefinitionProxyDTO struct {
+	Domain string `json:"domain" yaml:"domain"`
+	Active bool   `json:"active" yaml:"active"`
+}
+
+// MockDefinitionURLDTO describes a mocked URL and its variants.
+type MockDefinitionURLDTO struct {
+	URL          string                     `json:"url" yaml:"url"`
+	Name         string                     `json:"name" yaml:"name"`
+	Description  string                     `json:"description" yaml:"description"`
+	Status       models.StatusCode          `json:"status" yaml:"status"` // Defaults to OK
+	MockContents []MockDefinitionContentDTO `json:"mock_contents" yaml:"mock_contents"`
+
+	ResponseMode      models.ResponseMode `json:"response_mode" yaml:"response_mode"` // Defaults to random
+	SequenceEnd       models.SequenceEnd  `json:"sequence_end" yaml:"sequence_end"`   // Defaults to repeat_last
+	SequencePerClient bool                `json:"sequence_per_client" yaml:"sequence_per_client"`
+
+	RateLimit *MockDefinitionRateLimitDTO `json:"rate_limit" yaml:"rate_limit"`
+}
+
+// MockDefinitionContentDTO describes a single response variant.
+// Data may be a string (served verbatim) or any structured value, which is stored as JSON.
+type MockDefinitionContentDTO struct {
+	Name        string      `json:"name" yaml:"name"`
+	Description string      `json:"description" yaml:"description"`
+	Data        interface{} `json:"data" yaml:"data"`
+	Randomness  int64       `json:"randomness" yaml:"randomness"`
+	Latency     int64       `json:"latency" yaml:"latency"` // Latency in milliseconds
+
+	ScenarioName  string `json:"scenario_name" yaml:"scenario_name"`
+	RequiredState string `json:"required_state" yaml:"required_state"`
+	NewState      string `json:"new_state" yaml:"new_state"`
+
+	Status models.StatusCode `json:"status" yaml:"status"` // Overrides the URL status when set
+
+	Matcher *MockDefinitionMatcherDTO `json:"matcher" yaml:"matcher"` // Serves the variant only to matching requests
+}
+
+// MockDefinitionMatcherDTO restricts a variant to matching requests, see models.RequestMatcher.
+type MockDefinitionMatcherDTO struct {
+	Method       string            `json:"method" yaml:"method"`
+	Query        map[string]string `json:"query" yaml:"query"`
+	Headers      map[string]string `json:"headers" yaml:"headers"`
+	BodyContains string            `json:"body_contains" yaml:"body_contains"`
+}
+
+// MockDefinitionResourceDTO describes an in-memory CRUD resource seeded with Data.
+type MockDefinitionResourceDTO struct {
+	Path    string        `json:"path" yaml:"path"`
+	Name    string        `json:"name" yaml:"name"`         // Defaults to the path
+	IDField string        `json:"id_field" yaml:"id_field"` // Defaults to "id"
+	Data    []interface{} `json:"data" yaml:"data"`         // Seed items
+}
+
+// MockDefinitionRateLimitDTO is the rate limit of a file-defined project or URL.
+type MockDefinitionRateLimitDTO struct {
+	Limit         int    `json:"limit" yaml:"limit"`
+	WindowSeconds int64  `json:"window_seconds" yaml:"window_seconds"` // Defaults to 60
+	KeyBy         string `json:"key_by" yaml:"key_by"`                 // ip (default), header or token
+	KeyHeader     string `json:"key_header" yaml:"key_header"`
+	Algorithm     string `json:"algorithm" yaml:"algorithm"` // fixed_window (default), sliding_window or token_bucket
+}
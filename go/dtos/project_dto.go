@@ -8,3 +8,15 @@ type CreateProjectDTO struct {
 	Description *string `json:"description"`
 	TeamID      *uint   `json:"team_id"` // Optional: If not provided, might use a default team or user's primary team
 }
+
+// CloneProjectDTO is used for cloning an existing project.
+// Both fields are optional: a random slug is generated when Slug is omitted.
+type CloneProjectDTO struct {
+	Slug *string `json:"slug" binding:"omitempty,min=3,max=50"`
+	Name *string `json:"name"`
+}
+
+// ClaimProjectDTO is used for moving a free project into a team.
+type ClaimProjectDTO struct {
+	TeamSlug string `json:"team_slug" binding:"required"`
+}
@@ -0,0 +1,11 @@
+package dtos
+
+// RateLimitDTO is used for configuring the rate limit of a project or URL.
+// Only the fields that are set are changed; a limit of 0 disables rate limiting.
+type RateLimitDTO struct {
+	Limit         *int    `json:"limit" binding:"omitempty,min=0"`
+	WindowSeconds *int64  `json:"window_seconds" binding:"omitempty,min=1"`
+	KeyBy         *string `json:"key_by" binding:"omitempty,oneof=ip header token"`
+	KeyHeader     *string `json:"key_header"`
+	Algorithm     *string `json:"algorithm" binding:"omitempty,oneof=fixed_window sliding_window token_bucket"`
+}
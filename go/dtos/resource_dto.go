@@ -0,0 +1,19 @@
+package dtos
+
+import "encoding/json"
+
+// ResourceCreateDTO is used for declaring an in-memory CRUD resource on a project.
+type ResourceCreateDTO struct {
+	Path     string          `json:"path" binding:"required"` // Collection path, e.g. /todos
+	Name     *string         `json:"name"`                    // Defaults to the path
+	IDField  *string         `json:"id_field"`                // Defaults to "id"
+	SeedData json.RawMessage `json:"seed_data"`               // JSON array of objects
+}
+
+// ResourceUpdateDTO is used for updating a resource. Changing it restarts the collection from its seed.
+type ResourceUpdateDTO struct {
+	Path     *string         `json:"path"`
+	Name     *string         `json:"name"`
+	IDField  *string         `json:"id_field"`
+	SeedData json.RawMessage `json:"seed_data"`
+}
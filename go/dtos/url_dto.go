@@ -1,5 +1,7 @@
 package dtos
 
+import "encoding/json"
+
 // URLDataDTO is used for transferring URL data, particularly for updates.
 // Pointers are used for nullable fields to distinguish between a zero value and a field not being set.
 type URLDataDTO struct {
@@ -8,4 +10,14 @@ type URLDataDTO struct {
 	Requests    *int64  `json:"requests"` // Changed from int to int64 to match model's sql.NullInt64
 	Time        *int64  `json:"time"`     // Changed from int to int64 to match model's sql.NullInt64
 	Status      *string `json:"status"`   // Added to allow status updates, maps to models.StatusCode
+
+	ResponseMode      *string `json:"response_mode" binding:"omitempty,oneof=random sequence"`
+	SequenceEnd       *string `json:"sequence_end" binding:"omitempty,oneof=repeat_last wrap"`
+	SequencePerClient *bool   `json:"sequence_per_client"`
+
+	RateLimit *RateLimitDTO `json:"rate_limit"`
+
+	// ResponseSchema is a JSON Schema for the data of every variant. Omit it to keep the current schema, send null to remove it.
+	ResponseSchema json.RawMessage `json:"response_schema"`
+	StrictJSON     *bool           `json:"strict_json"`
 }
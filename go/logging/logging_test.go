@@ -0,0 +1,41 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mockapi/logging"
+)
+
+func TestLoggerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.NewLogger(&buf, slog.LevelInfo)
+
+	logger.InfoContext(logging.WithRequestID(context.Background(), "abc-123"), "Handled", "status", 200)
+	logger.Debug("Skipped below the level")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line), "exactly one JSON line is written")
+	assert.Equal(t, "Handled", line["msg"])
+	assert.Equal(t, "abc-123", line["request_id"])
+	assert.Equal(t, float64(200), line["status"])
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := logging.ParseLevel("DEBUG")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, level)
+
+	level, err = logging.ParseLevel("")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelInfo, level)
+
+	_, err = logging.ParseLevel("verbose")
+	assert.Error(t, err)
+}
@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,11 +10,14 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis/v8"
 
 	"mockapi/config"   // Adjust if your module path is different
 	"mockapi/database" // Adjust if your module path is different
-	"mockapi/routes"   // Adjust if your module path is different
+	"mockapi/logging"
+	"mockapi/metrics"
+	"mockapi/routes" // Adjust if your module path is different
+	"mockapi/services"
+	"mockapi/tracing"
 )
 
 func main() {
@@ -23,39 +26,64 @@ func main() {
 	// If running `go run main.go` from /app/go, and .env is in /app/go, "." is correct.
 	cfg, err := config.LoadConfig(".")
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		logging.Fatal("Failed to load configuration", "error", err)
+	}
+
+	// JSON logs at the configured level from here on
+	if err := logging.Setup(cfg); err != nil {
+		logging.Fatal("Failed to initialize logging", "error", err)
+	}
+
+	// `mockapi migrate ...` manages the schema and exits without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrateCommand(cfg, os.Args[2:]))
+	}
+
+	// Tracing is set up first so the database and Redis clients are instrumented
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		logging.Fatal("Failed to initialize tracing", "error", err)
 	}
 
 	// Initialize database
 	database.ConnectDB(cfg)
 	sqlDB, err := database.DB.DB()
 	if err != nil {
-		log.Fatalf("Failed to get underlying sql.DB from GORM: %v", err)
+		logging.Fatal("Failed to get underlying sql.DB from GORM", "error", err)
 	}
 	defer func() {
 		if err := sqlDB.Close(); err != nil {
-			log.Printf("Error closing database: %v", err)
+			slog.Error("Failed to close database", "error", err)
 		}
 	}()
 
+	// Initialize the key-value store (Redis, in-memory, or Redis with in-memory fallback)
+	store, closeStore, err := services.NewKeyValueStore(cfg)
+	if err != nil {
+		logging.Fatal("Failed to initialize key-value store", "error", err)
+	}
+	defer closeStore()
 
-	// Initialize Redis client
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     cfg.RedisAddr,
-		Password: cfg.RedisPassword,
-		DB:       cfg.RedisDB,
-	})
-
-	// Ping Redis to check connection
-	if _, err := redisClient.Ping(context.Background()).Result(); err != nil {
-		log.Fatalf("Could not connect to Redis: %v", err)
+	// Expose the connection pools on /metrics
+	if cfg.MetricsEnabled {
+		if err := metrics.RegisterDB(sqlDB, cfg.DBName); err != nil {
+			slog.Warn("Failed to register database metrics", "error", err)
+		}
+		if client := services.RedisClientOf(store); client != nil {
+			if err := metrics.RegisterRedis(client); err != nil {
+				slog.Warn("Failed to register Redis metrics", "error", err)
+			}
+		}
 	}
-	log.Println("Connected to Redis successfully.")
-	defer func() {
-		if err := redisClient.Close(); err != nil {
-			log.Printf("Error closing Redis client: %v", err)
+
+	// Reconcile mocks-as-code definitions before serving traffic
+	if cfg.MocksDir != "" {
+		mockDefinitionService := services.NewMockDefinitionService(database.DB, cfg.MocksDir)
+		mockDefinitionService.Cache = services.NewMockCache(database.DB, store, time.Duration(cfg.MockCacheTTLSeconds)*time.Second)
+		if _, err := mockDefinitionService.Reconcile(); err != nil {
+			logging.Fatal("Failed to load mock definitions", "dir", cfg.MocksDir, "error", err)
 		}
-	}()
+	}
 
 	// Set Gin mode
 	// Consider making this configurable, e.g., via cfg.GinMode
@@ -63,12 +91,51 @@ func main() {
 
 	// Setup router
 	// Ensure database.DB is the gorm.DB instance
-	router := routes.SetupRoutes(cfg, database.DB, redisClient)
+	// Request logs and counters are written in batches off the response path unless the queue is disabled
+	var logWriter *services.RequestLogWriter
+	if cfg.RequestLogQueueSize > 0 {
+		logWriter, err = services.NewRequestLogWriter(
+			services.NewRequestLogService(database.DB),
+			services.NewURLService(database.DB, store),
+			cfg.RequestLogQueueSize,
+			cfg.RequestLogBatchSize,
+			time.Duration(cfg.RequestLogFlushIntervalMs)*time.Millisecond,
+			cfg.RequestLogDropPolicy,
+		)
+		if err != nil {
+			logging.Fatal("Failed to initialize request log writer", "error", err)
+		}
+		logWriter.Start()
+		if cfg.MetricsEnabled {
+			if err := metrics.RegisterRequestLogWriter(logWriter.MetricsStats); err != nil {
+				slog.Warn("Failed to register request log writer metrics", "error", err)
+			}
+		}
+	}
+
+	// Purge request logs past their retention in the background. The admin endpoint shares the
+	// service, so its purges never run at the same time as the janitor's.
+	logRetentionService := services.NewLogRetentionService(database.DB,
+		time.Duration(cfg.RequestLogRetentionHours)*time.Hour, cfg.RequestLogMaxRowsPerProject, cfg.RequestLogPurgeBatchSize)
+	if cfg.RequestLogJanitorIntervalSeconds > 0 {
+		stopJanitor := logRetentionService.StartJanitor(time.Duration(cfg.RequestLogJanitorIntervalSeconds) * time.Second)
+		defer stopJanitor()
+	}
+
+	// Delete expired free projects in the background
+	if cfg.ProjectCleanupIntervalSeconds > 0 {
+		projectService := services.NewProjectService(database.DB)
+		projectService.Cache = services.NewMockCache(database.DB, store, time.Duration(cfg.MockCacheTTLSeconds)*time.Second)
+		stopCleanup := projectService.StartExpiryJanitor(time.Duration(cfg.ProjectCleanupIntervalSeconds) * time.Second)
+		defer stopCleanup()
+	}
+
+	router := routes.SetupRoutes(cfg, database.DB, store, logWriter, logRetentionService)
 
 	// Define server address
 	serverAddr := ":" + cfg.ServerPort
 	if cfg.ServerPort == "" {
-		log.Println("Warning: ServerPort not set in config, defaulting to :8080")
+		slog.Warn("ServerPort not set in config, defaulting to :8080")
 		serverAddr = ":8080" // Ensure a default if config is missing it and not handled by LoadConfig
 	}
 
@@ -85,9 +152,9 @@ func main() {
 
 	// Start the server in a goroutine
 	go func() {
-		log.Printf("Server starting on port %s", cfg.ServerPort) // Use cfg.ServerPort for the log message
+		slog.Info("Server starting", "port", cfg.ServerPort)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %s\n", err)
+			logging.Fatal("Failed to listen", "error", err)
 		}
 	}()
 
@@ -98,7 +165,7 @@ func main() {
 
 	// Block until a signal is received.
 	<-quit
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server")
 
 	// The context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
@@ -106,8 +173,24 @@ func main() {
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown: ", err)
+		slog.Error("Server forced to shutdown", "error", err)
+	}
+
+	// Flush queued request logs once no handler can enqueue more
+	if logWriter != nil {
+		flushCtx, cancelFlush := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancelFlush()
+		if err := logWriter.Close(flushCtx); err != nil {
+			slog.Error("Failed to flush request logs", "error", err)
+		}
+	}
+
+	// Export the remaining spans, including those of the requests that just finished
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelTracing()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
 	}
 
-	log.Println("Server exiting")
+	slog.Info("Server exiting")
 }
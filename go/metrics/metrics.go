@@ -0,0 +1,199 @@
+// Package metrics defines the Prometheus metrics exposed on /metrics.
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "mockapi"
+
+// Registry holds every metric of the server, together with the Go runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	httpRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of management API requests by route. The _count series counts the requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	mockRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mock_request_duration_seconds",
+		Help:      "Latency of served mock requests, including configured latency and proxying. The _count series counts the requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"project", "status", "proxied"})
+
+	rateLimitRejections = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Mock requests rejected by a rate limit, by scope (global, project or url).",
+	}, []string{"scope"})
+
+	fakerDSLDuration = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "faker_dsl_duration_seconds",
+		Help:      "Latency of calls to the Faker DSL service.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	fakerDSLErrors = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "faker_dsl_errors_total",
+		Help:      "Failed calls to the Faker DSL service.",
+	})
+
+	aiRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ai_request_duration_seconds",
+		Help:      "Latency of calls to the AI model.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10), // 100ms to ~51s
+	}, []string{"model"})
+
+	aiErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_errors_total",
+		Help:      "Failed calls to the AI model.",
+	}, []string{"model"})
+
+	aiCacheRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_cache_requests_total",
+		Help:      "Lookups of the AI response cache, by result (hit or miss).",
+	}, []string{"result"})
+
+	aiBudgetRejections = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_budget_rejections_total",
+		Help:      "AI calls rejected by a usage budget, by scope (project or team).",
+	}, []string{"scope"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// mockLabelsKey is the gin context key under which the mock controller leaves the labels of a served mock.
+const mockLabelsKey = "metrics.mockLabels"
+
+// otherProject labels served mocks of projects that are not in the allow-list set by SetProjectLabels.
+const otherProject = "other"
+
+// projectLabels holds the allow-list of "team/project" label values, as a map[string]bool.
+var projectLabels atomic.Value
+
+type mockLabels struct {
+	project string
+	proxied bool
+}
+
+// SetProjectLabels sets the projects, as "team/project" slug pairs, whose served mocks get their own label
+// value. Mocks of every other project are counted together under "other", so free projects cannot add
+// series without bound. Empty entries and surrounding spaces are ignored.
+func SetProjectLabels(projects []string) {
+	allowed := make(map[string]bool, len(projects))
+	for _, project := range projects {
+		if project = strings.TrimSpace(project); project != "" {
+			allowed[project] = true
+		}
+	}
+	projectLabels.Store(allowed)
+}
+
+// SetMockLabels marks the request as a served mock, to be recorded by ObserveRequest under the project.
+// The slugs are empty when the request did not resolve to a project.
+func SetMockLabels(c *gin.Context, teamSlug, projectSlug string, proxied bool) {
+	labels := mockLabels{proxied: proxied}
+	if projectSlug != "" {
+		labels.project = otherProject
+		key := teamSlug + "/" + projectSlug
+		if allowed, _ := projectLabels.Load().(map[string]bool); allowed[key] {
+			labels.project = key
+		}
+	}
+	c.Set(mockLabelsKey, labels)
+}
+
+// ObserveRequest records a finished request, as a served mock when SetMockLabels was called for it and
+// as a management API request otherwise.
+func ObserveRequest(c *gin.Context, duration time.Duration) {
+	status := strconv.Itoa(c.Writer.Status())
+	if value, ok := c.Get(mockLabelsKey); ok {
+		labels := value.(mockLabels)
+		mockRequestDuration.WithLabelValues(labels.project, status, strconv.FormatBool(labels.proxied)).
+			Observe(duration.Seconds())
+		return
+	}
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched" // Keeps arbitrary unknown paths out of the label values
+	}
+	httpRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(duration.Seconds())
+}
+
+// IncRateLimitRejection counts a request rejected by the rate limit of the given scope.
+func IncRateLimitRejection(scope string) {
+	rateLimitRejections.WithLabelValues(scope).Inc()
+}
+
+// ObserveFakerDSL records a call to the Faker DSL service.
+func ObserveFakerDSL(duration time.Duration, err error) {
+	fakerDSLDuration.Observe(duration.Seconds())
+	if err != nil {
+		fakerDSLErrors.Inc()
+	}
+}
+
+// ObserveAICall records a call to the given AI model.
+func ObserveAICall(model string, duration time.Duration, err error) {
+	aiRequestDuration.WithLabelValues(model).Observe(duration.Seconds())
+	if err != nil {
+		aiErrors.WithLabelValues(model).Inc()
+	}
+}
+
+// ObserveAICache records a lookup of the AI response cache.
+func ObserveAICache(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	aiCacheRequests.WithLabelValues(result).Inc()
+}
+
+// IncAIBudgetRejection counts an AI call rejected by the budget of the given scope.
+func IncAIBudgetRejection(scope string) {
+	aiBudgetRejections.WithLabelValues(scope).Inc()
+}
+
+// RegisterDB exposes the connection pool statistics of the database.
+func RegisterDB(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterRedis exposes the connection pool statistics of a Redis client.
+func RegisterRedis(client *redis.Client) error {
+	return Registry.Register(&redisPoolCollector{client: client})
+}
+
+// RegisterRequestLogWriter exposes the counters and queue length of the asynchronous request log writer.
+func RegisterRequestLogWriter(stats func() RequestLogStats) error {
+	return Registry.Register(&requestLogCollector{stats: stats})
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	requestLogsDesc = prometheus.NewDesc(namespace+"_request_logs_total",
+		"Request logs handed to the asynchronous writer, by outcome (enqueued, written, dropped or failed).", []string{"outcome"}, nil)
+	requestLogQueueDesc = prometheus.NewDesc(namespace+"_request_log_queue_length",
+		"Request logs waiting in the writer queue.", nil, nil)
+	requestLogQueueCapacityDesc = prometheus.NewDesc(namespace+"_request_log_queue_capacity",
+		"Size of the request log writer queue.", nil, nil)
+)
+
+// RequestLogStats is what the request log collector reads from the writer on every scrape.
+type RequestLogStats struct {
+	Enqueued, Written, Dropped, Failed uint64
+	QueueLength, QueueCapacity         int
+}
+
+// requestLogCollector reads the counters of the asynchronous request log writer on every scrape.
+type requestLogCollector struct {
+	stats func() RequestLogStats
+}
+
+func (c *requestLogCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- requestLogsDesc
+	ch <- requestLogQueueDesc
+	ch <- requestLogQueueCapacityDesc
+}
+
+func (c *requestLogCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(requestLogsDesc, prometheus.CounterValue, float64(stats.Enqueued), "enqueued")
+	ch <- prometheus.MustNewConstMetric(requestLogsDesc, prometheus.CounterValue, float64(stats.Written), "written")
+	ch <- prometheus.MustNewConstMetric(requestLogsDesc, prometheus.CounterValue, float64(stats.Dropped), "dropped")
+	ch <- prometheus.MustNewConstMetric(requestLogsDesc, prometheus.CounterValue, float64(stats.Failed), "failed")
+	ch <- prometheus.MustNewConstMetric(requestLogQueueDesc, prometheus.GaugeValue, float64(stats.QueueLength))
+	ch <- prometheus.MustNewConstMetric(requestLogQueueCapacityDesc, prometheus.GaugeValue, float64(stats.QueueCapacity))
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"mockapi/metrics"
+)
+
+// MetricsMiddleware records the latency of every request for the /metrics endpoint.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		metrics.ObserveRequest(c, time.Since(start))
+	}
+}
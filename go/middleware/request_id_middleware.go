@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"mockapi/logging"
+)
+
+// RequestIDHeader carries the ID that correlates a request with its log lines and request log.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds incoming IDs so clients cannot bloat every log line.
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware keeps the client's X-Request-ID, or assigns a new one when it is missing or
+// invalid, stores it in the request context for logging and echoes it in the response. The ID is also
+// set on the request headers so it is propagated to forward proxy targets.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Request.Header.Set(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// validRequestID accepts IDs of letters, digits and . _ : - only, which are safe to log and store.
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.', r == '_', r == ':', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
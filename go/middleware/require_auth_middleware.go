@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"mockapi/services"
+	"mockapi/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const callerKey = "caller"
+
+// RequireAuth creates a gin.HandlerFunc that rejects requests without a valid JWT.
+// The token is taken from the "token" query parameter, then from the "Authorization: Bearer" header.
+// Without a secret key every request is rejected, since no token could be verified.
+func RequireAuth(secretKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if secretKey == "" {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Authentication is not configured.")
+			c.Abort()
+			return
+		}
+
+		tokenString := c.Query("token")
+		if tokenString == "" {
+			if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+				parts := strings.Split(authHeader, " ")
+				if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+					utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid Authorization header format. Expected 'Bearer <token>'.")
+					c.Abort()
+					return
+				}
+				tokenString = parts[1]
+			}
+		}
+		if tokenString == "" {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Authentication token is required.")
+			c.Abort()
+			return
+		}
+
+		token, err := utils.ValidateJWTToken(tokenString, secretKey)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid token: "+err.Error())
+			c.Abort()
+			return
+		}
+		claims := token.Claims.(*utils.AppClaims)
+		c.Set(callerKey, services.Caller{UserID: claims.UserID, TeamSlug: claims.TeamID, Admin: claims.Admin})
+		c.Next()
+	}
+}
+
+// RequireAdmin creates a gin.HandlerFunc that rejects callers that are not administrators.
+// It must run after RequireAuth.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CallerFromContext(c).Admin {
+			utils.ErrorResponse(c, http.StatusForbidden, "Administrator access is required.")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// CallerFromContext returns the caller RequireAuth authenticated, or an anonymous caller.
+func CallerFromContext(c *gin.Context) services.Caller {
+	caller, _ := c.Get(callerKey)
+	authenticated, _ := caller.(services.Caller)
+	return authenticated
+}
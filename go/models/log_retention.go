@@ -0,0 +1,8 @@
+package models
+
+// LogRetentionPolicy overrides the global request log retention for a project.
+// A nil field falls back to the global setting; 0 keeps logs forever or without a row limit.
+type LogRetentionPolicy struct {
+	MaxAgeHours *int `json:"max_age_hours"`
+	MaxRows     *int `json:"max_rows"`
+}
@@ -10,4 +10,28 @@ type MockContent struct {
 	Data        string `gorm:"type:text;not null" json:"data"` // The actual mock response body (e.g., JSON, XML)
 	UrlID       uint   `gorm:"not null" json:"url_id"`         // Foreign key for Url
 	URL         Url    `json:"url,omitempty"`                  // Belongs to Url
+
+	// Status overrides the Url status for this variant when set
+	Status StatusCode `gorm:"type:varchar(50)" json:"status,omitempty"`
+
+	// Stateful scenarios: a variant bound to a scenario is only served while the scenario
+	// is in RequiredState (any state if empty), and moves the scenario to NewState when served.
+	ScenarioName  string `gorm:"index" json:"scenario_name"`
+	RequiredState string `json:"required_state"`
+	NewState      string `json:"new_state"`
+
+	// Matcher restricts the variant to matching requests, see RequestMatcher; nil matches every request
+	Matcher *RequestMatcher `gorm:"serializer:json;type:text" json:"matcher,omitempty"`
+
+	// Origin records who wrote the variant: empty when written by hand, otherwise the AI operation
+	// that generated it. Edge-case variants point at the variant they were derived from.
+	Origin              string `gorm:"type:varchar(50)" json:"origin,omitempty"`
+	SourceMockContentID *uint  `json:"source_mock_content_id,omitempty"`
 }
+
+// Origins of AI-generated mock contents.
+const (
+	MockContentOriginAIDescription = "ai_description" // Generated from a description with the URL
+	MockContentOriginAISchema      = "ai_schema"      // Generated to conform to a JSON Schema
+	MockContentOriginAIEdgeCase    = "ai_edge_case"   // Derived from another variant to exercise an edge case
+)
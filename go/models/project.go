@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // Project represents a project within a team
 type Project struct {
 	BaseModel
@@ -11,4 +13,16 @@ type Project struct {
 	TeamID               uint          `json:"team_id"`                                             // Foreign key for Team
 	Team                 Team          `json:"team,omitempty"`                                      // Belongs to Team
 	ForwardProxy         *ForwardProxy `gorm:"foreignKey:ProjectID" json:"forward_proxy,omitempty"` // Has one ForwardProxy, use pointer
+	ManagedByFile        bool          `gorm:"default:false" json:"managed_by_file"`                // True when the project is defined in MOCKS_DIR
+
+	RateLimit RateLimitPolicy `gorm:"embedded;embeddedPrefix:rate_limit_" json:"rate_limit"` // Applies to every URL of the project
+
+	LogRetention LogRetentionPolicy `gorm:"embedded;embeddedPrefix:log_retention_" json:"log_retention"` // Overrides the global request log retention
+
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at,omitempty"` // Set on free projects; expired projects are removed by the cleanup job
+}
+
+// IsExpired reports whether the project has an expiry that lies before now.
+func (p *Project) IsExpired(now time.Time) bool {
+	return p.ExpiresAt != nil && !p.ExpiresAt.After(now)
 }
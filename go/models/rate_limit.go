@@ -0,0 +1,35 @@
+package models
+
+// RateLimitKeyBy selects what identifies a client for rate limiting.
+type RateLimitKeyBy string
+
+const (
+	RateLimitKeyByIP     RateLimitKeyBy = "ip"     // Client IP address (default)
+	RateLimitKeyByHeader RateLimitKeyBy = "header" // Value of RateLimitPolicy.KeyHeader
+	RateLimitKeyByToken  RateLimitKeyBy = "token"  // The "token" query parameter or bearer token
+)
+
+// RateLimitAlgorithm selects how requests are counted against a limit.
+type RateLimitAlgorithm string
+
+const (
+	RateLimitFixedWindow   RateLimitAlgorithm = "fixed_window"   // Counter reset every window (default)
+	RateLimitSlidingWindow RateLimitAlgorithm = "sliding_window" // At most Limit requests in any window-long period
+	RateLimitTokenBucket   RateLimitAlgorithm = "token_bucket"   // Bursts up to Limit, refilled at Limit per window
+)
+
+// RateLimitPolicy is an optional request limit, embedded in Project and Url.
+// A Limit of 0 disables it.
+type RateLimitPolicy struct {
+	Limit         int            `gorm:"default:0;not null" json:"limit"`
+	WindowSeconds int64          `gorm:"default:60;not null" json:"window_seconds"`
+	KeyBy         RateLimitKeyBy `gorm:"type:varchar(20);default:ip;not null" json:"key_by"`
+	KeyHeader     string         `gorm:"type:varchar(100)" json:"key_header,omitempty"` // Header used when KeyBy is header
+
+	Algorithm RateLimitAlgorithm `gorm:"type:varchar(20);default:fixed_window;not null" json:"algorithm"`
+}
+
+// Enabled reports whether the policy limits requests at all.
+func (p RateLimitPolicy) Enabled() bool {
+	return p.Limit > 0 && p.WindowSeconds > 0
+}
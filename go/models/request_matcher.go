@@ -0,0 +1,14 @@
+package models
+
+// RequestMatcher restricts a variant to the requests it describes. Every condition that is set must hold.
+type RequestMatcher struct {
+	Method       string            `json:"method,omitempty"`        // HTTP method, e.g. POST
+	Query        map[string]string `json:"query,omitempty"`         // Query parameters and their exact values
+	Headers      map[string]string `json:"headers,omitempty"`       // Headers and their exact values; names are case-insensitive
+	BodyContains string            `json:"body_contains,omitempty"` // Text the request body must contain
+}
+
+// IsEmpty reports whether the matcher has no conditions, so it matches every request.
+func (m *RequestMatcher) IsEmpty() bool {
+	return m == nil || (m.Method == "" && len(m.Query) == 0 && len(m.Headers) == 0 && m.BodyContains == "")
+}
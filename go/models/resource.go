@@ -0,0 +1,13 @@
+package models
+
+// Resource is an in-memory REST collection served under a project, similar to json-server.
+// The seed is stored here; the live collection is kept in Redis until it is reset.
+type Resource struct {
+	BaseModel
+	Name      string  `gorm:"not null" json:"name"`
+	Path      string  `gorm:"not null;index:idx_resource_project,unique" json:"path"` // Collection path, e.g. /todos
+	IDField   string  `gorm:"type:varchar(100);default:id;not null" json:"id_field"`  // Item property used as identifier
+	SeedData  string  `gorm:"type:text" json:"seed_data"`                             // JSON array of objects
+	ProjectID uint    `gorm:"index:idx_resource_project,unique" json:"project_id"`
+	Project   Project `json:"project,omitempty"`
+}
@@ -4,6 +4,22 @@ import (
 	"database/sql"
 )
 
+// ResponseMode controls how a variant is picked among a URL's mock contents.
+type ResponseMode string
+
+const (
+	ResponseModeRandom   ResponseMode = "random"   // Weighted random selection (default)
+	ResponseModeSequence ResponseMode = "sequence" // Variants are served in order
+)
+
+// SequenceEnd controls what a sequence does after its last variant has been served.
+type SequenceEnd string
+
+const (
+	SequenceEndRepeatLast SequenceEnd = "repeat_last" // Keep serving the last variant (default)
+	SequenceEndWrap       SequenceEnd = "wrap"        // Start over from the first variant
+)
+
 // Url represents a URL endpoint within a project that can be mocked
 type Url struct {
 	BaseModel
@@ -16,4 +32,15 @@ type Url struct {
 	ProjectID    uint          `gorm:"index:idx_url_project,unique" json:"project_id"`  // Foreign key for Project, part of composite unique index
 	Project      Project       `json:"project,omitempty"`                               // Belongs to Project
 	MockContents []MockContent `gorm:"foreignKey:UrlID" json:"mock_contents,omitempty"` // Has many MockContents
+
+	ResponseMode      ResponseMode `gorm:"type:varchar(20);default:random;not null" json:"response_mode"`
+	SequenceEnd       SequenceEnd  `gorm:"type:varchar(20);default:repeat_last;not null" json:"sequence_end"`
+	SequencePerClient bool         `gorm:"default:false" json:"sequence_per_client"` // Keep a separate cursor per client session key
+
+	RateLimit RateLimitPolicy `gorm:"embedded;embeddedPrefix:rate_limit_" json:"rate_limit"`
+
+	// ResponseSchema is a JSON Schema the data of every variant must conform to; empty for none
+	ResponseSchema string `gorm:"type:text" json:"response_schema,omitempty"`
+	// StrictJSON rejects variants whose data is not JSON, also without a ResponseSchema
+	StrictJSON bool `gorm:"default:false;not null" json:"strict_json"`
 }
@@ -1,101 +1,149 @@
 package routes
 
 import (
-	"context"
-	"log"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis/v8"
-	"google.golang.org/genai"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"gorm.io/gorm"
 
 	"mockapi/config"
 	"mockapi/controllers"
+	"mockapi/logging"
+	"mockapi/metrics"
 	"mockapi/middleware"
 	"mockapi/services"
+	"mockapi/tracing"
 )
 
 // SetupRoutes initializes all services, controllers, and sets up the Gin router.
-func SetupRoutes(cfg config.Config, db *gorm.DB, redisClient *redis.Client) *gin.Engine {
-	router := gin.Default()
+// Request logs and counters go through logWriter when it is non-nil and are written synchronously otherwise.
+// logRetention should be the service that runs the janitor, so manual purges and the janitor never overlap;
+// one is built from cfg when it is nil.
+func SetupRoutes(cfg config.Config, db *gorm.DB, store services.KeyValueStore, logWriter *services.RequestLogWriter, logRetention *services.LogRetentionService) *gin.Engine {
+	// The request ID comes first so every later middleware and handler logs with it
+	router := gin.New()
+	router.Use(middleware.RequestIDMiddleware(), middleware.AccessLogMiddleware(), gin.Recovery())
+	if tracing.Enabled(cfg) {
+		router.Use(otelgin.Middleware(cfg.TracingServiceName))
+	}
+	if cfg.MetricsEnabled {
+		metrics.SetProjectLabels(strings.Split(cfg.MetricsProjects, ","))
+		router.Use(middleware.MetricsMiddleware())
+	}
 
 	// Configure CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Adjust for production
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	// Initialize Services
 	teamService := services.NewTeamService()
+	if store == nil {
+		logging.Fatal("Key-value store is not initialized for routes setup")
+	}
+
+	// Read-through cache of mock resolution, shared by every service that writes projects, URLs or mock contents
+	mockCache := services.NewMockCache(db, store, time.Duration(cfg.MockCacheTTLSeconds)*time.Second)
+
 	projectService := services.NewProjectService(db)
+	projectService.ReadOnlyManaged = cfg.MocksReadOnly
+	projectService.Cache = mockCache
+	projectService.FreeProjectTTL = time.Duration(cfg.FreeProjectTTLHours) * time.Hour
 	randomWordsService := services.NewRandomWordsService()
 	requestLogService := services.NewRequestLogService(db)
+	requestLogService.Writer = logWriter
 
-	if redisClient == nil {
-		log.Fatal("Redis client is not initialized for routes setup.")
-	}
-	redisService := services.NewRedisService(redisClient)
-
-	urlService := services.NewURLService(db, redisService)
+	urlService := services.NewURLService(db, store)
+	urlService.Cache = mockCache
+	urlService.Writer = logWriter
 	mockContentService := services.NewMockContentService(db)
+	mockContentService.Cache = mockCache
 	proxyService := services.NewProxyService(db)
 	fakerService := services.NewFakerService(cfg) // Initialize FakerService
+	mockDefinitionService := services.NewMockDefinitionService(db, cfg.MocksDir)
+	mockDefinitionService.Cache = mockCache
+	scenarioService := services.NewScenarioService(db, store)
+	sequenceService := services.NewSequenceService(store)
+	resourceService := services.NewResourceService(db, store)
+	rateLimitService := services.NewRateLimitService(store)
+	if logRetention == nil {
+		logRetention = services.NewLogRetentionService(db, time.Duration(cfg.RequestLogRetentionHours)*time.Hour, cfg.RequestLogMaxRowsPerProject, cfg.RequestLogPurgeBatchSize)
+	}
 
-	// AI Prompting Service and Controller
-	geminiAPIKey := cfg.GeminiAPIKey
+	// AI usage per project and team, counted in the key-value store against the configured budgets
+	aiUsageService := services.NewAIUsageService(store, cfg.AIBudgetPeriod,
+		services.AIBudget{Requests: cfg.AIProjectRequestBudget, Tokens: cfg.AIProjectTokenBudget},
+		services.AIBudget{Requests: cfg.AITeamRequestBudget, Tokens: cfg.AITeamTokenBudget})
+
+	// AI Prompting Service and Controller, backed by the provider selected in the configuration
 	var aiPromptService services.AIPromptServiceInterface
-	var genaiClient *genai.Client
-
-	if geminiAPIKey != "" {
-		var errClient error
-		genaiClient, errClient = genai.NewClient(context.Background(), &genai.ClientConfig{
-			APIKey:  geminiAPIKey,
-			Backend: genai.BackendGeminiAPI,
-		})
-		if errClient != nil {
-			log.Printf("Warning: Failed to create genai.Client for AIPromptService: %v. AI features may be disabled.", errClient)
-		} else {
-			var errService error
-			aiPromptService, errService = services.NewAIPromptService(cfg, genaiClient.Models)
-			if errService != nil {
-				log.Fatalf("Failed to initialize AIPromptService: %v", errService)
-			}
-			// Note: genaiClient.Close() is handled in main.go
-		}
+	aiProvider, err := services.NewAIProvider(cfg)
+	if err != nil {
+		logging.Fatal("Failed to initialize the AI provider", "error", err)
+	}
+	if aiProvider != nil {
+		promptService := services.NewAIPromptServiceWithProvider(aiProvider, time.Duration(cfg.AITimeoutSeconds)*time.Second)
+		promptService.Cache = services.NewAIResponseCache(store, time.Duration(cfg.AICacheTTLSeconds)*time.Second)
+		promptService.Usage = aiUsageService
+		aiPromptService = promptService
+		slog.Info("AI provider initialized", "provider", aiProvider.Name(), "model", aiProvider.Model())
 	} else {
-		log.Println("GEMINI_API_KEY is not configured. AIPromptService is not initialized.")
+		slog.Info("No AI provider is configured, AI features are disabled")
 	}
 
-	aiPromptController := controllers.NewAIPromptController(aiPromptService)
-
 	// --- Define Routes ---
 	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "UP", "timestamp": time.Now()})
+		health := gin.H{"status": "UP", "timestamp": time.Now()}
+		if logWriter != nil {
+			health["request_log_writer"] = logWriter.Stats()
+		}
+		c.JSON(http.StatusOK, health)
 	})
 
+	// Liveness and readiness probes for orchestrators
+	healthChecks := []services.HealthCheck{services.DatabaseCheck(db)}
+	if redisCheck, ok := services.RedisCheck(store); ok {
+		healthChecks = append(healthChecks, redisCheck)
+	}
+	healthChecks = append(healthChecks, services.FakerCheck(fakerService, cfg.FakerRequired))
+	healthController := controllers.NewHealthController(services.NewHealthService(time.Duration(cfg.HealthCheckTimeoutMs)*time.Millisecond, healthChecks...))
+	router.GET("/livez", healthController.Livez)
+	router.GET("/readyz", healthController.Readyz)
+
+	if cfg.MetricsEnabled {
+		router.GET("/metrics", gin.WrapH(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})))
+	}
+
 	apiV1 := router.Group("/api/v1")
 	{
+		authMiddleware := middleware.JWTAuthMiddleware(cfg.JWTSecretKey)
+		// Management writes need a valid token; the handlers check the caller may manage the project
+		requireAuth := middleware.RequireAuth(cfg.JWTSecretKey)
+		requireAdmin := middleware.RequireAdmin()
+
 		// Home
 		homeController := controllers.NewHomeController()
 		apiV1.GET("/home", homeController.Home)
 
-		// AI Prompt
+		// AI Prompt. The controller requires a service, so it is only built when a provider is configured.
 		if aiPromptService != nil {
+			aiPromptController := controllers.NewAIPromptController(aiPromptService, projectService)
 			apiV1.POST("/ai/prompt", aiPromptController.HandleAIPrompt)
+			apiV1.POST("/ai/prompt/stream", aiPromptController.HandleAIPromptStream)
 		} else {
-			apiV1.POST("/ai/prompt", func(c *gin.Context) {
-				c.JSON(http.StatusServiceUnavailable, gin.H{
-					"status":  "error",
-					"message": "AI service is not configured or available.",
-				})
-			})
+			apiV1.POST("/ai/prompt", aiUnavailable)
+			apiV1.POST("/ai/prompt/stream", aiUnavailable)
 		}
 
 		// Team
@@ -109,14 +157,50 @@ func SetupRoutes(cfg config.Config, db *gorm.DB, redisClient *redis.Client) *gin
 			projectRoutes.POST("/free", projectController.CreateFreeProject)
 			projectRoutes.POST("/free/fast-forward", projectController.CreateFreeFastForwardProject)
 			projectRoutes.GET("/:projectSlug", projectController.GetProjectBySlug)
+			projectRoutes.POST("/:projectSlug/clone", projectController.CloneProject)
+			projectRoutes.POST("/:projectSlug/extend", requireAuth, projectController.ExtendProject)
+			projectRoutes.POST("/:projectSlug/claim", requireAuth, projectController.ClaimProject)
+			projectRoutes.PATCH("/:projectSlug/rate-limit", requireAuth, projectController.UpdateProjectRateLimit)
+			projectRoutes.PUT("/:projectSlug/log-retention", requireAuth, projectController.UpdateProjectLogRetention)
+
+			aiUsageController := controllers.NewAIUsageController(projectService, aiUsageService)
+			projectRoutes.GET("/:projectSlug/ai-usage", aiUsageController.GetUsage)
+
+			scenarioController := controllers.NewScenarioController(projectService, scenarioService)
+			projectRoutes.GET("/:projectSlug/scenarios", scenarioController.GetScenarios)
+			projectRoutes.DELETE("/:projectSlug/scenarios", scenarioController.ResetScenarios)
+			projectRoutes.DELETE("/:projectSlug/scenarios/:scenarioName", scenarioController.ResetScenarios)
+
+			resourceController := controllers.NewResourceController(projectService, resourceService)
+			projectRoutes.GET("/:projectSlug/resources", resourceController.GetResources)
+			projectRoutes.POST("/:projectSlug/resources", resourceController.CreateResource)
+			projectRoutes.POST("/:projectSlug/resources/reset", resourceController.ResetResources)
+			projectRoutes.PATCH("/:projectSlug/resources/:resourceId", resourceController.UpdateResource)
+			projectRoutes.DELETE("/:projectSlug/resources/:resourceId", resourceController.DeleteResource)
+			projectRoutes.POST("/:projectSlug/resources/:resourceId/reset", resourceController.ResetResources)
+		}
+
+		// AI-generated mocks and variants
+		var aiMockController *controllers.AIMockController
+		if aiPromptService != nil {
+			aiMockController = controllers.NewAIMockController(projectService, urlService, services.NewAIMockService(aiPromptService, urlService, mockContentService))
 		}
 
 		// URL
-		urlController := controllers.NewURLController(urlService)
+		urlController := controllers.NewURLController(urlService, projectService, sequenceService)
 		urlRoutes := apiV1.Group("/url")
 		{
 			urlRoutes.PATCH("/:urlId", urlController.UpdateURLInfo)
 			urlRoutes.GET("/:urlId", urlController.GetURLDetails)
+			urlRoutes.DELETE("/:urlId/sequence", urlController.ResetURLSequence)
+
+			if aiMockController != nil {
+				urlRoutes.POST("/:urlId/ai-variants", aiMockController.GenerateSchemaVariants)
+				urlRoutes.POST("/:urlId/ai-edge-cases", aiMockController.GenerateEdgeCases)
+			} else {
+				urlRoutes.POST("/:urlId/ai-variants", aiUnavailable)
+				urlRoutes.POST("/:urlId/ai-edge-cases", aiUnavailable)
+			}
 		}
 
 		// Proxy
@@ -128,16 +212,33 @@ func SetupRoutes(cfg config.Config, db *gorm.DB, redisClient *redis.Client) *gin
 		}
 
 		// Mock Content
-		mockContentController := controllers.NewMockContentController(projectService, mockContentService, urlService, requestLogService, redisService, proxyService, fakerService, cfg)
+		mockContentController := controllers.NewMockContentController(projectService, mockContentService, urlService, requestLogService, store, proxyService, fakerService, scenarioService, sequenceService, resourceService, rateLimitService, cfg)
 		managementMockRoutes := apiV1.Group("/mock")
 		{
 			managementMockRoutes.POST("/:projectSlug", mockContentController.SaveMockContent)
 			managementMockRoutes.PATCH("/:projectSlug/:urlId", mockContentController.UpdateMockContent)
+
+			if aiMockController != nil {
+				managementMockRoutes.POST("/:projectSlug/ai-generate", aiMockController.GenerateMock)
+			} else {
+				managementMockRoutes.POST("/:projectSlug/ai-generate", aiUnavailable)
+			}
 		}
 
+		// Administration
+		requestLogController := controllers.NewRequestLogController(projectService, logRetention)
+		apiV1.POST("/admin/request-logs/purge", requireAuth, requireAdmin, requestLogController.PurgeRequestLogs)
+
+		// Mocks-as-code
+		mockDefinitionController := controllers.NewMockDefinitionController(mockDefinitionService)
+		apiV1.POST("/mocks/reload", requireAuth, requireAdmin, mockDefinitionController.ReloadMockDefinitions)
+
 		// Protected Mock JSON (with JWT middleware)
-		authMiddleware := middleware.JWTAuthMiddleware(cfg.JWTSecretKey)
 		apiV1.GET("/mock/:teamSlug/:projectSlug", authMiddleware, mockContentController.GetMockedJSON)
+
+		// Path-based mock serving for every method, used by in-memory CRUD resources (e.g. POST /api/v1/serve/team/shop/todos).
+		// It has its own prefix because the management routes already use /api/v1/mock/:projectSlug.
+		apiV1.Any("/serve/:teamSlug/:projectSlug/*wildcardPath", authMiddleware, mockContentController.GetMockedJSON)
 	}
 
 	// Catch-all for 404
@@ -147,3 +248,11 @@ func SetupRoutes(cfg config.Config, db *gorm.DB, redisClient *redis.Client) *gin
 
 	return router
 }
+
+// aiUnavailable answers AI endpoints when no AI model is configured.
+func aiUnavailable(c *gin.Context) {
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"status":  "error",
+		"message": "AI service is not configured or available.",
+	})
+}
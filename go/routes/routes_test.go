@@ -0,0 +1,558 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"mockapi/config"
+	"mockapi/database"
+	"mockapi/models"
+	"mockapi/routes"
+	"mockapi/services"
+	"mockapi/utils"
+)
+
+// The whole HTTP layer runs against an in-memory database and store, without MySQL or Redis.
+func TestMockLifecycleInMemory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	defer store.Close()
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	require.NoError(t, db.Create(&models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: team.ID}).Error)
+
+	cfg := config.Config{GlobalMaxAllowedRequests: 100, GlobalTimeWindowSeconds: 60}
+	router := routes.SetupRoutes(cfg, db, store, nil, nil)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := do(http.MethodPost, "/api/v1/mock/shop", `{
+		"url_data": {"name": "Orders", "url": "/orders", "status": "OK"},
+		"mock_content_list": [{"name": "default", "data": "{\"orders\":[]}"}]
+	}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	resp = do(http.MethodGet, "/api/v1/serve/team/shop/orders", "")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.JSONEq(t, `{"orders":[]}`, resp.Body.String())
+
+	resp = do(http.MethodGet, "/api/v1/serve/team/shop/missing", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	defer store.Close()
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	project := models.Project{Name: "Metered", Slug: "metered", ChannelID: "metered", TeamID: team.ID}
+	require.NoError(t, db.Create(&project).Error)
+	url := models.Url{Name: "Ping", URL: "/ping", Status: models.StatusOK, ProjectID: project.ID}
+	require.NoError(t, db.Create(&url).Error)
+	require.NoError(t, db.Create(&models.MockContent{Name: "pong", Data: `"pong"`, UrlID: url.ID}).Error)
+
+	free := models.Project{Name: "Free", Slug: "free", ChannelID: "free", TeamID: team.ID}
+	require.NoError(t, db.Create(&free).Error)
+
+	cfg := config.Config{GlobalMaxAllowedRequests: 100, GlobalTimeWindowSeconds: 60, MetricsEnabled: true, MetricsProjects: "team/metered, other/shop"}
+	router := routes.SetupRoutes(cfg, db, store, nil, nil)
+	for _, path := range []string{"/api/v1/serve/team/metered/ping", "/api/v1/serve/team/free/ping", "/api/v1/serve/team/unknown/ping", "/api/v1/project/metered"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	body := resp.Body.String()
+	assert.Contains(t, body, `mockapi_mock_request_duration_seconds_count{project="team/metered",proxied="false",status="200"}`)
+	assert.Contains(t, body, `mockapi_mock_request_duration_seconds_count{project="other",proxied="false",status="404"}`,
+		"projects outside the allow-list share one label value")
+	assert.Contains(t, body, `mockapi_http_request_duration_seconds_count{method="GET",route="/api/v1/project/:projectSlug",status="200"}`)
+	assert.NotContains(t, body, `project="unknown"`, "unresolved project slugs are not used as labels")
+	assert.NotContains(t, body, `url_id=`)
+}
+
+func TestMockServingIsTraced(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	defer store.Close()
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	project := models.Project{Name: "Traced", Slug: "traced", ChannelID: "traced", TeamID: team.ID}
+	require.NoError(t, db.Create(&project).Error)
+	url := models.Url{Name: "Ping", URL: "/ping", Status: models.StatusOK, ProjectID: project.ID}
+	require.NoError(t, db.Create(&url).Error)
+	require.NoError(t, db.Create(&models.MockContent{Name: "pong", Data: `"pong"`, UrlID: url.ID}).Error)
+
+	cfg := config.Config{GlobalMaxAllowedRequests: 100, GlobalTimeWindowSeconds: 60, TracingExporter: "stdout", TracingServiceName: "mockapi"}
+	router := routes.SetupRoutes(cfg, db, store, nil, nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/v1/serve/team/traced/ping", nil))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	server, ok := spans["/api/v1/serve/:teamSlug/:projectSlug/*wildcardPath"]
+	require.True(t, ok, "the request gets a server span")
+	for _, stage := range []string{"mock.rate_limit", "mock.resolve_project", "mock.resolve_url", "mock.select_content", "mock.simulate_latency"} {
+		span, ok := spans[stage]
+		if assert.True(t, ok, "missing span %s", stage) {
+			assert.Equal(t, server.SpanContext().SpanID(), span.Parent().SpanID(), "%s is a child of the request span", stage)
+		}
+	}
+}
+
+func TestRequestIDIsEchoedAndLogged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	defer store.Close()
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	project := models.Project{Name: "Logged", Slug: "logged", ChannelID: "logged", TeamID: team.ID}
+	require.NoError(t, db.Create(&project).Error)
+	url := models.Url{Name: "Ping", URL: "/ping", Status: models.StatusOK, ProjectID: project.ID}
+	require.NoError(t, db.Create(&url).Error)
+	require.NoError(t, db.Create(&models.MockContent{Name: "pong", Data: `"pong"`, UrlID: url.ID}).Error)
+
+	cfg := config.Config{GlobalMaxAllowedRequests: 100, GlobalTimeWindowSeconds: 60}
+	router := routes.SetupRoutes(cfg, db, store, nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/serve/team/logged/ping", nil)
+	req.Header.Set("X-Request-ID", "client-id-1")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t, "client-id-1", resp.Header().Get("X-Request-ID"), "a valid client ID is kept")
+
+	var requestLog models.RequestLog
+	require.NoError(t, db.Where("project_id = ?", project.ID).First(&requestLog).Error)
+	assert.Equal(t, "client-id-1", requestLog.RequestID)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/serve/team/logged/ping", nil)
+	req.Header.Set("X-Request-ID", "not valid\n")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	generated := resp.Header().Get("X-Request-ID")
+	assert.NotEmpty(t, generated)
+	assert.NotEqual(t, "not valid\n", generated, "an invalid client ID is replaced")
+}
+
+func TestReadinessProbe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	defer store.Close()
+
+	// Nothing listens on the faker URL, which only matters when the faker is required
+	cfg := config.Config{GlobalMaxAllowedRequests: 100, GlobalTimeWindowSeconds: 60, NodeJSFakerServiceURL: "http://127.0.0.1:1", HealthCheckTimeoutMs: 500}
+	router := routes.SetupRoutes(cfg, db, store, nil, nil)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var report services.ReadinessReport
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+	assert.Equal(t, services.HealthStatusUp, report.Dependencies["database"].Status)
+	assert.Equal(t, services.HealthStatusDown, report.Dependencies["faker"].Status)
+	assert.NotContains(t, report.Dependencies, "redis", "the in-memory store has no Redis to check")
+
+	cfg.FakerRequired = true
+	resp = httptest.NewRecorder()
+	routes.SetupRoutes(cfg, db, store, nil, nil).ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestAIGenerateWithStubProvider(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	defer store.Close()
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	require.NoError(t, db.Create(&models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: team.ID}).Error)
+
+	cfg := config.Config{GlobalMaxAllowedRequests: 100, GlobalTimeWindowSeconds: 60, AIProvider: "stub"}
+	router := routes.SetupRoutes(cfg, db, store, nil, nil)
+
+	resp := httptest.NewRecorder()
+	body := `{"description": "list of orders", "path": "/orders"}`
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/api/v1/mock/shop/ai-generate", strings.NewReader(body)))
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	var created struct {
+		Data struct {
+			URL models.Url `json:"url"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+	urlID := created.Data.URL.ID
+	sourceID := created.Data.URL.MockContents[0].ID
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/v1/serve/team/shop/orders", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"stub":true}`, resp.Body.String())
+
+	resp = httptest.NewRecorder()
+	body = `{"schema": {"type": "object", "required": ["stub"]}, "variants": 2}`
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/url/%d/ai-variants", urlID), strings.NewReader(body)))
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	assert.Contains(t, resp.Body.String(), `"origin":"ai_schema"`)
+
+	resp = httptest.NewRecorder()
+	body = fmt.Sprintf(`{"mock_content_id": %d, "categories": ["nulls"]}`, sourceID)
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/url/%d/ai-edge-cases", urlID), strings.NewReader(body)))
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	assert.Contains(t, resp.Body.String(), fmt.Sprintf(`"source_mock_content_id":%d`, sourceID))
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/url/%d", urlID), nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, 3, strings.Count(resp.Body.String(), `"url_id"`), "the variants are saved on the same URL")
+
+	resp = httptest.NewRecorder()
+	body = `{"description": "a created receipt", "path": "/receipts", "method": "POST"}`
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/api/v1/mock/shop/ai-generate", strings.NewReader(body)))
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	assert.Contains(t, resp.Body.String(), `"matcher":{"method":"POST"}`)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/api/v1/serve/team/shop/receipts", nil))
+	assert.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/v1/serve/team/shop/receipts", nil))
+	assert.Equal(t, http.StatusNotFound, resp.Code, "the generated variants only answer their method")
+
+	resp = httptest.NewRecorder()
+	body = `{"description": "a trace", "path": "/trace", "method": "TRACE"}`
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/api/v1/mock/shop/ai-generate", strings.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, resp.Code, resp.Body.String())
+}
+
+func TestAIUsageIsReportedAndBudgeted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	defer store.Close()
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	require.NoError(t, db.Create(&models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: team.ID}).Error)
+
+	cfg := config.Config{GlobalMaxAllowedRequests: 100, GlobalTimeWindowSeconds: 60, AIProvider: "stub", AIProjectRequestBudget: 1}
+	router := routes.SetupRoutes(cfg, db, store, nil, nil)
+
+	prompt := func(text string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		body := `{"prompt": "` + text + `", "project": "shop"}`
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/api/v1/ai/prompt", strings.NewReader(body)))
+		return resp
+	}
+	require.Equal(t, http.StatusOK, prompt("hello").Code)
+	assert.Equal(t, http.StatusTooManyRequests, prompt("goodbye").Code)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/api/v1/ai/prompt", strings.NewReader(`{"prompt": "unbudgeted"}`)))
+	assert.Equal(t, http.StatusOK, resp.Code, "prompts without a project are not charged to any budget")
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/v1/project/shop/ai-usage", nil))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var body struct {
+		Data services.AIUsageReport `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, "month", body.Data.Period)
+	require.NotNil(t, body.Data.Project)
+	assert.Equal(t, int64(1), body.Data.Project.Requests)
+	assert.Equal(t, int64(1), body.Data.Project.RequestBudget)
+	assert.True(t, body.Data.Project.Exceeded)
+	require.NotNil(t, body.Data.Team)
+	assert.Equal(t, team.ID, body.Data.Team.ID)
+}
+
+func TestResourceCRUDThroughServeRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	defer store.Close()
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	require.NoError(t, db.Create(&models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: team.ID}).Error)
+
+	cfg := config.Config{GlobalMaxAllowedRequests: 100, GlobalTimeWindowSeconds: 60}
+	router := routes.SetupRoutes(cfg, db, store, nil, nil)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := do(http.MethodPost, "/api/v1/project/shop/resources", `{"path": "/todos", "seed_data": [{"id": 1, "title": "Learn Go"}]}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	resp = do(http.MethodPost, "/api/v1/serve/team/shop/todos", `{"title": "Write tests"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	assert.JSONEq(t, `{"id": 2, "title": "Write tests"}`, resp.Body.String())
+
+	resp = do(http.MethodPut, "/api/v1/serve/team/shop/todos/1", `{"title": "Learn Rust"}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	resp = do(http.MethodPatch, "/api/v1/serve/team/shop/todos/2", `{"done": true}`)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.JSONEq(t, `{"id": 2, "title": "Write tests", "done": true}`, resp.Body.String())
+
+	resp = do(http.MethodGet, "/api/v1/serve/team/shop/todos?_sort=id", "")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.JSONEq(t, `[{"id": 1, "title": "Learn Rust"}, {"id": 2, "title": "Write tests", "done": true}]`, resp.Body.String())
+	assert.Equal(t, "2", resp.Header().Get("X-Total-Count"))
+
+	resp = do(http.MethodDelete, "/api/v1/serve/team/shop/todos/2", "")
+	require.Less(t, resp.Code, 300, resp.Body.String())
+	resp = do(http.MethodGet, "/api/v1/serve/team/shop/todos/2", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+// Extending and claiming need a token of a member of the project's team, or of an administrator.
+func TestExtendAndClaimRequireAuthorization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	defer store.Close()
+
+	free := models.Team{Name: "Free", Slug: "free"}
+	require.NoError(t, db.Create(&free).Error)
+	acme := models.Team{Name: "Acme", Slug: "acme"}
+	require.NoError(t, db.Create(&acme).Error)
+	soon := time.Now().Add(time.Hour)
+	require.NoError(t, db.Create(&models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: free.ID, ExpiresAt: &soon}).Error)
+
+	cfg := config.Config{GlobalMaxAllowedRequests: 100, GlobalTimeWindowSeconds: 60, JWTSecretKey: "secret", FreeProjectTTLHours: 24}
+	router := routes.SetupRoutes(cfg, db, store, nil, nil)
+	token := func(team string) string {
+		signed, err := utils.GenerateJWTToken("user", team, cfg.JWTSecretKey, time.Hour)
+		require.NoError(t, err)
+		return signed
+	}
+	do := func(path, token, body string) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do("/api/v1/project/shop/extend", "", ""))
+	assert.Equal(t, http.StatusUnauthorized, do("/api/v1/project/shop/extend", "not-a-token", ""))
+	forged, err := utils.GenerateJWTToken("user", "free", "other-secret", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, do("/api/v1/project/shop/extend", forged, ""))
+	assert.Equal(t, http.StatusForbidden, do("/api/v1/project/shop/extend", token("acme"), ""))
+	assert.Equal(t, http.StatusOK, do("/api/v1/project/shop/extend", token("free"), ""))
+
+	claim := `{"team_slug": "acme"}`
+	assert.Equal(t, http.StatusUnauthorized, do("/api/v1/project/shop/claim", "", claim))
+	assert.Equal(t, http.StatusForbidden, do("/api/v1/project/shop/claim", token("free"), claim),
+		"members of the project's team may not claim it into a team they do not belong to")
+	assert.Equal(t, http.StatusOK, do("/api/v1/project/shop/claim", token("acme"), claim))
+	assert.Equal(t, http.StatusBadRequest, do("/api/v1/project/shop/claim", token("acme"), claim),
+		"claimed projects no longer expire")
+}
+
+// Log retention needs a caller that may manage the project, and purging needs an administrator.
+func TestRequestLogManagementRequiresAuthorization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	defer store.Close()
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	require.NoError(t, db.Create(&models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: team.ID}).Error)
+
+	cfg := config.Config{GlobalMaxAllowedRequests: 100, GlobalTimeWindowSeconds: 60, JWTSecretKey: "secret"}
+	router := routes.SetupRoutes(cfg, db, store, nil, nil)
+	memberToken, err := utils.GenerateJWTToken("user", "team", cfg.JWTSecretKey, time.Hour)
+	require.NoError(t, err)
+	outsiderToken, err := utils.GenerateJWTToken("user", "other", cfg.JWTSecretKey, time.Hour)
+	require.NoError(t, err)
+	adminToken, err := utils.GenerateAdminJWTToken("admin", cfg.JWTSecretKey, time.Hour)
+	require.NoError(t, err)
+	do := func(method, path, token, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	retention := `{"max_age_hours": 24}`
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPut, "/api/v1/project/shop/log-retention", "", retention))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPut, "/api/v1/project/shop/log-retention", outsiderToken, retention))
+	assert.Equal(t, http.StatusOK, do(http.MethodPut, "/api/v1/project/shop/log-retention", memberToken, retention))
+	assert.Equal(t, http.StatusOK, do(http.MethodPut, "/api/v1/project/shop/log-retention", adminToken, retention))
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/api/v1/admin/request-logs/purge", "", ""))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/api/v1/admin/request-logs/purge", memberToken, ""),
+		"project members are not administrators")
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/api/v1/admin/request-logs/purge?project=shop", adminToken, ""))
+}
+
+func TestProjectRateLimitRequiresAuthorization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	defer store.Close()
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	require.NoError(t, db.Create(&models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: team.ID}).Error)
+
+	cfg := config.Config{GlobalMaxAllowedRequests: 100, GlobalTimeWindowSeconds: 60, JWTSecretKey: "secret"}
+	router := routes.SetupRoutes(cfg, db, store, nil, nil)
+	patch := func(token string) int {
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/project/shop/rate-limit", strings.NewReader(`{"limit": 5}`))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, patch(""))
+	outsiderToken, err := utils.GenerateJWTToken("user", "other", cfg.JWTSecretKey, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, patch(outsiderToken))
+	memberToken, err := utils.GenerateJWTToken("user", "team", cfg.JWTSecretKey, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, patch(memberToken))
+}
+
+func TestMockDefinitionReloadRequiresAdministrator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	defer store.Close()
+	require.NoError(t, db.Create(&models.Team{Name: "Team", Slug: "team"}).Error)
+
+	cfg := config.Config{GlobalMaxAllowedRequests: 100, GlobalTimeWindowSeconds: 60, JWTSecretKey: "secret", MocksDir: t.TempDir()}
+	router := routes.SetupRoutes(cfg, db, store, nil, nil)
+	reload := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/mocks/reload", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, reload(""))
+	memberToken, err := utils.GenerateJWTToken("user", "team", cfg.JWTSecretKey, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, reload(memberToken))
+	adminToken, err := utils.GenerateAdminJWTToken("admin", cfg.JWTSecretKey, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, reload(adminToken))
+}
+
+func TestMatchersSelectVariantsWhenServing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	defer store.Close()
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	require.NoError(t, db.Create(&models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: team.ID}).Error)
+
+	cfg := config.Config{GlobalMaxAllowedRequests: 100, GlobalTimeWindowSeconds: 60}
+	router := routes.SetupRoutes(cfg, db, store, nil, nil)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := do(http.MethodPost, "/api/v1/mock/shop", `{
+		"url_data": {"name": "Orders", "url": "/orders", "status": "OK"},
+		"mock_content_list": [
+			{"name": "list", "data": "[]"},
+			{"name": "created", "data": "{\"id\":1}", "status": "CREATED", "matcher": {"method": "POST"}},
+			{"name": "express", "data": "{\"id\":2}", "matcher": {"method": "POST", "body_contains": "express"}}
+		]
+	}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+
+	resp = do(http.MethodGet, "/api/v1/serve/team/shop/orders", "")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.JSONEq(t, `[]`, resp.Body.String())
+
+	resp = do(http.MethodPost, "/api/v1/serve/team/shop/orders", `{"item": "book"}`)
+	require.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	assert.JSONEq(t, `{"id":1}`, resp.Body.String())
+
+	resp = do(http.MethodPost, "/api/v1/mock/shop", `{
+		"url_data": {"name": "Broken", "url": "/broken", "status": "OK"},
+		"mock_content_list": [{"name": "broken", "data": "{}", "matcher": {"method": "FETCH"}}]
+	}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code, resp.Body.String())
+}
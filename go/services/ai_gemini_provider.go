@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// GeminiProvider generates content with Google's Gemini API.
+type GeminiProvider struct {
+	modelsService ModelsServiceInterface
+	model         string
+}
+
+// NewGeminiProvider creates a GeminiProvider on top of genai's client.Models.
+func NewGeminiProvider(modelsSvc ModelsServiceInterface, model string) *GeminiProvider {
+	return &GeminiProvider{modelsService: modelsSvc, model: model}
+}
+
+func (p *GeminiProvider) Name() string  { return AIProviderGemini }
+func (p *GeminiProvider) Model() string { return p.model }
+
+// Generate sends the request to Gemini. Without a response schema, no generation config is sent.
+func (p *GeminiProvider) Generate(ctx context.Context, req AIRequest) (*AIResponse, error) {
+	contents := []*genai.Content{genai.NewContentFromText(req.Prompt, genai.RoleUser)}
+	resp, err := p.modelsService.GenerateContent(ctx, p.model, contents, geminiConfig(req))
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("received nil response from Gemini API")
+	}
+	return geminiResponse(resp), nil
+}
+
+// geminiConfig returns the generation config of req, or nil when it needs none.
+func geminiConfig(req AIRequest) *genai.GenerateContentConfig {
+	if req.SystemInstruction == "" && req.ResponseSchema == nil {
+		return nil
+	}
+	generateConfig := &genai.GenerateContentConfig{}
+	if req.SystemInstruction != "" {
+		generateConfig.SystemInstruction = genai.NewContentFromText(req.SystemInstruction, genai.RoleUser)
+	}
+	if req.ResponseSchema != nil {
+		generateConfig.ResponseMIMEType = "application/json"
+		generateConfig.ResponseSchema = geminiSchema(req.ResponseSchema)
+	}
+	return generateConfig
+}
+
+// geminiResponse converts a (possibly partial, when streaming) Gemini response.
+func geminiResponse(resp *genai.GenerateContentResponse) *AIResponse {
+	result := &AIResponse{Raw: resp}
+	if len(resp.Candidates) > 0 {
+		result.Text = resp.Text()
+		result.FinishReason = string(resp.Candidates[0].FinishReason)
+	}
+	if usage := resp.UsageMetadata; usage != nil {
+		result.Usage = AIUsage{
+			PromptTokens:     int(usage.PromptTokenCount),
+			CompletionTokens: int(usage.CandidatesTokenCount),
+			TotalTokens:      int(usage.TotalTokenCount),
+		}
+	}
+	return result
+}
+
+// geminiSchema converts an AISchema into Gemini's OpenAPI-style schema.
+func geminiSchema(schema *AISchema) *genai.Schema {
+	if schema == nil {
+		return nil
+	}
+	converted := &genai.Schema{
+		Type:             genai.Type(strings.ToUpper(schema.Type)),
+		Description:      schema.Description,
+		Required:         schema.Required,
+		Enum:             schema.Enum,
+		Items:            geminiSchema(schema.Items),
+		PropertyOrdering: schema.PropertyOrder,
+	}
+	if len(schema.Properties) > 0 {
+		converted.Properties = make(map[string]*genai.Schema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			converted.Properties[name] = geminiSchema(property)
+		}
+	}
+	return converted
+}
+
+// GenerateStream relays Gemini's streamed chunks. The last chunk carries the finish reason and usage.
+func (p *GeminiProvider) GenerateStream(ctx context.Context, req AIRequest, emit func(text string) error) (*AIResponse, error) {
+	contents := []*genai.Content{genai.NewContentFromText(req.Prompt, genai.RoleUser)}
+	result := &AIResponse{}
+	// Leaving the loop early stops the iterator, which closes the upstream connection
+	for resp, err := range p.modelsService.GenerateContentStream(ctx, p.model, contents, geminiConfig(req)) {
+		if err != nil {
+			return nil, err
+		}
+		chunk := geminiResponse(resp)
+		if chunk.FinishReason != "" {
+			result.FinishReason = chunk.FinishReason
+		}
+		if chunk.Usage.TotalTokens > 0 {
+			result.Usage = chunk.Usage
+		}
+		if chunk.Text == "" {
+			continue
+		}
+		if err := emit(chunk.Text); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
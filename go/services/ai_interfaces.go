@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"iter"
 	"google.golang.org/genai"
+
+	"mockapi/dtos"
+	"mockapi/models"
 )
 
 // GenerativeModelInterface defines the methods we use from *genai.GenerativeModel
@@ -15,6 +19,7 @@ type GenerativeModelInterface interface {
 // ModelsServiceInterface defines the methods we use from *genai.Client.Models
 type ModelsServiceInterface interface {
 	GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error)
+	GenerateContentStream(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) iter.Seq2[*genai.GenerateContentResponse, error]
 	// Add other methods from *genai.Models if used
 }
 
@@ -23,5 +28,20 @@ type ModelsServiceInterface interface {
 // This allows for mocking the service in controller tests.
 type AIPromptServiceInterface interface {
 	GetAIResponse(ctx context.Context, prompt string) (map[string]interface{}, error)
+	GenerateJSON(ctx context.Context, systemInstruction, prompt string, schema *AISchema) (string, error)
+	StreamAIResponse(ctx context.Context, prompt string, emit func(text string) error) (*AIResponse, error)
+	CheckBudget(ctx context.Context) error
 	// Close() method is removed as client lifecycle is managed externally.
 }
+
+// AIMockServiceInterface defines the methods of AIMockService used by controllers.
+type AIMockServiceInterface interface {
+	GenerateMock(ctx context.Context, projectID uint, dto dtos.AIGenerateMockDTO) (*models.Url, error)
+	GenerateSchemaVariants(ctx context.Context, url *models.Url, dto dtos.AISchemaVariantsDTO) ([]models.MockContent, error)
+	GenerateEdgeCases(ctx context.Context, url *models.Url, source *models.MockContent, dto dtos.AIEdgeCasesDTO) ([]models.MockContent, error)
+}
+
+// AIUsageServiceInterface defines the methods of AIUsageService used by controllers.
+type AIUsageServiceInterface interface {
+	Usage(ctx context.Context, scope AIScope) (*AIUsageReport, error)
+}
@@ -0,0 +1,427 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"mockapi/dtos"
+	"mockapi/models"
+)
+
+// ErrAIInvalidOutput means the model answered, but not with what was asked for.
+var ErrAIInvalidOutput = errors.New("invalid AI output")
+
+// Weights of generated variants: successful variants are served most of the time and the error
+// variant now and then.
+const (
+	aiSuccessVariantWeight = 10
+	aiErrorVariantWeight   = 1
+)
+
+// aiVariantStatuses are the statuses the model may pick for a variant.
+var aiVariantStatuses = []models.StatusCode{
+	models.StatusOK, models.StatusCreated, models.StatusAccepted, models.StatusNoContent,
+	models.StatusBadRequest, models.StatusUnauthorized, models.StatusForbidden, models.StatusNotFound,
+	models.StatusConflict, models.StatusUnprocessableEntity, models.StatusTooManyRequests,
+	models.StatusInternalServerError, models.StatusServiceUnavailable,
+}
+
+// aiErrorStatuses are the statuses that make a generated variant an error variant.
+var aiErrorStatuses = map[models.StatusCode]bool{
+	models.StatusBadRequest: true, models.StatusUnauthorized: true, models.StatusForbidden: true,
+	models.StatusNotFound: true, models.StatusConflict: true, models.StatusUnprocessableEntity: true,
+	models.StatusTooManyRequests: true, models.StatusInternalServerError: true, models.StatusServiceUnavailable: true,
+}
+
+const aiMockSystemInstruction = `You generate realistic mock HTTP API responses for frontend and integration testing.
+Each variant's "data" is the complete response body as a JSON document encoded in a string.
+Use plausible, varied values: real-looking names, emails, ids, ISO 8601 timestamps and prices. Never use placeholders such as "string" or "lorem ipsum".
+Follow the described shape exactly, including counts of list items and pagination fields.`
+
+// aiMockSchema is the response schema of a generation. The body is a string because the schema
+// cannot describe arbitrary JSON; it is parsed and validated afterwards.
+func aiMockSchema() *AISchema {
+	statuses := make([]string, len(aiVariantStatuses))
+	for i, status := range aiVariantStatuses {
+		statuses[i] = string(status)
+	}
+	return &AISchema{
+		Type: "object",
+		Properties: map[string]*AISchema{
+			"url_name": {Type: "string", Description: "Short human-readable name of the endpoint"},
+			"variants": {
+				Type: "array",
+				Items: &AISchema{
+					Type: "object",
+					Properties: map[string]*AISchema{
+						"name":        {Type: "string"},
+						"description": {Type: "string"},
+						"status":      {Type: "string", Enum: statuses},
+						"data":        {Type: "string", Description: "Response body as a JSON document", JSON: true},
+					},
+					Required:      []string{"name", "status", "data"},
+					PropertyOrder: []string{"name", "description", "status", "data"},
+				},
+			},
+		},
+		Required:      []string{"url_name", "variants"},
+		PropertyOrder: []string{"url_name", "variants"},
+	}
+}
+
+// aiMockGeneration is the model's answer to aiMockSchema.
+type aiMockGeneration struct {
+	URLName  string `json:"url_name"`
+	Variants []struct {
+		Name        string            `json:"name"`
+		Description string            `json:"description"`
+		Status      models.StatusCode `json:"status"`
+		Data        string            `json:"data"`
+	} `json:"variants"`
+}
+
+// AIMockService creates URLs with mock contents generated by the AI model from a description.
+type AIMockService struct {
+	aiService          AIPromptServiceInterface
+	urlService         URLServiceInterface
+	mockContentService MockContentServiceInterface
+}
+
+// NewAIMockService creates a new AIMockService.
+func NewAIMockService(ai AIPromptServiceInterface, us URLServiceInterface, mcs MockContentServiceInterface) *AIMockService {
+	return &AIMockService{aiService: ai, urlService: us, mockContentService: mcs}
+}
+
+// GenerateMock generates the variants described by dto and saves them on a new URL of the project.
+// Nothing is saved unless every variant is valid JSON, so a bad generation leaves no URL behind.
+func (s *AIMockService) GenerateMock(ctx context.Context, projectID uint, dto dtos.AIGenerateMockDTO) (*models.Url, error) {
+	variants := dto.Variants
+	if variants == 0 {
+		variants = 1
+	}
+
+	method := dto.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Endpoint: %s %s\n", method, dto.Path)
+	fmt.Fprintf(&prompt, "Response description: %s\n", dto.Description)
+	fmt.Fprintf(&prompt, "Generate exactly %d successful variant(s) with a 2xx status and different realistic data.\n", variants)
+	if dto.IncludeErrorVariant {
+		prompt.WriteString("Also generate exactly 1 error variant with a fitting 4xx or 5xx status and an error body with a message.\n")
+	}
+
+	text, err := s.aiService.GenerateJSON(ctx, aiMockSystemInstruction, prompt.String(), aiMockSchema())
+	if err != nil {
+		return nil, err
+	}
+	var generation aiMockGeneration
+	if err := json.Unmarshal([]byte(text), &generation); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAIInvalidOutput, err)
+	}
+
+	contents, err := aiMockContents(generation, variants, dto.IncludeErrorVariant)
+	if err != nil {
+		return nil, err
+	}
+	// A URL answers every method, so the variants are matched to the method when one is given
+	if dto.Method != "" {
+		for i := range contents {
+			contents[i].Matcher = &models.RequestMatcher{Method: dto.Method}
+		}
+	}
+
+	url := &models.Url{
+		Name:        dto.Name,
+		Description: dto.Description,
+		URL:         dto.Path,
+		Status:      contents[0].Status,
+	}
+	if url.Name == "" {
+		url.Name = generation.URLName
+	}
+	if url.Name == "" {
+		url.Name = method + " " + dto.Path
+	}
+	if err := s.urlService.CreateURLWithMockContents(url, projectID, contents); err != nil {
+		return nil, fmt.Errorf("failed to create URL: %w", err)
+	}
+	return url, nil
+}
+
+// aiMockContents validates the generated variants and turns them into weighted mock contents,
+// the successful ones first.
+func aiMockContents(generation aiMockGeneration, variants int, includeError bool) ([]models.MockContent, error) {
+	var successes, failures []models.MockContent
+	for i, variant := range generation.Variants {
+		data, err := compactJSON(variant.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: variant %d (%s) is not valid JSON: %v", ErrAIInvalidOutput, i+1, variant.Name, err)
+		}
+		content := models.MockContent{
+			Name:        aiVariantName(variant.Name, i),
+			Description: variant.Description,
+			Data:        data,
+			Status:      variant.Status,
+			Origin:      models.MockContentOriginAIDescription,
+		}
+		if aiErrorStatuses[variant.Status] {
+			content.Randomness = aiErrorVariantWeight
+			failures = append(failures, content)
+		} else {
+			content.Randomness = aiSuccessVariantWeight
+			successes = append(successes, content)
+		}
+	}
+	if len(successes) == 0 {
+		return nil, fmt.Errorf("%w: no successful variant was generated", ErrAIInvalidOutput)
+	}
+	if includeError && len(failures) == 0 {
+		return nil, fmt.Errorf("%w: no error variant was generated", ErrAIInvalidOutput)
+	}
+	if len(successes) > variants {
+		successes = successes[:variants]
+	}
+	if len(failures) > 1 {
+		failures = failures[:1]
+	}
+	if !includeError {
+		failures = nil
+	}
+	return append(successes, failures...), nil
+}
+
+// Defaults of the generations on existing URLs.
+const (
+	aiSchemaVariantsDefault = 3
+	aiEdgeCaseWeight        = aiErrorVariantWeight
+)
+
+// AIEdgeCaseCategories are the kinds of edge-case variants derived from a mock content.
+var AIEdgeCaseCategories = map[string]string{
+	"empty_lists":      "every list is empty, with counts and totals to match",
+	"nulls":            "optional and nullable fields are null",
+	"unicode":          "strings use non-Latin scripts, combining characters, right-to-left text and emoji",
+	"long_strings":     "strings are very long, several thousand characters",
+	"boundary_numbers": "numbers sit at their boundaries: 0, negative values, very large values and many decimals",
+}
+
+// aiEdgeCaseOrder is the order in which categories are requested and saved.
+var aiEdgeCaseOrder = []string{"empty_lists", "nulls", "unicode", "long_strings", "boundary_numbers"}
+
+// ErrMockContentNotJSON means an operation needs a mock content whose data is JSON.
+var ErrMockContentNotJSON = errors.New("mock content data is not JSON")
+
+// aiVariantsSchema is the response schema of generations that add variants to an existing URL.
+// With categories, every variant names the edge case it covers.
+func aiVariantsSchema(categories []string) *AISchema {
+	variant := &AISchema{
+		Type: "object",
+		Properties: map[string]*AISchema{
+			"name":        {Type: "string"},
+			"description": {Type: "string"},
+			"data":        {Type: "string", Description: "Response body as a JSON document", JSON: true},
+		},
+		Required:      []string{"name", "data"},
+		PropertyOrder: []string{"name", "description", "data"},
+	}
+	if len(categories) > 0 {
+		variant.Properties["category"] = &AISchema{Type: "string", Enum: categories}
+		variant.Required = append([]string{"category"}, variant.Required...)
+		variant.PropertyOrder = append([]string{"category"}, variant.PropertyOrder...)
+	}
+	return &AISchema{
+		Type:       "object",
+		Properties: map[string]*AISchema{"variants": {Type: "array", Items: variant}},
+		Required:   []string{"variants"},
+	}
+}
+
+// aiVariant is one variant of the model's answer to aiVariantsSchema.
+type aiVariant struct {
+	Category    string `json:"category"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Data        string `json:"data"`
+}
+
+// aiVariantsGeneration is the model's answer to aiVariantsSchema.
+type aiVariantsGeneration struct {
+	Variants []aiVariant `json:"variants"`
+}
+
+// GenerateSchemaVariants generates up to dto.Variants variants whose bodies conform to the JSON Schema
+// of dto and adds them to the URL. Nothing is saved unless every variant conforms.
+func (s *AIMockService) GenerateSchemaVariants(ctx context.Context, url *models.Url, dto dtos.AISchemaVariantsDTO) ([]models.MockContent, error) {
+	schema, err := CompileJSONSchema(string(dto.Schema))
+	if err != nil {
+		return nil, err
+	}
+	variants := dto.Variants
+	if variants == 0 {
+		variants = aiSchemaVariantsDefault
+	}
+	weight := dto.Weight
+	if weight == 0 {
+		weight = aiSuccessVariantWeight
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Endpoint: %s (%s)\n", url.URL, url.Name)
+	if url.Description != "" {
+		fmt.Fprintf(&prompt, "Endpoint description: %s\n", url.Description)
+	}
+	fmt.Fprintf(&prompt, "The response body must conform to this JSON Schema:\n%s\n", dto.Schema)
+	if dto.Instructions != "" {
+		fmt.Fprintf(&prompt, "Instructions: %s\n", dto.Instructions)
+	}
+	fmt.Fprintf(&prompt, "Generate exactly %d variant(s) with different realistic data.\n", variants)
+
+	generation, err := s.generateVariants(ctx, prompt.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(generation.Variants) == 0 {
+		return nil, fmt.Errorf("%w: no variant was generated", ErrAIInvalidOutput)
+	}
+	if len(generation.Variants) > variants {
+		generation.Variants = generation.Variants[:variants]
+	}
+
+	contents := make([]models.MockContent, 0, len(generation.Variants))
+	for i, variant := range generation.Variants {
+		data, err := compactJSON(variant.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: variant %d (%s) is not valid JSON: %v", ErrAIInvalidOutput, i+1, variant.Name, err)
+		}
+		if violations := ValidateJSONDocument(schema, data); len(violations) > 0 {
+			return nil, fmt.Errorf("%w: variant %d (%s) does not conform to the schema at '%s': %s",
+				ErrAIInvalidOutput, i+1, variant.Name, violations[0].Path, violations[0].Message)
+		}
+		contents = append(contents, models.MockContent{
+			Name:        aiVariantName(variant.Name, i),
+			Description: variant.Description,
+			Data:        data,
+			Randomness:  weight,
+			Origin:      models.MockContentOriginAISchema,
+		})
+	}
+	return s.saveVariants(contents, url.ID)
+}
+
+// GenerateEdgeCases derives one variant per category of dto from the source mock content, which
+// must be JSON, and adds them to the URL. They keep the source's status and latency.
+func (s *AIMockService) GenerateEdgeCases(ctx context.Context, url *models.Url, source *models.MockContent, dto dtos.AIEdgeCasesDTO) ([]models.MockContent, error) {
+	if !json.Valid([]byte(source.Data)) {
+		return nil, fmt.Errorf("%w: mock content %d", ErrMockContentNotJSON, source.ID)
+	}
+	categories := aiEdgeCaseOrder
+	if len(dto.Categories) > 0 {
+		categories = nil
+		for _, category := range aiEdgeCaseOrder {
+			if slices.Contains(dto.Categories, category) {
+				categories = append(categories, category)
+			}
+		}
+	}
+	weight := dto.Weight
+	if weight == 0 {
+		weight = aiEdgeCaseWeight
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Endpoint: %s (%s)\n", url.URL, url.Name)
+	fmt.Fprintf(&prompt, "Existing response body:\n%s\n", source.Data)
+	if url.ResponseSchema != "" {
+		fmt.Fprintf(&prompt, "Every variant must still conform to the endpoint's JSON Schema:\n%s\n", url.ResponseSchema)
+	}
+	prompt.WriteString("Derive edge-case variants of this body that keep its structure and field names. Generate exactly one variant per category:\n")
+	for _, category := range categories {
+		fmt.Fprintf(&prompt, "- %s: %s\n", category, AIEdgeCaseCategories[category])
+	}
+
+	generation, err := s.generateVariants(ctx, prompt.String(), categories)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceID := source.ID
+	contents := make([]models.MockContent, 0, len(categories))
+	for _, category := range categories {
+		i := slices.IndexFunc(generation.Variants, func(v aiVariant) bool { return v.Category == category })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: no %s variant was generated", ErrAIInvalidOutput, category)
+		}
+		variant := generation.Variants[i]
+		data, err := compactJSON(variant.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: the %s variant is not valid JSON: %v", ErrAIInvalidOutput, category, err)
+		}
+		name := variant.Name
+		if name == "" {
+			name = source.Name + " (" + category + ")"
+		}
+		contents = append(contents, models.MockContent{
+			Name:                name,
+			Description:         variant.Description,
+			Data:                data,
+			Status:              source.Status,
+			Latency:             source.Latency,
+			Randomness:          weight,
+			Origin:              models.MockContentOriginAIEdgeCase,
+			SourceMockContentID: &sourceID,
+		})
+	}
+	return s.saveVariants(contents, url.ID)
+}
+
+// generateVariants asks the model for variants of an existing URL.
+func (s *AIMockService) generateVariants(ctx context.Context, prompt string, categories []string) (*aiVariantsGeneration, error) {
+	text, err := s.aiService.GenerateJSON(ctx, aiMockSystemInstruction, prompt, aiVariantsSchema(categories))
+	if err != nil {
+		return nil, err
+	}
+	var generation aiVariantsGeneration
+	if err := json.Unmarshal([]byte(text), &generation); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAIInvalidOutput, err)
+	}
+	return &generation, nil
+}
+
+// saveVariants adds the variants to the URL's existing ones.
+func (s *AIMockService) saveVariants(contents []models.MockContent, urlID uint) ([]models.MockContent, error) {
+	saved, err := s.mockContentService.SaveMockContentList(contents, urlID)
+	if errors.Is(err, ErrInvalidMockContent) {
+		// The model ignored the URL's response schema
+		return nil, fmt.Errorf("%w: %w", ErrAIInvalidOutput, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save mock contents: %w", err)
+	}
+	return saved, nil
+}
+
+// compactJSON validates the JSON text and removes its insignificant whitespace, so stored mocks
+// do not depend on the model's formatting.
+func compactJSON(text string) (string, error) {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, []byte(text)); err != nil {
+		return "", err
+	}
+	return compacted.String(), nil
+}
+
+func aiVariantName(name string, i int) string {
+	if name == "" {
+		return fmt.Sprintf("AI variant %d", i+1)
+	}
+	return name
+}
@@ -0,0 +1,261 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+	"gorm.io/gorm"
+
+	"mockapi/config"
+	"mockapi/database"
+	"mockapi/dtos"
+	"mockapi/models"
+	"mockapi/services"
+)
+
+// modelAnswering returns a models service whose model answers every prompt with text.
+func modelAnswering(t *testing.T, text string) *MockModelsService {
+	return &MockModelsService{
+		GenerateContentFunc: func(ctx context.Context, model string, contents []*genai.Content, cfg *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+			require.NotNil(t, cfg, "structured output needs a generation config")
+			assert.Equal(t, "application/json", cfg.ResponseMIMEType)
+			require.NotNil(t, cfg.ResponseSchema)
+			assert.Contains(t, cfg.ResponseSchema.Properties, "variants")
+			return &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+				Content:      genai.NewContentFromText(text, genai.RoleModel),
+				FinishReason: genai.FinishReasonStop,
+			}}}, nil
+		},
+	}
+}
+
+func newAIMockService(t *testing.T, answer string) (*services.AIMockService, *models.Project, *services.URLService) {
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	t.Cleanup(store.Close)
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	project := models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: team.ID}
+	require.NoError(t, db.Create(&project).Error)
+
+	aiService, err := services.NewAIPromptService(config.Config{GeminiModelName: "test-model"}, modelAnswering(t, answer))
+	require.NoError(t, err)
+	urlService := services.NewURLService(db, store)
+	return services.NewAIMockService(aiService, urlService, services.NewMockContentService(db)), &project, urlService
+}
+
+func generationJSON(t *testing.T, variants ...map[string]string) string {
+	answer, err := json.Marshal(map[string]interface{}{"url_name": "List orders", "variants": variants})
+	require.NoError(t, err)
+	return string(answer)
+}
+
+func TestAIMockService_GenerateMock(t *testing.T) {
+	answer := generationJSON(t,
+		map[string]string{"name": "Orders", "status": "OK", "data": "{ \"orders\": [ {\"id\": 1} ], \"page\": 1 }"},
+		map[string]string{"name": "Server error", "status": "INTERNAL_SERVER_ERROR", "data": `{"message":"boom"}`},
+	)
+	aiMockService, project, urlService := newAIMockService(t, answer)
+
+	url, err := aiMockService.GenerateMock(context.Background(), project.ID, dtos.AIGenerateMockDTO{
+		Description:         "paginated list of orders",
+		Path:                "/orders",
+		IncludeErrorVariant: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "List orders", url.Name)
+	assert.Equal(t, models.StatusOK, url.Status)
+
+	saved, err := urlService.FindByProjectIDAndURL(project.ID, "/orders")
+	require.NoError(t, err)
+	require.Len(t, saved.MockContents, 2)
+	assert.Equal(t, `{"orders":[{"id":1}],"page":1}`, saved.MockContents[0].Data, "bodies are stored compacted")
+	assert.Equal(t, models.StatusInternalServerError, saved.MockContents[1].Status)
+	assert.Greater(t, saved.MockContents[0].Randomness, saved.MockContents[1].Randomness, "the error variant is served less often")
+	assert.Equal(t, models.MockContentOriginAIDescription, saved.MockContents[0].Origin)
+	assert.Nil(t, saved.MockContents[0].Matcher, "without a method the variants answer every method")
+}
+
+func TestAIMockService_GenerateMockForMethod(t *testing.T) {
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	t.Cleanup(store.Close)
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	project := models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: team.ID}
+	require.NoError(t, db.Create(&project).Error)
+
+	var prompt string
+	answer, err := json.Marshal(map[string]interface{}{"variants": []map[string]string{{"name": "Created", "status": "CREATED", "data": `{"id":1}`}}})
+	require.NoError(t, err)
+	modelsService := &MockModelsService{
+		GenerateContentFunc: func(ctx context.Context, model string, contents []*genai.Content, cfg *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+			prompt = contents[len(contents)-1].Parts[0].Text
+			return &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+				Content:      genai.NewContentFromText(string(answer), genai.RoleModel),
+				FinishReason: genai.FinishReasonStop,
+			}}}, nil
+		},
+	}
+	aiService, err := services.NewAIPromptService(config.Config{GeminiModelName: "test-model"}, modelsService)
+	require.NoError(t, err)
+	urlService := services.NewURLService(db, store)
+	aiMockService := services.NewAIMockService(aiService, urlService, services.NewMockContentService(db))
+
+	url, err := aiMockService.GenerateMock(context.Background(), project.ID, dtos.AIGenerateMockDTO{
+		Description: "create an order",
+		Path:        "/orders",
+		Method:      "POST",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "Endpoint: POST /orders")
+	assert.Equal(t, "POST /orders", url.Name, "the default name names the method")
+
+	saved, err := urlService.FindByProjectIDAndURL(project.ID, "/orders")
+	require.NoError(t, err)
+	require.Len(t, saved.MockContents, 1)
+	require.NotNil(t, saved.MockContents[0].Matcher)
+	assert.Equal(t, "POST", saved.MockContents[0].Matcher.Method, "the variants only answer the generated method")
+}
+
+func TestAIMockService_GenerateMockRejectsInvalidOutput(t *testing.T) {
+	t.Run("body_is_not_json", func(t *testing.T) {
+		aiMockService, project, urlService := newAIMockService(t, generationJSON(t,
+			map[string]string{"name": "Orders", "status": "OK", "data": `{"orders": [1, 2,]}`},
+		))
+		_, err := aiMockService.GenerateMock(context.Background(), project.ID, dtos.AIGenerateMockDTO{Description: "orders", Path: "/orders"})
+		assert.ErrorIs(t, err, services.ErrAIInvalidOutput)
+
+		_, err = urlService.FindByProjectIDAndURL(project.ID, "/orders")
+		assert.Error(t, err, "nothing is saved for an invalid generation")
+	})
+
+	t.Run("save_fails", func(t *testing.T) {
+		aiMockService, project, urlService := newAIMockService(t, generationJSON(t,
+			map[string]string{"name": "Orders", "status": "OK", "data": `[]`},
+		))
+		require.NoError(t, urlService.DB.Callback().Create().Before("gorm:create").Register("fail_mock_contents", func(tx *gorm.DB) {
+			if tx.Statement.Table == "mock_contents" {
+				_ = tx.AddError(errors.New("disk full"))
+			}
+		}))
+		_, err := aiMockService.GenerateMock(context.Background(), project.ID, dtos.AIGenerateMockDTO{Description: "orders", Path: "/orders"})
+		require.Error(t, err)
+
+		var count int64
+		require.NoError(t, urlService.DB.Unscoped().Model(&models.Url{}).Where("url = ?", "/orders").Count(&count).Error)
+		assert.Zero(t, count, "the URL is rolled back with its mock contents, so the path stays free")
+	})
+
+	t.Run("missing_error_variant", func(t *testing.T) {
+		aiMockService, project, _ := newAIMockService(t, generationJSON(t,
+			map[string]string{"name": "Orders", "status": "OK", "data": `[]`},
+		))
+		_, err := aiMockService.GenerateMock(context.Background(), project.ID, dtos.AIGenerateMockDTO{Description: "orders", Path: "/orders", IncludeErrorVariant: true})
+		assert.ErrorIs(t, err, services.ErrAIInvalidOutput)
+	})
+
+	t.Run("answer_is_not_json", func(t *testing.T) {
+		aiMockService, project, _ := newAIMockService(t, "Sure! Here are your orders")
+		_, err := aiMockService.GenerateMock(context.Background(), project.ID, dtos.AIGenerateMockDTO{Description: "orders", Path: "/orders"})
+		assert.ErrorIs(t, err, services.ErrAIInvalidOutput)
+	})
+}
+
+// createOrdersURL creates a URL with one hand-written variant.
+func createOrdersURL(t *testing.T, urlService *services.URLService, projectID uint) *models.Url {
+	url := &models.Url{Name: "Orders", URL: "/orders", Status: models.StatusOK, MockContents: []models.MockContent{
+		{Name: "Two orders", Data: `{"orders":[{"id":1,"customer":"Ada"},{"id":2,"customer":"Linus"}],"total":2}`, Randomness: 10, Latency: 50},
+	}}
+	require.NoError(t, urlService.CreateURL(url, projectID))
+	url, err := urlService.GetURLByID(url.ID)
+	require.NoError(t, err)
+	return url
+}
+
+const ordersSchema = `{
+	"type": "object",
+	"required": ["orders", "total"],
+	"properties": {
+		"orders": {"type": "array", "items": {"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}},
+		"total": {"type": "integer", "minimum": 0}
+	}
+}`
+
+func TestAIMockService_GenerateSchemaVariants(t *testing.T) {
+	answer := generationJSON(t,
+		map[string]string{"name": "One order", "data": `{"orders":[{"id":7}],"total":1}`},
+		map[string]string{"name": "No orders", "data": `{"orders":[],"total":0}`},
+	)
+	aiMockService, project, urlService := newAIMockService(t, answer)
+	url := createOrdersURL(t, urlService, project.ID)
+
+	contents, err := aiMockService.GenerateSchemaVariants(context.Background(), url, dtos.AISchemaVariantsDTO{Schema: json.RawMessage(ordersSchema), Variants: 2})
+	require.NoError(t, err)
+	require.Len(t, contents, 2)
+
+	saved, err := urlService.GetURLByID(url.ID)
+	require.NoError(t, err)
+	require.Len(t, saved.MockContents, 3, "variants are added to the existing ones")
+	assert.Empty(t, saved.MockContents[0].Origin)
+	assert.Equal(t, models.MockContentOriginAISchema, saved.MockContents[1].Origin)
+	assert.Equal(t, int64(10), saved.MockContents[1].Randomness)
+	assert.Equal(t, `{"orders":[],"total":0}`, saved.MockContents[2].Data)
+
+	_, err = aiMockService.GenerateSchemaVariants(context.Background(), url, dtos.AISchemaVariantsDTO{Schema: json.RawMessage(`{"type": "nope"}`)})
+	assert.ErrorIs(t, err, services.ErrInvalidJSONSchema)
+}
+
+func TestAIMockService_GenerateSchemaVariantsRejectsNonConformingOutput(t *testing.T) {
+	answer := generationJSON(t, map[string]string{"name": "Bad order", "data": `{"orders":[{"id":"seven"}],"total":1}`})
+	aiMockService, project, urlService := newAIMockService(t, answer)
+	url := createOrdersURL(t, urlService, project.ID)
+
+	_, err := aiMockService.GenerateSchemaVariants(context.Background(), url, dtos.AISchemaVariantsDTO{Schema: json.RawMessage(ordersSchema), Variants: 1})
+	assert.ErrorIs(t, err, services.ErrAIInvalidOutput)
+	assert.Contains(t, err.Error(), "/orders/0/id")
+
+	saved, err := urlService.GetURLByID(url.ID)
+	require.NoError(t, err)
+	assert.Len(t, saved.MockContents, 1, "nothing is saved")
+}
+
+func TestAIMockService_GenerateEdgeCases(t *testing.T) {
+	answer := generationJSON(t,
+		map[string]string{"category": "unicode", "name": "Unicode customers", "data": `{"orders":[{"id":1,"customer":"Zoë 李"}],"total":1}`},
+		map[string]string{"category": "empty_lists", "name": "No orders", "data": `{"orders":[],"total":0}`},
+	)
+	aiMockService, project, urlService := newAIMockService(t, answer)
+	url := createOrdersURL(t, urlService, project.ID)
+	source := &url.MockContents[0]
+
+	contents, err := aiMockService.GenerateEdgeCases(context.Background(), url, source, dtos.AIEdgeCasesDTO{
+		MockContentID: source.ID,
+		Categories:    []string{"unicode", "empty_lists"},
+	})
+	require.NoError(t, err)
+	require.Len(t, contents, 2)
+	assert.Equal(t, "No orders", contents[0].Name, "variants follow the order of the categories")
+	for _, content := range contents {
+		assert.Equal(t, models.MockContentOriginAIEdgeCase, content.Origin)
+		require.NotNil(t, content.SourceMockContentID)
+		assert.Equal(t, source.ID, *content.SourceMockContentID)
+		assert.Equal(t, int64(1), content.Randomness, "edge cases are served now and then")
+		assert.Equal(t, int64(50), content.Latency)
+	}
+
+	_, err = aiMockService.GenerateEdgeCases(context.Background(), url, source, dtos.AIEdgeCasesDTO{MockContentID: source.ID, Categories: []string{"nulls"}})
+	assert.ErrorIs(t, err, services.ErrAIInvalidOutput, "a requested category is missing")
+
+	text := &models.MockContent{Name: "Plain", Data: "not json"}
+	_, err = aiMockService.GenerateEdgeCases(context.Background(), url, text, dtos.AIEdgeCasesDTO{})
+	assert.ErrorIs(t, err, services.ErrMockContentNotJSON)
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"mockapi/tracing"
+)
+
+// defaultOpenAIBaseURL is used when AI_BASE_URL is not set.
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIProvider generates content with an OpenAI-compatible chat completions API. Self-hosted
+// servers such as Ollama (http://localhost:11434/v1) and vLLM expose the same API.
+type OpenAIProvider struct {
+	BaseURL    string // Up to and including the version, e.g. https://api.openai.com/v1
+	APIKey     string // Sent as a bearer token when set; local servers usually need none
+	HTTPClient *http.Client
+	model      string
+}
+
+// NewOpenAIProvider creates an OpenAIProvider whose requests time out after timeout (0 for none).
+func NewOpenAIProvider(baseURL, apiKey, model string, timeout time.Duration) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	return &OpenAIProvider{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: timeout, Transport: tracing.HTTPTransport(nil)},
+		model:      model,
+	}
+}
+
+func (p *OpenAIProvider) Name() string  { return AIProviderOpenAI }
+func (p *OpenAIProvider) Model() string { return p.model }
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAIMessage       `json:"messages"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+	Stream         bool                  `json:"stream,omitempty"`
+	StreamOptions  *openAIStreamOptions  `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+type openAIJSONSchema struct {
+	Name   string    `json:"name"`
+	Schema *AISchema `json:"schema"`
+}
+
+// openAIChatResponse holds the fields read from a chat completion; the rest is kept in Raw.
+type openAIChatResponse struct {
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *AIUsage `json:"usage"`
+}
+
+// Generate sends the request as a chat completion. A response schema is sent as a json_schema response format.
+func (p *OpenAIProvider) Generate(ctx context.Context, req AIRequest) (*AIResponse, error) {
+	resp, err := p.post(ctx, p.chatRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat completion: %w", err)
+	}
+	var completion openAIChatResponse
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return nil, fmt.Errorf("failed to decode chat completion: %w", err)
+	}
+	var raw map[string]interface{}
+	_ = json.Unmarshal(body, &raw)
+
+	result := &AIResponse{Raw: raw}
+	if len(completion.Choices) > 0 {
+		result.Text = completion.Choices[0].Message.Content
+		result.FinishReason = completion.Choices[0].FinishReason
+	}
+	if completion.Usage != nil {
+		result.Usage = *completion.Usage
+	}
+	return result, nil
+}
+
+// openAIChatChunk is one server-sent event of a streamed chat completion. The last one carries the usage.
+type openAIChatChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *AIUsage `json:"usage"`
+}
+
+// GenerateStream sends the request as a streamed chat completion and relays the content deltas.
+func (p *OpenAIProvider) GenerateStream(ctx context.Context, req AIRequest, emit func(text string) error) (*AIResponse, error) {
+	chat := p.chatRequest(req)
+	chat.Stream = true
+	chat.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	resp, err := p.post(ctx, chat)
+	if err != nil {
+		return nil, err
+	}
+	// Returning closes the body, which cancels the upstream generation
+	defer resp.Body.Close()
+
+	result := &AIResponse{}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // Blank separators, comments and other SSE fields
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk openAIChatChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode chat completion chunk: %w", err)
+		}
+		if chunk.Usage != nil {
+			result.Usage = *chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		if chunk.Choices[0].FinishReason != "" {
+			result.FinishReason = chunk.Choices[0].FinishReason
+		}
+		if text := chunk.Choices[0].Delta.Content; text != "" {
+			if err := emit(text); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read chat completion stream: %w", err)
+	}
+	return result, nil
+}
+
+func (p *OpenAIProvider) chatRequest(req AIRequest) openAIChatRequest {
+	chat := openAIChatRequest{Model: p.model}
+	if req.SystemInstruction != "" {
+		chat.Messages = append(chat.Messages, openAIMessage{Role: "system", Content: req.SystemInstruction})
+	}
+	chat.Messages = append(chat.Messages, openAIMessage{Role: "user", Content: req.Prompt})
+	if req.ResponseSchema != nil {
+		chat.ResponseFormat = &openAIResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &openAIJSONSchema{Name: "response", Schema: req.ResponseSchema},
+		}
+	}
+	return chat
+}
+
+// post sends a chat completion request and returns the response once it has a 200 status.
+func (p *OpenAIProvider) post(ctx context.Context, payload interface{}) (*http.Response, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal chat completion request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL+"/chat/completions", bytes.NewReader(payloadBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call chat completions endpoint: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("chat completions endpoint returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
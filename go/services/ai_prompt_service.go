@@ -4,69 +4,176 @@ import (
 	"context"
 	"encoding/json" // Will be used for marshalling the full response
 	"fmt"
-	"log"
-	// "strings" // No longer needed after switching to full response marshalling
-
-	// "google.golang.org/api/option" // Not used here if client is passed in
-	"google.golang.org/genai"
+	"log/slog"
+	"time"
 
 	"mockapi/config"
+	"mockapi/metrics"
 )
 
-// AIPromptService handles interactions with the Gemini AI model.
+// AIPromptService handles interactions with the configured AI provider.
 type AIPromptService struct {
-	modelsService ModelsServiceInterface // Use the new interface for client.Models
-	modelName     string               // Store the model name
+	provider AIProvider
+	Timeout  time.Duration // Bounds each call; 0 leaves it to the caller's context
+	// Cache answers repeated requests without calling the provider; optional
+	Cache *AIResponseCache
+	// Usage enforces the budgets of the project and team set with WithAIScope and records their usage; optional
+	Usage *AIUsageService
 }
 
-// NewAIPromptService creates a new AIPromptService.
-// It now accepts ModelsServiceInterface for better testability.
+// NewAIPromptService creates an AIPromptService backed by Gemini.
+// It accepts ModelsServiceInterface for better testability.
 func NewAIPromptService(cfg config.Config, modelsSvc ModelsServiceInterface) (*AIPromptService, error) {
 	if modelsSvc == nil {
 		return nil, fmt.Errorf("ModelsServiceInterface cannot be nil for AIPromptService")
 	}
 
-	modelName := cfg.GeminiModelName
+	modelName := aiModel(cfg, cfg.GeminiModelName)
 	if modelName == "" {
-		log.Println("Warning: Gemini model name not configured in cfg, using default 'gemini-1.5-flash-latest'")
+		slog.Warn("Gemini model name not configured, using the default", "model", "gemini-1.5-flash-latest")
 		modelName = "gemini-1.5-flash-latest"
 	}
 
-	return &AIPromptService{modelsService: modelsSvc, modelName: modelName}, nil
+	return NewAIPromptServiceWithProvider(NewGeminiProvider(modelsSvc, modelName), time.Duration(cfg.AITimeoutSeconds)*time.Second), nil
+}
+
+// NewAIPromptServiceWithProvider creates an AIPromptService backed by any provider.
+func NewAIPromptServiceWithProvider(provider AIProvider, timeout time.Duration) *AIPromptService {
+	return &AIPromptService{provider: provider, Timeout: timeout}
 }
 
-// GetAIResponse sends a prompt to the Gemini model and returns the response as a map.
+// Provider returns the provider behind the service.
+func (s *AIPromptService) Provider() AIProvider {
+	return s.provider
+}
+
+// GetAIResponse sends a prompt to the provider and returns the provider's response as a map.
 func (s *AIPromptService) GetAIResponse(ctx context.Context, prompt string) (map[string]interface{}, error) {
-	if s.modelsService == nil {
-		return nil, fmt.Errorf("models service is not initialized in AIPromptService")
+	resp, err := s.generate(ctx, AIRequest{Prompt: prompt})
+	if err != nil {
+		return nil, err
 	}
 
-	// Construct content for client.Models.GenerateContent
-	part := genai.NewPartFromText(prompt) // Returns *Part
-	// NewContentFromParts expects []*Part, and genai.RoleUser is "user"
-	content := genai.NewContentFromParts([]*genai.Part{part}, genai.RoleUser)
-	contents := []*genai.Content{content}
+	var jsonResponse map[string]interface{}
+	responseBytes, err := json.Marshal(resp.Raw)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal AI response", "provider", s.provider.Name(), "error", err)
+		return nil, fmt.Errorf("failed to marshal %s response: %w", s.provider.Name(), err)
+	}
+	if err := json.Unmarshal(responseBytes, &jsonResponse); err != nil {
+		slog.ErrorContext(ctx, "Failed to unmarshal AI response", "provider", s.provider.Name(), "error", err)
+		return nil, fmt.Errorf("failed to unmarshal %s response to map: %w", s.provider.Name(), err)
+	}
+	return jsonResponse, nil
+}
 
-	// Call GenerateContent on the ModelsServiceInterface
-	resp, err := s.modelsService.GenerateContent(ctx, s.modelName, contents, nil) // Pass nil for GenerateContentConfig for now
+// GenerateJSON asks the model for a response in JSON that conforms to schema and returns the JSON text.
+// The system instruction steers the model; the prompt is the user's request.
+func (s *AIPromptService) GenerateJSON(ctx context.Context, systemInstruction, prompt string, schema *AISchema) (string, error) {
+	resp, err := s.generate(ctx, AIRequest{SystemInstruction: systemInstruction, Prompt: prompt, ResponseSchema: schema})
 	if err != nil {
-		log.Printf("Error from Gemini API call: %v", err)
-		return nil, fmt.Errorf("gemini API call failed: %w", err)
+		return "", err
+	}
+	if !json.Valid([]byte(resp.Text)) {
+		return "", fmt.Errorf("%w: the model did not return valid JSON (finish reason %s)", ErrAIInvalidOutput, resp.FinishReason)
 	}
+	return resp.Text, nil
+}
 
-	if resp == nil {
-		return nil, fmt.Errorf("received nil response from Gemini API")
+// generate answers req from the cache, or calls the provider with the service's timeout once the
+// budgets allow it, recording the call's metrics and usage.
+func (s *AIPromptService) generate(ctx context.Context, req AIRequest) (*AIResponse, error) {
+	if s.provider == nil {
+		return nil, fmt.Errorf("AI provider is not initialized in AIPromptService")
+	}
+	// Cached answers cost nothing, so they are served even when the budget is used up
+	if resp, ok := s.Cache.Get(ctx, s.provider.Name(), s.provider.Model(), req); ok {
+		return resp, nil
+	}
+	if err := s.reserveUsage(ctx); err != nil {
+		return nil, err
+	}
+	callCtx := ctx
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
 	}
 
-	var jsonResponse map[string]interface{}
-	responseBytes, err := json.Marshal(resp)
+	start := time.Now()
+	resp, err := s.provider.Generate(callCtx, req)
+	metrics.ObserveAICall(s.provider.Model(), time.Since(start), err)
 	if err != nil {
-		log.Printf("Error marshalling Gemini response: %v", err)
-		return nil, fmt.Errorf("failed to marshal gemini response: %w", err)
+		s.releaseUsage(ctx)
+		slog.ErrorContext(ctx, "AI API call failed", "provider", s.provider.Name(), "model", s.provider.Model(), "error", err)
+		return nil, fmt.Errorf("%s API call failed: %w", s.provider.Name(), err)
 	}
-	if err := json.Unmarshal(responseBytes, &jsonResponse); err != nil {
-		log.Printf("Error unmarshalling Gemini response bytes to map: %v", err)
-		return nil, fmt.Errorf("failed to unmarshal gemini response to map: %w", err)
+	s.recordUsage(ctx, resp.Usage)
+	s.Cache.Set(ctx, s.provider.Name(), s.provider.Model(), req, resp)
+	return resp, nil
+}
+
+// CheckBudget returns ErrAIBudgetExceeded when the project or team set with WithAIScope has no budget left.
+// Callers that cannot report errors once they start answering, such as streams, check it upfront.
+func (s *AIPromptService) CheckBudget(ctx context.Context) error {
+	if s.Usage == nil {
+		return nil
 	}
-	return jsonResponse, nil
+	return s.Usage.Check(ctx)
+}
+
+// reserveUsage counts the call against the budgets before it is made, so concurrent calls cannot overspend them.
+func (s *AIPromptService) reserveUsage(ctx context.Context) error {
+	if s.Usage == nil {
+		return nil
+	}
+	return s.Usage.Reserve(ctx)
+}
+
+// releaseUsage gives back the reservation of a call that failed. The caller's context may already be done,
+// so the release does not depend on it.
+func (s *AIPromptService) releaseUsage(ctx context.Context) {
+	if s.Usage == nil {
+		return
+	}
+	s.Usage.Release(context.WithoutCancel(ctx))
+}
+
+// recordUsage charges the tokens of a completed call. A call that could not be recorded is logged rather than failed,
+// since the provider has already answered it.
+func (s *AIPromptService) recordUsage(ctx context.Context, usage AIUsage) {
+	if s.Usage == nil {
+		return
+	}
+	if err := s.Usage.Record(ctx, usage); err != nil {
+		slog.WarnContext(ctx, "Failed to record AI usage", "error", err)
+	}
+}
+
+// StreamAIResponse sends a prompt to the provider and passes the text to emit as it is generated.
+// It returns the finish reason and token usage once the generation ends. Streams are not cached.
+func (s *AIPromptService) StreamAIResponse(ctx context.Context, prompt string, emit func(text string) error) (*AIResponse, error) {
+	if s.provider == nil {
+		return nil, fmt.Errorf("AI provider is not initialized in AIPromptService")
+	}
+	if err := s.reserveUsage(ctx); err != nil {
+		return nil, err
+	}
+	callCtx := ctx
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	resp, err := s.provider.GenerateStream(callCtx, AIRequest{Prompt: prompt}, emit)
+	metrics.ObserveAICall(s.provider.Model(), time.Since(start), err)
+	if err != nil {
+		s.releaseUsage(ctx)
+		slog.ErrorContext(ctx, "AI streaming call failed", "provider", s.provider.Name(), "model", s.provider.Model(), "error", err)
+		return nil, fmt.Errorf("%s API call failed: %w", s.provider.Name(), err)
+	}
+	s.recordUsage(ctx, resp.Usage)
+	return resp, nil
 }
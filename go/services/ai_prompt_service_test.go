@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"iter"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -16,7 +17,8 @@ import (
 
 // MockModelsService implements the ModelsServiceInterface for testing.
 type MockModelsService struct {
-	GenerateContentFunc func(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error)
+	GenerateContentFunc       func(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error)
+	GenerateContentStreamFunc func(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) iter.Seq2[*genai.GenerateContentResponse, error]
 }
 
 func (m *MockModelsService) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
@@ -26,6 +28,15 @@ func (m *MockModelsService) GenerateContent(ctx context.Context, model string, c
 	return nil, fmt.Errorf("MockModelsService.GenerateContentFunc not implemented")
 }
 
+func (m *MockModelsService) GenerateContentStream(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) iter.Seq2[*genai.GenerateContentResponse, error] {
+	if m.GenerateContentStreamFunc != nil {
+		return m.GenerateContentStreamFunc(ctx, model, contents, config)
+	}
+	return func(yield func(*genai.GenerateContentResponse, error) bool) {
+		yield(nil, fmt.Errorf("MockModelsService.GenerateContentStreamFunc not implemented"))
+	}
+}
+
 func TestAIPromptService_GetAIResponse(t *testing.T) {
 	ctx := context.Background()
 	dummyConfig := config.Config{GeminiModelName: "test-model"}
@@ -157,3 +168,39 @@ func TestAIPromptService_GetAIResponse(t *testing.T) {
 		assert.EqualError(t, err, "ModelsServiceInterface cannot be nil for AIPromptService")
 	})
 }
+
+func TestAIPromptService_StreamAIResponse(t *testing.T) {
+	mockModelsSvc := &MockModelsService{
+		GenerateContentStreamFunc: func(ctx context.Context, model string, contents []*genai.Content, cfg *genai.GenerateContentConfig) iter.Seq2[*genai.GenerateContentResponse, error] {
+			return func(yield func(*genai.GenerateContentResponse, error) bool) {
+				for _, text := range []string{"Hello", " from", " Gemini"} {
+					resp := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{Content: genai.NewContentFromText(text, genai.RoleModel)}}}
+					if text == " Gemini" {
+						resp.Candidates[0].FinishReason = genai.FinishReasonStop
+						resp.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 4, CandidatesTokenCount: 3, TotalTokenCount: 7}
+					}
+					if !yield(resp, nil) {
+						return
+					}
+				}
+			}
+		},
+	}
+	service, err := services.NewAIPromptService(config.Config{GeminiModelName: "test-model"}, mockModelsSvc)
+	require.NoError(t, err)
+
+	var streamed string
+	resp, err := service.StreamAIResponse(context.Background(), "hi", func(text string) error {
+		streamed += text
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello from Gemini", streamed)
+	assert.Equal(t, string(genai.FinishReasonStop), resp.FinishReason)
+	assert.Equal(t, services.AIUsage{PromptTokens: 4, CompletionTokens: 3, TotalTokens: 7}, resp.Usage)
+
+	stop := errors.New("client went away")
+	_, err = service.StreamAIResponse(context.Background(), "hi", func(text string) error { return stop })
+	assert.ErrorIs(t, err, stop, "an emit error ends the stream")
+}
+
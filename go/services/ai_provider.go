@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"google.golang.org/genai"
+
+	"mockapi/config"
+	"mockapi/tracing"
+)
+
+// AI providers selectable with AI_PROVIDER.
+const (
+	AIProviderGemini = "gemini"
+	AIProviderOpenAI = "openai" // Any OpenAI-compatible chat completions endpoint, e.g. Ollama or vLLM
+	AIProviderStub   = "stub"   // Deterministic answers without a model, for tests and local development
+)
+
+// AIProvider generates content with one AI backend. AIPromptService adds timeouts and metrics on top.
+type AIProvider interface {
+	Name() string
+	Model() string
+	Generate(ctx context.Context, req AIRequest) (*AIResponse, error)
+	// GenerateStream passes the text to emit as it is generated and returns the finish reason and usage
+	// once generation ends. Cancelling ctx, or emit returning an error, stops the upstream generation.
+	GenerateStream(ctx context.Context, req AIRequest, emit func(text string) error) (*AIResponse, error)
+}
+
+// AIRequest is a single-turn generation.
+type AIRequest struct {
+	SystemInstruction string
+	Prompt            string
+	// ResponseSchema asks for JSON conforming to the schema instead of free text
+	ResponseSchema *AISchema
+}
+
+// AIResponse is the generated text with the provider's own response in Raw. Streams leave Text and Raw empty.
+type AIResponse struct {
+	Text         string
+	FinishReason string
+	Usage        AIUsage
+	Raw          interface{} // Provider-specific response, returned as is by GetAIResponse
+}
+
+// AIUsage is the token usage reported by the provider.
+type AIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// AISchema is the subset of JSON Schema that every provider supports for structured output.
+type AISchema struct {
+	Type        string               `json:"type"` // object, array, string, integer, number or boolean
+	Description string               `json:"description,omitempty"`
+	Properties  map[string]*AISchema `json:"properties,omitempty"`
+	Required    []string             `json:"required,omitempty"`
+	Items       *AISchema            `json:"items,omitempty"`
+	Enum        []string             `json:"enum,omitempty"`
+	// PropertyOrder is the order in which the model should write the properties
+	PropertyOrder []string `json:"-"`
+	// JSON marks a string that holds an encoded JSON document, since the schema cannot describe arbitrary JSON
+	JSON bool `json:"-"`
+}
+
+// NewAIProvider creates the provider selected by the configuration, or returns nil when AI is not configured.
+// Without AI_PROVIDER, Gemini is used when GEMINI_API_KEY is set.
+func NewAIProvider(cfg config.Config) (AIProvider, error) {
+	providerName := cfg.AIProvider
+	if providerName == "" && cfg.GeminiAPIKey != "" {
+		providerName = AIProviderGemini
+	}
+	timeout := time.Duration(cfg.AITimeoutSeconds) * time.Second
+
+	switch providerName {
+	case "":
+		return nil, nil
+	case AIProviderGemini:
+		apiKey := cfg.AIAPIKey
+		if apiKey == "" {
+			apiKey = cfg.GeminiAPIKey
+		}
+		if apiKey == "" {
+			return nil, fmt.Errorf("AI_PROVIDER=gemini needs AI_API_KEY or GEMINI_API_KEY")
+		}
+		clientConfig := &genai.ClientConfig{
+			APIKey:     apiKey,
+			Backend:    genai.BackendGeminiAPI,
+			HTTPClient: &http.Client{Timeout: timeout, Transport: tracing.HTTPTransport(nil)},
+		}
+		if cfg.AIBaseURL != "" {
+			clientConfig.HTTPOptions.BaseURL = cfg.AIBaseURL
+		}
+		client, err := genai.NewClient(context.Background(), clientConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+		}
+		return NewGeminiProvider(client.Models, aiModel(cfg, cfg.GeminiModelName)), nil
+	case AIProviderOpenAI:
+		if cfg.AIModel == "" {
+			return nil, fmt.Errorf("AI_PROVIDER=openai needs AI_MODEL")
+		}
+		return NewOpenAIProvider(cfg.AIBaseURL, cfg.AIAPIKey, cfg.AIModel, timeout), nil
+	case AIProviderStub:
+		return NewStubProvider(aiModel(cfg, "stub")), nil
+	}
+	return nil, fmt.Errorf("unknown AI_PROVIDER '%s' (use gemini, openai or stub)", providerName)
+}
+
+// aiModel returns AI_MODEL, or fallback when it is not set.
+func aiModel(cfg config.Config, fallback string) string {
+	if cfg.AIModel != "" {
+		return cfg.AIModel
+	}
+	return fallback
+}
@@ -0,0 +1,157 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mockapi/config"
+	"mockapi/services"
+)
+
+func TestNewAIProvider(t *testing.T) {
+	provider, err := services.NewAIProvider(config.Config{})
+	require.NoError(t, err)
+	assert.Nil(t, provider, "AI is disabled without configuration")
+
+	provider, err = services.NewAIProvider(config.Config{GeminiAPIKey: "key", GeminiModelName: "gemini-test"})
+	require.NoError(t, err)
+	assert.Equal(t, services.AIProviderGemini, provider.Name(), "a Gemini key keeps selecting Gemini")
+	assert.Equal(t, "gemini-test", provider.Model())
+
+	provider, err = services.NewAIProvider(config.Config{AIProvider: "openai", AIModel: "llama3", AIBaseURL: "http://localhost:11434/v1"})
+	require.NoError(t, err)
+	assert.Equal(t, services.AIProviderOpenAI, provider.Name())
+	assert.Equal(t, "llama3", provider.Model())
+
+	_, err = services.NewAIProvider(config.Config{AIProvider: "openai"})
+	assert.Error(t, err, "the OpenAI-compatible provider needs a model")
+
+	_, err = services.NewAIProvider(config.Config{AIProvider: "watson"})
+	assert.Error(t, err)
+}
+
+func TestOpenAIProvider_Generate(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":"{\"name\":\"Ada\"}"},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":5,"total_tokens":17}}`))
+	}))
+	defer server.Close()
+
+	provider := services.NewOpenAIProvider(server.URL+"/v1/", "secret", "llama3", time.Second)
+	resp, err := provider.Generate(context.Background(), services.AIRequest{
+		SystemInstruction: "Be brief.",
+		Prompt:            "A user",
+		ResponseSchema:    &services.AISchema{Type: "object", Properties: map[string]*services.AISchema{"name": {Type: "string"}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"Ada"}`, resp.Text)
+	assert.Equal(t, "stop", resp.FinishReason)
+	assert.Equal(t, services.AIUsage{PromptTokens: 12, CompletionTokens: 5, TotalTokens: 17}, resp.Usage)
+
+	assert.Equal(t, "llama3", received["model"])
+	messages := received["messages"].([]interface{})
+	require.Len(t, messages, 2)
+	assert.Equal(t, "system", messages[0].(map[string]interface{})["role"])
+	responseFormat := received["response_format"].(map[string]interface{})
+	assert.Equal(t, "json_schema", responseFormat["type"])
+	assert.NotNil(t, responseFormat["json_schema"].(map[string]interface{})["schema"])
+}
+
+func TestOpenAIProvider_GenerateFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"model not found"}}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := services.NewOpenAIProvider(server.URL, "", "missing", time.Second).Generate(context.Background(), services.AIRequest{Prompt: "hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "model not found")
+}
+
+func TestStubProviderIsDeterministic(t *testing.T) {
+	aiService := services.NewAIPromptServiceWithProvider(services.NewStubProvider("stub"), time.Second)
+	schema := &services.AISchema{Type: "object", Properties: map[string]*services.AISchema{
+		"status": {Type: "string", Enum: []string{"OK", "NOT_FOUND"}},
+		"items":  {Type: "array", Items: &services.AISchema{Type: "integer"}},
+		"body":   {Type: "string", JSON: true},
+	}}
+
+	first, err := aiService.GenerateJSON(context.Background(), "", "anything", schema)
+	require.NoError(t, err)
+	second, err := aiService.GenerateJSON(context.Background(), "", "anything else", schema)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.JSONEq(t, `{"status":"OK","items":[1],"body":"{\"stub\":true}"}`, first)
+}
+
+func TestOpenAIProvider_GenerateStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, true, req["stream"])
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range []string{
+			`{"choices":[{"delta":{"role":"assistant","content":""},"finish_reason":null}]}`,
+			`{"choices":[{"delta":{"content":"Hello"},"finish_reason":null}]}`,
+			`{"choices":[{"delta":{"content":" world"},"finish_reason":"stop"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`,
+			`[DONE]`,
+		} {
+			_, _ = w.Write([]byte("data: " + event + "\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	var chunks []string
+	resp, err := services.NewOpenAIProvider(server.URL, "", "llama3", time.Second).GenerateStream(context.Background(), services.AIRequest{Prompt: "hi"}, func(text string) error {
+		chunks = append(chunks, text)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hello", " world"}, chunks)
+	assert.Equal(t, "stop", resp.FinishReason)
+	assert.Equal(t, 5, resp.Usage.TotalTokens)
+}
+
+func TestOpenAIProvider_GenerateStreamCancelsUpstream(t *testing.T) {
+	upstreamDone := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(upstreamDone)
+		for {
+			if _, err := w.Write([]byte(`data: {"choices":[{"delta":{"content":"more "}}]}` + "\n\n")); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return // The client went away
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := services.NewOpenAIProvider(server.URL, "", "llama3", 0).GenerateStream(ctx, services.AIRequest{Prompt: "hi"}, func(text string) error {
+		cancel()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	select {
+	case <-upstreamDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the upstream generation kept running after the stream was cancelled")
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"mockapi/metrics"
+)
+
+// aiCachePrefix starts every key written by AIResponseCache. Keys are laid out as
+// aicache:<provider>:<model>:<request hash>, so a model's answers are dropped with a single pattern delete.
+const aiCachePrefix = "aicache"
+
+// AIResponseCache keeps provider responses in the key-value store, so identical requests to the same
+// provider and model are answered without calling it again. A nil AIResponseCache, or one with a TTL
+// of zero, caches nothing.
+type AIResponseCache struct {
+	Store KeyValueStore
+	TTL   time.Duration
+}
+
+// NewAIResponseCache creates an AIResponseCache whose entries expire after ttl.
+func NewAIResponseCache(store KeyValueStore, ttl time.Duration) *AIResponseCache {
+	return &AIResponseCache{Store: store, TTL: ttl}
+}
+
+func (c *AIResponseCache) enabled() bool {
+	return c != nil && c.Store != nil && c.TTL > 0
+}
+
+// cachedAIResponse is the stored form of an AIResponse.
+type cachedAIResponse struct {
+	Text         string          `json:"text"`
+	FinishReason string          `json:"finish_reason"`
+	Usage        AIUsage         `json:"usage"`
+	Raw          json.RawMessage `json:"raw,omitempty"`
+}
+
+// normalizePrompt trims the text and collapses runs of whitespace, which do not change the answer.
+func normalizePrompt(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// key hashes everything that shapes the answer: the normalized system instruction and prompt, and the schema.
+func (c *AIResponseCache) key(provider, model string, req AIRequest) string {
+	hash := sha256.New()
+	hash.Write([]byte(normalizePrompt(req.SystemInstruction)))
+	hash.Write([]byte{0})
+	hash.Write([]byte(normalizePrompt(req.Prompt)))
+	if req.ResponseSchema != nil {
+		schema, _ := json.Marshal(req.ResponseSchema)
+		hash.Write([]byte{0})
+		hash.Write(schema)
+	}
+	return c.Store.CreateRedisKey(aiCachePrefix, provider, model, hex.EncodeToString(hash.Sum(nil)))
+}
+
+// Get returns the cached response to req. Raw holds the provider's response decoded as generic JSON.
+// Store errors are logged and reported as a miss.
+func (c *AIResponseCache) Get(ctx context.Context, provider, model string, req AIRequest) (*AIResponse, bool) {
+	if !c.enabled() {
+		return nil, false
+	}
+	key := c.key(provider, model, req)
+	value, err := c.Store.GetValue(ctx, key)
+	if err != nil {
+		slog.Warn("Failed to read cached AI response", "key", key, "error", err)
+	}
+	var cached cachedAIResponse
+	if err != nil || value == "" || json.Unmarshal([]byte(value), &cached) != nil {
+		metrics.ObserveAICache(false)
+		return nil, false
+	}
+	metrics.ObserveAICache(true)
+
+	resp := &AIResponse{Text: cached.Text, FinishReason: cached.FinishReason, Usage: cached.Usage}
+	if len(cached.Raw) > 0 {
+		var raw interface{}
+		if json.Unmarshal(cached.Raw, &raw) == nil {
+			resp.Raw = raw
+		}
+	}
+	return resp, true
+}
+
+// Set caches the response to req. Store errors are logged, since the response itself is fine.
+func (c *AIResponseCache) Set(ctx context.Context, provider, model string, req AIRequest, resp *AIResponse) {
+	if !c.enabled() || resp == nil {
+		return
+	}
+	cached := cachedAIResponse{Text: resp.Text, FinishReason: resp.FinishReason, Usage: resp.Usage}
+	if resp.Raw != nil {
+		raw, err := json.Marshal(resp.Raw)
+		if err != nil {
+			slog.Warn("Failed to encode AI response for the cache", "provider", provider, "error", err)
+			return
+		}
+		cached.Raw = raw
+	}
+	value, err := json.Marshal(cached)
+	if err != nil {
+		slog.Warn("Failed to encode AI response for the cache", "provider", provider, "error", err)
+		return
+	}
+	key := c.key(provider, model, req)
+	if err := c.Store.SetValue(ctx, key, string(value), c.TTL); err != nil {
+		slog.Warn("Failed to cache AI response", "key", key, "error", err)
+	}
+}
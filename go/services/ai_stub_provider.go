@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StubProvider answers without a model: free-text prompts are echoed and structured requests get
+// the same schema-conforming JSON every time, so tests and offline environments behave predictably.
+type StubProvider struct {
+	model string
+}
+
+// NewStubProvider creates a StubProvider that reports model as its model.
+func NewStubProvider(model string) *StubProvider {
+	return &StubProvider{model: model}
+}
+
+func (p *StubProvider) Name() string  { return AIProviderStub }
+func (p *StubProvider) Model() string { return p.model }
+
+// Generate answers deterministically. Usage counts words so budgets can be exercised.
+func (p *StubProvider) Generate(ctx context.Context, req AIRequest) (*AIResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	text := "Stub response to: " + req.Prompt
+	if req.ResponseSchema != nil {
+		data, err := json.Marshal(stubValue("", req.ResponseSchema))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal stub response: %w", err)
+		}
+		text = string(data)
+	}
+	usage := AIUsage{
+		PromptTokens:     len(strings.Fields(req.SystemInstruction + " " + req.Prompt)),
+		CompletionTokens: len(strings.Fields(text)),
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return &AIResponse{
+		Text:         text,
+		FinishReason: "STOP",
+		Usage:        usage,
+		Raw:          map[string]interface{}{"text": text, "finish_reason": "STOP", "usage": usage},
+	}, nil
+}
+
+// stubValue builds the value of a schema: the first enum value, the property name for strings,
+// one item for arrays and every property for objects.
+func stubValue(name string, schema *AISchema) interface{} {
+	switch {
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case schema.JSON:
+		return `{"stub":true}`
+	}
+	switch schema.Type {
+	case "object":
+		names := make([]string, 0, len(schema.Properties))
+		for propertyName := range schema.Properties {
+			names = append(names, propertyName)
+		}
+		sort.Strings(names)
+		object := make(map[string]interface{}, len(names))
+		for _, propertyName := range names {
+			object[propertyName] = stubValue(propertyName, schema.Properties[propertyName])
+		}
+		return object
+	case "array":
+		if schema.Items == nil {
+			return []interface{}{}
+		}
+		return []interface{}{stubValue(name, schema.Items)}
+	case "integer", "number":
+		return 1
+	case "boolean":
+		return true
+	}
+	if name == "" {
+		return "stub"
+	}
+	return "stub " + name
+}
+
+// GenerateStream emits the answer of Generate word by word.
+func (p *StubProvider) GenerateStream(ctx context.Context, req AIRequest, emit func(text string) error) (*AIResponse, error) {
+	resp, err := p.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	words := strings.SplitAfter(resp.Text, " ")
+	for _, word := range words {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := emit(word); err != nil {
+			return nil, err
+		}
+	}
+	return &AIResponse{FinishReason: resp.FinishReason, Usage: resp.Usage}, nil
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"mockapi/metrics"
+)
+
+// AI usage periods selectable with AI_BUDGET_PERIOD. Usage is counted from the start of the
+// current UTC day or month and starts over at the next one.
+const (
+	AIBudgetPeriodDay   = "day"
+	AIBudgetPeriodMonth = "month"
+)
+
+// aiUsagePrefix starts every key written by AIUsageService. Keys are laid out as
+// aiusage:<period start>:<scope>:<id>, so counters of past periods simply expire.
+const aiUsagePrefix = "aiusage"
+
+// ErrAIBudgetExceeded is returned when the project or team charged for an AI call has used its budget.
+var ErrAIBudgetExceeded = errors.New("AI usage budget exceeded")
+
+// AIBudget limits the AI requests and tokens of one project or team per period. Zero means unlimited.
+type AIBudget struct {
+	Requests int64
+	Tokens   int64
+}
+
+// AIScope identifies the project and team an AI call is charged to. Zero IDs are not charged.
+type AIScope struct {
+	ProjectID uint
+	TeamID    uint
+}
+
+type aiScopeKey struct{}
+
+// WithAIScope returns a context whose AI calls are charged to scope.
+func WithAIScope(ctx context.Context, scope AIScope) context.Context {
+	return context.WithValue(ctx, aiScopeKey{}, scope)
+}
+
+// AIScopeFromContext returns the scope set with WithAIScope, or the zero scope.
+func AIScopeFromContext(ctx context.Context) AIScope {
+	scope, _ := ctx.Value(aiScopeKey{}).(AIScope)
+	return scope
+}
+
+// AIUsageReport is the usage of a project and its team in the current period.
+type AIUsageReport struct {
+	Period      string        `json:"period"`
+	PeriodStart time.Time     `json:"period_start"`
+	ResetsAt    time.Time     `json:"resets_at"`
+	Project     *AIScopeUsage `json:"project,omitempty"`
+	Team        *AIScopeUsage `json:"team,omitempty"`
+}
+
+// AIScopeUsage is the usage of one project or team against its budget (0 for unlimited).
+type AIScopeUsage struct {
+	ID            uint  `json:"id"`
+	Requests      int64 `json:"requests"`
+	Tokens        int64 `json:"tokens"`
+	RequestBudget int64 `json:"request_budget"`
+	TokenBudget   int64 `json:"token_budget"`
+	Exceeded      bool  `json:"exceeded"`
+}
+
+// aiUsageCounter is the value stored for one scope and period.
+type aiUsageCounter struct {
+	Requests int64 `json:"requests"`
+	Tokens   int64 `json:"tokens"`
+}
+
+// AIUsageService counts AI requests and tokens per project and team in the key-value store and
+// enforces their budgets. Requests are reserved before a call, so concurrent calls cannot overspend a
+// request budget. Tokens are only known once a call returns, so the calls that cross a token budget
+// complete and the next one is rejected.
+type AIUsageService struct {
+	Store         KeyValueStore
+	Period        string // AIBudgetPeriodDay or AIBudgetPeriodMonth
+	ProjectBudget AIBudget
+	TeamBudget    AIBudget
+	Now           func() time.Time
+}
+
+// NewAIUsageService creates an AIUsageService. An unknown period counts per month.
+func NewAIUsageService(store KeyValueStore, period string, projectBudget, teamBudget AIBudget) *AIUsageService {
+	if period != AIBudgetPeriodDay {
+		period = AIBudgetPeriodMonth
+	}
+	return &AIUsageService{Store: store, Period: period, ProjectBudget: projectBudget, TeamBudget: teamBudget, Now: time.Now}
+}
+
+// periodBounds returns the start of the period containing now and the start of the next one.
+func (s *AIUsageService) periodBounds(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	if s.Period == AIBudgetPeriodDay {
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1)
+	}
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+func (s *AIUsageService) key(periodStart time.Time, scope string, id uint) string {
+	return s.Store.CreateRedisKey(aiUsagePrefix, periodStart.Format("2006-01-02"), scope, strconv.FormatUint(uint64(id), 10))
+}
+
+// aiCharge is one scope an AI call is charged to.
+type aiCharge struct {
+	scope  string
+	id     uint
+	budget AIBudget
+}
+
+func (s *AIUsageService) charges(scope AIScope) []aiCharge {
+	var charges []aiCharge
+	if scope.ProjectID != 0 {
+		charges = append(charges, aiCharge{scope: "project", id: scope.ProjectID, budget: s.ProjectBudget})
+	}
+	if scope.TeamID != 0 {
+		charges = append(charges, aiCharge{scope: "team", id: scope.TeamID, budget: s.TeamBudget})
+	}
+	return charges
+}
+
+func (s *AIUsageService) counter(ctx context.Context, key string) (aiUsageCounter, error) {
+	var counter aiUsageCounter
+	value, err := s.Store.GetValue(ctx, key)
+	if err != nil || value == "" {
+		return counter, err
+	}
+	if err := json.Unmarshal([]byte(value), &counter); err != nil {
+		return counter, fmt.Errorf("failed to decode AI usage at %s: %w", key, err)
+	}
+	return counter, nil
+}
+
+func (b AIBudget) exceededBy(counter aiUsageCounter) bool {
+	return (b.Requests > 0 && counter.Requests >= b.Requests) || (b.Tokens > 0 && counter.Tokens >= b.Tokens)
+}
+
+// Check returns ErrAIBudgetExceeded when the project or team the context is charged to has no budget left.
+// It does not count anything; calls are counted with Reserve.
+func (s *AIUsageService) Check(ctx context.Context) error {
+	start, _ := s.periodBounds(s.Now())
+	for _, charge := range s.charges(AIScopeFromContext(ctx)) {
+		if charge.budget == (AIBudget{}) {
+			continue
+		}
+		counter, err := s.counter(ctx, s.key(start, charge.scope, charge.id))
+		if err != nil {
+			return err
+		}
+		if charge.budget.exceededBy(counter) {
+			metrics.IncAIBudgetRejection(charge.scope)
+			return s.exceeded(charge, counter)
+		}
+	}
+	return nil
+}
+
+// Reserve counts one request against the project and team of the context before the call is made, or
+// returns ErrAIBudgetExceeded without counting it. Each counter is checked and incremented in one atomic
+// update, and a rejection by the team gives back the request already counted for the project.
+func (s *AIUsageService) Reserve(ctx context.Context) error {
+	now := s.Now()
+	start, end := s.periodBounds(now)
+	expiration := usageExpiration(now, end)
+	charges := s.charges(AIScopeFromContext(ctx))
+	for i, charge := range charges {
+		err := s.update(ctx, s.key(start, charge.scope, charge.id), expiration, func(counter *aiUsageCounter) error {
+			if charge.budget.exceededBy(*counter) {
+				return s.exceeded(charge, *counter)
+			}
+			counter.Requests++
+			return nil
+		})
+		if err != nil {
+			s.release(ctx, charges[:i])
+			if errors.Is(err, ErrAIBudgetExceeded) {
+				metrics.IncAIBudgetRejection(charge.scope)
+				return err
+			}
+			return fmt.Errorf("failed to reserve AI usage of %s %d: %w", charge.scope, charge.id, err)
+		}
+	}
+	return nil
+}
+
+// Release gives back the request reserved for a call that failed, so only answered calls are counted.
+func (s *AIUsageService) Release(ctx context.Context) {
+	s.release(ctx, s.charges(AIScopeFromContext(ctx)))
+}
+
+func (s *AIUsageService) release(ctx context.Context, charges []aiCharge) {
+	now := s.Now()
+	start, end := s.periodBounds(now)
+	for _, charge := range charges {
+		err := s.update(ctx, s.key(start, charge.scope, charge.id), usageExpiration(now, end), func(counter *aiUsageCounter) error {
+			if counter.Requests > 0 {
+				counter.Requests--
+			}
+			return nil
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to release AI usage", "scope", charge.scope, "id", charge.id, "error", err)
+		}
+	}
+}
+
+// Record charges the tokens of usage to the project and team of the context, whose request was reserved.
+func (s *AIUsageService) Record(ctx context.Context, usage AIUsage) error {
+	now := s.Now()
+	start, end := s.periodBounds(now)
+	for _, charge := range s.charges(AIScopeFromContext(ctx)) {
+		err := s.update(ctx, s.key(start, charge.scope, charge.id), usageExpiration(now, end), func(counter *aiUsageCounter) error {
+			counter.Tokens += int64(usage.TotalTokens)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record AI usage of %s %d: %w", charge.scope, charge.id, err)
+		}
+	}
+	return nil
+}
+
+// update atomically applies change to the counter stored at key. Errors returned by change are passed
+// through as is and leave the counter untouched.
+func (s *AIUsageService) update(ctx context.Context, key string, expiration time.Duration, change func(counter *aiUsageCounter) error) error {
+	return s.Store.UpdateValue(ctx, key, expiration, func(current string) (string, error) {
+		var counter aiUsageCounter
+		if current != "" {
+			if err := json.Unmarshal([]byte(current), &counter); err != nil {
+				return "", fmt.Errorf("failed to decode AI usage: %w", err)
+			}
+		}
+		if err := change(&counter); err != nil {
+			return "", err
+		}
+		next, err := json.Marshal(counter)
+		return string(next), err
+	})
+}
+
+func (s *AIUsageService) exceeded(charge aiCharge, counter aiUsageCounter) error {
+	return fmt.Errorf("%w: the %s has used %d of %d requests and %d of %d tokens this %s",
+		ErrAIBudgetExceeded, charge.scope, counter.Requests, charge.budget.Requests, counter.Tokens, charge.budget.Tokens, s.Period)
+}
+
+// usageExpiration keeps counters for a day past their period, so the report of a period that just ended
+// stays readable.
+func usageExpiration(now, end time.Time) time.Duration {
+	return end.Sub(now) + 24*time.Hour
+}
+
+// Usage reports the usage of the scope's project and team in the current period.
+func (s *AIUsageService) Usage(ctx context.Context, scope AIScope) (*AIUsageReport, error) {
+	start, end := s.periodBounds(s.Now())
+	report := &AIUsageReport{Period: s.Period, PeriodStart: start, ResetsAt: end}
+	for _, charge := range s.charges(scope) {
+		counter, err := s.counter(ctx, s.key(start, charge.scope, charge.id))
+		if err != nil {
+			return nil, err
+		}
+		usage := &AIScopeUsage{
+			ID:            charge.id,
+			Requests:      counter.Requests,
+			Tokens:        counter.Tokens,
+			RequestBudget: charge.budget.Requests,
+			TokenBudget:   charge.budget.Tokens,
+			Exceeded:      charge.budget.exceededBy(counter),
+		}
+		if charge.scope == "project" {
+			report.Project = usage
+		} else {
+			report.Team = usage
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,161 @@
+package services_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mockapi/services"
+)
+
+// countingProvider counts the calls that reach the stub provider.
+type countingProvider struct {
+	*services.StubProvider
+	calls int
+}
+
+func (p *countingProvider) Generate(ctx context.Context, req services.AIRequest) (*services.AIResponse, error) {
+	p.calls++
+	return p.StubProvider.Generate(ctx, req)
+}
+
+func TestAIPromptService_CachesResponses(t *testing.T) {
+	store := services.NewMemoryStore()
+	provider := &countingProvider{StubProvider: services.NewStubProvider("stub-a")}
+	service := services.NewAIPromptServiceWithProvider(provider, 0)
+	service.Cache = services.NewAIResponseCache(store, time.Minute)
+	ctx := context.Background()
+
+	first, err := service.GetAIResponse(ctx, "list three  colours")
+	require.NoError(t, err)
+	again, err := service.GetAIResponse(ctx, "  list three\ncolours ")
+	require.NoError(t, err)
+	assert.Equal(t, 1, provider.calls, "prompts differing only in whitespace share a cache entry")
+	assert.Equal(t, first, again)
+
+	_, err = service.GetAIResponse(ctx, "list three shapes")
+	require.NoError(t, err)
+	assert.Equal(t, 2, provider.calls)
+
+	other := &countingProvider{StubProvider: services.NewStubProvider("stub-b")}
+	otherService := services.NewAIPromptServiceWithProvider(other, 0)
+	otherService.Cache = services.NewAIResponseCache(store, time.Minute)
+	_, err = otherService.GetAIResponse(ctx, "list three colours")
+	require.NoError(t, err)
+	assert.Equal(t, 1, other.calls, "each model has its own entries")
+
+	service.Cache.TTL = 0
+	_, err = service.GetAIResponse(ctx, "list three colours")
+	require.NoError(t, err)
+	assert.Equal(t, 3, provider.calls, "a TTL of zero disables the cache")
+}
+
+func TestAIUsageService_EnforcesBudgets(t *testing.T) {
+	now := time.Date(2026, 3, 31, 22, 0, 0, 0, time.UTC)
+	usage := services.NewAIUsageService(services.NewMemoryStore(), services.AIBudgetPeriodMonth,
+		services.AIBudget{Requests: 2}, services.AIBudget{Tokens: 20})
+	usage.Now = func() time.Time { return now }
+
+	provider := &countingProvider{StubProvider: services.NewStubProvider("stub")}
+	service := services.NewAIPromptServiceWithProvider(provider, 0)
+	service.Usage = usage
+
+	projectA := services.WithAIScope(context.Background(), services.AIScope{ProjectID: 1, TeamID: 10})
+	projectB := services.WithAIScope(context.Background(), services.AIScope{ProjectID: 2, TeamID: 10})
+
+	for i := 0; i < 2; i++ {
+		_, err := service.GetAIResponse(projectA, "say hello")
+		require.NoError(t, err)
+	}
+	_, err := service.GetAIResponse(projectA, "say hello")
+	assert.ErrorIs(t, err, services.ErrAIBudgetExceeded, "the project has used its 2 requests")
+	assert.Equal(t, 2, provider.calls)
+
+	report, err := usage.Usage(context.Background(), services.AIScope{ProjectID: 1, TeamID: 10})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), report.PeriodStart)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), report.ResetsAt)
+	assert.Equal(t, int64(2), report.Project.Requests)
+	assert.True(t, report.Project.Exceeded)
+	assert.Equal(t, int64(2), report.Team.Requests)
+	tokensPerCall := report.Team.Tokens / 2
+	require.Greater(t, tokensPerCall, int64(0), "tokens are taken from the response usage")
+
+	// The team's token budget is shared by its projects
+	for report.Team.Tokens < 20 {
+		_, err = service.GetAIResponse(projectB, "say hello")
+		require.NoError(t, err)
+		report, err = usage.Usage(context.Background(), services.AIScope{TeamID: 10})
+		require.NoError(t, err)
+	}
+	_, err = service.GetAIResponse(projectB, "say hello")
+	assert.ErrorIs(t, err, services.ErrAIBudgetExceeded, "the team has used its tokens")
+	_, err = service.StreamAIResponse(projectB, "say hello", func(string) error { return nil })
+	assert.ErrorIs(t, err, services.ErrAIBudgetExceeded, "streams are limited as well")
+
+	_, err = service.GetAIResponse(context.Background(), "say hello")
+	assert.NoError(t, err, "calls without a project are not charged")
+
+	now = now.Add(3 * time.Hour)
+	_, err = service.GetAIResponse(projectA, "say hello")
+	assert.NoError(t, err, "the budgets start over in the next period")
+	report, err = usage.Usage(context.Background(), services.AIScope{ProjectID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Project.Requests)
+	assert.Nil(t, report.Team)
+}
+
+func TestAIPromptService_ServesCachedResponsesOverBudget(t *testing.T) {
+	store := services.NewMemoryStore()
+	provider := &countingProvider{StubProvider: services.NewStubProvider("stub")}
+	service := services.NewAIPromptServiceWithProvider(provider, 0)
+	service.Cache = services.NewAIResponseCache(store, time.Minute)
+	service.Usage = services.NewAIUsageService(store, services.AIBudgetPeriodDay, services.AIBudget{Requests: 1}, services.AIBudget{})
+	ctx := services.WithAIScope(context.Background(), services.AIScope{ProjectID: 1})
+
+	_, err := service.GetAIResponse(ctx, "say hello")
+	require.NoError(t, err)
+	_, err = service.GetAIResponse(ctx, "say hello")
+	assert.NoError(t, err, "cached responses cost nothing")
+	_, err = service.GetAIResponse(ctx, "say goodbye")
+	assert.ErrorIs(t, err, services.ErrAIBudgetExceeded)
+	assert.Equal(t, 1, provider.calls)
+}
+
+func TestAIUsageService_ReservesConcurrentRequests(t *testing.T) {
+	usage := services.NewAIUsageService(services.NewMemoryStore(), services.AIBudgetPeriodDay,
+		services.AIBudget{Requests: 5}, services.AIBudget{Requests: 3})
+	ctx := services.WithAIScope(context.Background(), services.AIScope{ProjectID: 1, TeamID: 10})
+
+	var wg sync.WaitGroup
+	var reserved atomic.Int64
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := usage.Reserve(ctx); err == nil {
+				reserved.Add(1)
+			} else {
+				assert.ErrorIs(t, err, services.ErrAIBudgetExceeded)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(3), reserved.Load(), "concurrent calls cannot take more than the team's requests")
+
+	report, err := usage.Usage(context.Background(), services.AIScope{ProjectID: 1, TeamID: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), report.Project.Requests, "requests rejected by the team are given back to the project")
+	assert.Equal(t, int64(3), report.Team.Requests)
+
+	usage.Release(ctx)
+	report, err = usage.Usage(context.Background(), services.AIScope{ProjectID: 1, TeamID: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), report.Project.Requests, "failed calls give their request back")
+	assert.Equal(t, int64(2), report.Team.Requests)
+}
@@ -0,0 +1,13 @@
+package services
+
+import "errors"
+
+// ErrForbidden means the caller may not make the change.
+var ErrForbidden = errors.New("forbidden")
+
+// Caller is the authenticated user a management request is made by, as read from its token.
+type Caller struct {
+	UserID   string
+	TeamSlug string // Team the user belongs to, empty for none
+	Admin    bool   // Administrators may manage every project and use the administration endpoints
+}
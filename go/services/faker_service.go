@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,8 @@ import (
 	"time"
 
 	"mockapi/config" // Assuming config contains the Node.js service URL
+	"mockapi/metrics"
+	"mockapi/tracing"
 )
 
 // FakerService handles communication with the Node.js Faker DSL processing service.
@@ -23,7 +26,8 @@ func NewFakerService(cfg config.Config) *FakerService {
 	return &FakerService{
 		NodeJSBaseURL: cfg.NodeJSFakerServiceURL, // Expect this to be in the config
 		HTTPClient: &http.Client{
-			Timeout: 10 * time.Second, // Sensible default timeout
+			Timeout:   10 * time.Second, // Sensible default timeout
+			Transport: tracing.HTTPTransport(nil),
 		},
 	}
 }
@@ -37,14 +41,23 @@ type ProcessDSLRequest struct {
 // The returned value from Node.js can be of any type (string, number, array, object).
 // We will receive it as json.RawMessage to handle this flexibility and then marshal it back to a string
 // to be stored in the `Data` field of `MockContent`.
-func (fs *FakerService) ProcessDSL(dslString string) (string, error) {
+func (fs *FakerService) ProcessDSL(ctx context.Context, dslString string) (string, error) {
+	ctx, span := tracing.Start(ctx, "faker.process_dsl")
+	start := time.Now()
+	data, err := fs.processDSL(ctx, dslString)
+	metrics.ObserveFakerDSL(time.Since(start), err)
+	tracing.End(span, err)
+	return data, err
+}
+
+func (fs *FakerService) processDSL(ctx context.Context, dslString string) (string, error) {
 	requestPayload := ProcessDSLRequest{DSL: dslString}
 	payloadBytes, err := json.Marshal(requestPayload)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal DSL request payload: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", fs.NodeJSBaseURL+"/process-dsl", bytes.NewBuffer(payloadBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", fs.NodeJSBaseURL+"/process-dsl", bytes.NewBuffer(payloadBytes))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request to Node.js service: %w", err)
 	}
@@ -75,3 +88,22 @@ func (fs *FakerService) ProcessDSL(dslString string) (string, error) {
     // This is already in the correct string format to be stored in the Data field.
 	return string(responseBody), nil
 }
+
+// Ping reports whether the Node.js service answers HTTP requests. It has no health endpoint, so any
+// response below 500 to a GET of its base URL counts as reachable.
+func (fs *FakerService) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fs.NodeJSBaseURL+"/", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request to Node.js service: %w", err)
+	}
+	resp, err := fs.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Node.js service: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("Node.js service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
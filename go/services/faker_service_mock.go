@@ -1,5 +1,7 @@
 package services
 
+import "context"
+
 // MockFakerService is a manual mock for FakerService.
 type MockFakerService struct {
 	ProcessDSLFunc func(dslString string) (string, error)
@@ -8,7 +10,7 @@ type MockFakerService struct {
 // ProcessDSL delegates to ProcessDSLFunc if it's set.
 // Otherwise, it might panic or return a default value, depending on test needs.
 // For robustness in tests, it's often better if the test explicitly sets ProcessDSLFunc.
-func (m *MockFakerService) ProcessDSL(dslString string) (string, error) {
+func (m *MockFakerService) ProcessDSL(ctx context.Context, dslString string) (string, error) {
 	if m.ProcessDSLFunc != nil {
 		return m.ProcessDSLFunc(dslString)
 	}
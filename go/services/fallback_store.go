@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mockapi/models"
+)
+
+// defaultRedisProbeInterval is how often a degraded FallbackStore checks whether Redis is back.
+const defaultRedisProbeInterval = 5 * time.Second
+
+// FallbackStore uses Redis while it is reachable and degrades to an in-memory store when a call fails
+// with a connection error. While degraded it probes Redis in the background and switches back once
+// it answers. State written during an outage stays in memory and is not copied to Redis, so scenarios,
+// sequences, resources and rate limit counters restart from Redis' state after recovery.
+type FallbackStore struct {
+	Primary   *RedisService
+	Secondary *MemoryStore
+
+	degraded      atomic.Bool
+	probeInterval time.Duration
+	stop          chan struct{}
+	stopOnce      sync.Once
+}
+
+// NewFallbackStore creates a FallbackStore and starts probing Redis while degraded.
+// probeInterval defaults to five seconds when it is not positive. Call Close to stop probing.
+func NewFallbackStore(primary *RedisService, secondary *MemoryStore, probeInterval time.Duration) *FallbackStore {
+	if probeInterval <= 0 {
+		probeInterval = defaultRedisProbeInterval
+	}
+	s := &FallbackStore{
+		Primary:       primary,
+		Secondary:     secondary,
+		probeInterval: probeInterval,
+		stop:          make(chan struct{}),
+	}
+	go s.probe()
+	return s
+}
+
+// Close stops probing Redis and closes the in-memory store. The Redis client is left open.
+func (s *FallbackStore) Close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		s.Secondary.Close()
+	})
+}
+
+// Degraded reports whether calls are currently served from memory.
+func (s *FallbackStore) Degraded() bool {
+	return s.degraded.Load()
+}
+
+func (s *FallbackStore) degrade(err error) {
+	if s.degraded.CompareAndSwap(false, true) {
+		slog.Warn("Redis is unavailable, falling back to the in-memory store", "error", err)
+	}
+}
+
+func (s *FallbackStore) probe() {
+	ticker := time.NewTicker(s.probeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if s.degraded.Load() && s.Primary.Ping(context.Background()) == nil {
+				s.degraded.Store(false)
+				slog.Info("Redis is reachable again, leaving the in-memory store")
+			}
+		}
+	}
+}
+
+// withFallback runs primary unless the store is degraded, and runs secondary instead when
+// primary fails because Redis cannot be reached. A failure caused by the caller's context ending is
+// returned as it is, so a cancelled request does not switch the store to memory.
+func withFallback[T any](ctx context.Context, s *FallbackStore, primary, secondary func() (T, error)) (T, error) {
+	if !s.degraded.Load() {
+		value, err := primary()
+		if !isConnectionError(err) || ctx.Err() != nil {
+			return value, err
+		}
+		s.degrade(err)
+	}
+	return secondary()
+}
+
+// withFallbackErr is withFallback for calls that only return an error.
+func withFallbackErr(ctx context.Context, s *FallbackStore, primary, secondary func() error) error {
+	_, err := withFallback(ctx, s, func() (struct{}, error) { return struct{}{}, primary() },
+		func() (struct{}, error) { return struct{}{}, secondary() })
+	return err
+}
+
+// CreateRedisKey generates a key by joining parts with a colon.
+func (s *FallbackStore) CreateRedisKey(parts ...string) string {
+	return s.Primary.CreateRedisKey(parts...)
+}
+
+// Ping reports whether Redis is reachable. A degraded store still serves requests, so callers
+// that only need a working store should check Degraded instead.
+func (s *FallbackStore) Ping(ctx context.Context) error {
+	return s.Primary.Ping(ctx)
+}
+
+func (s *FallbackStore) GetValue(ctx context.Context, key string) (string, error) {
+	return withFallback(ctx, s, func() (string, error) { return s.Primary.GetValue(ctx, key) },
+		func() (string, error) { return s.Secondary.GetValue(ctx, key) })
+}
+
+func (s *FallbackStore) SetValue(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return withFallbackErr(ctx, s, func() error { return s.Primary.SetValue(ctx, key, value, expiration) },
+		func() error { return s.Secondary.SetValue(ctx, key, value, expiration) })
+}
+
+func (s *FallbackStore) DeleteValue(ctx context.Context, key string) error {
+	return withFallbackErr(ctx, s, func() error { return s.Primary.DeleteValue(ctx, key) },
+		func() error { return s.Secondary.DeleteValue(ctx, key) })
+}
+
+func (s *FallbackStore) DeleteKeysByPattern(ctx context.Context, pattern string) (int64, error) {
+	return withFallback(ctx, s, func() (int64, error) { return s.Primary.DeleteKeysByPattern(ctx, pattern) },
+		func() (int64, error) { return s.Secondary.DeleteKeysByPattern(ctx, pattern) })
+}
+
+func (s *FallbackStore) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	return withFallback(ctx, s, func() (int64, error) { return s.Primary.Increment(ctx, key, expiration) },
+		func() (int64, error) { return s.Secondary.Increment(ctx, key, expiration) })
+}
+
+func (s *FallbackStore) UpdateValue(ctx context.Context, key string, expiration time.Duration, update func(current string) (string, error)) error {
+	return withFallbackErr(ctx, s, func() error { return s.Primary.UpdateValue(ctx, key, expiration, update) },
+		func() error { return s.Secondary.UpdateValue(ctx, key, expiration, update) })
+}
+
+func (s *FallbackStore) RateLimit(ctx context.Context, key string, limit int, windowSeconds int64) (bool, error) {
+	return withFallback(ctx, s, func() (bool, error) { return s.Primary.RateLimit(ctx, key, limit, windowSeconds) },
+		func() (bool, error) { return s.Secondary.RateLimit(ctx, key, limit, windowSeconds) })
+}
+
+func (s *FallbackStore) CheckRateLimit(ctx context.Context, algorithm models.RateLimitAlgorithm, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	return withFallback(ctx, s, func() (*RateLimitResult, error) { return s.Primary.CheckRateLimit(ctx, algorithm, key, limit, window) },
+		func() (*RateLimitResult, error) {
+			return s.Secondary.CheckRateLimit(ctx, algorithm, key, limit, window)
+		})
+}
+
+func (s *FallbackStore) Publish(ctx context.Context, channel, message string) error {
+	return withFallbackErr(ctx, s, func() error { return s.Primary.Publish(ctx, channel, message) },
+		func() error { return s.Secondary.Publish(ctx, channel, message) })
+}
+
+// Subscribe listens on the channel in memory and, when reachable, in Redis, so subscribers keep
+// receiving messages whichever store the publisher is using.
+func (s *FallbackStore) Subscribe(ctx context.Context, channel string) (<-chan string, func(), error) {
+	memoryMessages, memoryUnsubscribe, err := s.Secondary.Subscribe(ctx, channel)
+	if err != nil {
+		return nil, nil, err
+	}
+	redisMessages, redisUnsubscribe, err := s.Primary.Subscribe(ctx, channel)
+	if err != nil {
+		if !isConnectionError(err) {
+			memoryUnsubscribe()
+			return nil, nil, err
+		}
+		s.degrade(err)
+		return memoryMessages, memoryUnsubscribe, nil
+	}
+
+	messages := make(chan string, subscriptionBuffer)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	forward := func(source <-chan string) {
+		defer wg.Done()
+		for msg := range source {
+			select {
+			case messages <- msg:
+			case <-done:
+				return
+			}
+		}
+	}
+	wg.Add(2)
+	go forward(memoryMessages)
+	go forward(redisMessages)
+	go func() {
+		wg.Wait()
+		close(messages)
+	}()
+
+	var once sync.Once
+	return messages, func() {
+		once.Do(func() {
+			close(done)
+			memoryUnsubscribe()
+			redisUnsubscribe()
+		})
+	}, nil
+}
@@ -0,0 +1,66 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mockapi/services"
+)
+
+func newTestFallbackStore(t *testing.T) (*services.FallbackStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() { _ = client.Close() })
+	store := services.NewFallbackStore(services.NewRedisService(client), services.NewMemoryStore(), 10*time.Millisecond)
+	t.Cleanup(store.Close)
+	return store, mr
+}
+
+func TestFallbackStore_FallsBackAndRecovers(t *testing.T) {
+	store, mr := newTestFallbackStore(t)
+
+	require.NoError(t, store.SetValue(context.Background(), "greeting", "from redis", 0))
+	assert.False(t, store.Degraded())
+	mr.CheckGet(t, "greeting", "from redis")
+
+	mr.Close()
+	require.NoError(t, store.SetValue(context.Background(), "greeting", "from memory", 0), "writes succeed while Redis is down")
+	assert.True(t, store.Degraded())
+	value, err := store.GetValue(context.Background(), "greeting")
+	require.NoError(t, err)
+	assert.Equal(t, "from memory", value)
+	count, err := store.Increment(context.Background(), "counter", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Error(t, store.Ping(context.Background()), "Ping reports Redis itself")
+
+	require.NoError(t, mr.Restart())
+	require.Eventually(t, func() bool { return !store.Degraded() }, 2*time.Second, 10*time.Millisecond,
+		"the store switches back once Redis answers")
+
+	value, err = store.GetValue(context.Background(), "greeting")
+	require.NoError(t, err)
+	assert.Equal(t, "from redis", value, "state written during the outage is not copied to Redis")
+	require.NoError(t, store.SetValue(context.Background(), "greeting", "back in redis", 0))
+	mr.CheckGet(t, "greeting", "back in redis")
+}
+
+func TestFallbackStore_KeepsNonConnectionErrors(t *testing.T) {
+	store, mr := newTestFallbackStore(t)
+
+	require.NoError(t, mr.Set("name", "not a number"))
+	_, err := store.Increment(context.Background(), "name", 0)
+	assert.Error(t, err, "command errors are returned as they are")
+	errStop := errors.New("stop")
+	err = store.UpdateValue(context.Background(), "name", 0, func(string) (string, error) { return "", errStop })
+	assert.ErrorIs(t, err, errStop)
+	assert.False(t, store.Degraded(), "only connection errors switch to memory")
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Dependency statuses reported by readiness checks.
+const (
+	HealthStatusUp   = "UP"
+	HealthStatusDown = "DOWN"
+)
+
+// HealthCheck is one dependency probed by readiness. Required dependencies make the instance
+// unready when they are down; optional ones are only reported.
+type HealthCheck struct {
+	Name     string
+	Required bool
+	Check    func(ctx context.Context) error
+}
+
+// DependencyHealth is the outcome of one HealthCheck.
+type DependencyHealth struct {
+	Status    string  `json:"status"`
+	Required  bool    `json:"required"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// ReadinessReport is the outcome of all checks. Status is DOWN when a required dependency is down.
+type ReadinessReport struct {
+	Status       string                      `json:"status"`
+	Timestamp    time.Time                   `json:"timestamp"`
+	Dependencies map[string]DependencyHealth `json:"dependencies"`
+}
+
+// Ready reports whether every required dependency is up.
+func (r *ReadinessReport) Ready() bool {
+	return r.Status == HealthStatusUp
+}
+
+// HealthService probes the dependencies of the server for the readiness endpoint.
+type HealthService struct {
+	Checks  []HealthCheck
+	Timeout time.Duration // Per check; a check that takes longer counts as down
+}
+
+// defaultHealthCheckTimeout applies when no timeout is configured.
+const defaultHealthCheckTimeout = 2 * time.Second
+
+// NewHealthService creates a HealthService that gives each check up to timeout.
+func NewHealthService(timeout time.Duration, checks ...HealthCheck) *HealthService {
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+	return &HealthService{Checks: checks, Timeout: timeout}
+}
+
+// Readiness runs all checks concurrently, each with its own timeout.
+func (s *HealthService) Readiness(ctx context.Context) *ReadinessReport {
+	report := &ReadinessReport{
+		Status:       HealthStatusUp,
+		Timestamp:    time.Now(),
+		Dependencies: make(map[string]DependencyHealth, len(s.Checks)),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range s.Checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			result := s.run(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Dependencies[check.Name] = result
+			if check.Required && result.Status != HealthStatusUp {
+				report.Status = HealthStatusDown
+			}
+		}(check)
+	}
+	wg.Wait()
+	return report
+}
+
+func (s *HealthService) run(ctx context.Context, check HealthCheck) DependencyHealth {
+	checkCtx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	// Checks that ignore their context must not hold up the report past the timeout
+	done := make(chan error, 1)
+	go func() { done <- check.Check(checkCtx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-checkCtx.Done():
+		err = checkCtx.Err()
+	}
+
+	result := DependencyHealth{
+		Status:    HealthStatusUp,
+		Required:  check.Required,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = HealthStatusDown
+		result.Error = err.Error()
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Error = "timed out after " + s.Timeout.String()
+		}
+	}
+	return result
+}
+
+// DatabaseCheck pings the database. The server cannot serve mocks without it, so it is always required.
+func DatabaseCheck(db *gorm.DB) HealthCheck {
+	return HealthCheck{Name: "database", Required: true, Check: func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}}
+}
+
+// RedisCheck pings the Redis server behind store, or returns false when the store does not use Redis.
+// Redis is optional when the store falls back to memory while it is unreachable.
+func RedisCheck(store KeyValueStore) (HealthCheck, bool) {
+	client := RedisClientOf(store)
+	if client == nil {
+		return HealthCheck{}, false
+	}
+	_, fallback := store.(*FallbackStore)
+	return HealthCheck{Name: "redis", Required: !fallback, Check: func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}}, true
+}
+
+// FakerCheck checks that the Node.js Faker DSL service is reachable.
+func FakerCheck(fakerService *FakerService, required bool) HealthCheck {
+	return HealthCheck{Name: "faker", Required: required, Check: fakerService.Ping}
+}
@@ -0,0 +1,62 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mockapi/database"
+	"mockapi/services"
+)
+
+func TestReadinessReportsEachDependency(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("connection refused") }
+
+	healthService := services.NewHealthService(time.Second,
+		services.HealthCheck{Name: "database", Required: true, Check: up},
+		services.HealthCheck{Name: "faker", Required: false, Check: down},
+	)
+	report := healthService.Readiness(context.Background())
+	assert.True(t, report.Ready(), "an optional dependency being down keeps the instance ready")
+	assert.Equal(t, services.HealthStatusUp, report.Dependencies["database"].Status)
+	assert.Equal(t, services.HealthStatusDown, report.Dependencies["faker"].Status)
+	assert.Equal(t, "connection refused", report.Dependencies["faker"].Error)
+
+	healthService.Checks[1].Required = true
+	report = healthService.Readiness(context.Background())
+	assert.False(t, report.Ready())
+	assert.Equal(t, services.HealthStatusDown, report.Status)
+}
+
+func TestReadinessTimesOutHangingChecks(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+	healthService := services.NewHealthService(50*time.Millisecond, services.HealthCheck{
+		Name:     "redis",
+		Required: true,
+		Check:    func(ctx context.Context) error { <-hang; return nil }, // Ignores its context
+	})
+
+	start := time.Now()
+	report := healthService.Readiness(context.Background())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.False(t, report.Ready())
+	assert.Contains(t, report.Dependencies["redis"].Error, "timed out")
+}
+
+func TestDatabaseCheck(t *testing.T) {
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	check := services.DatabaseCheck(db)
+	assert.NoError(t, check.Check(context.Background()))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+	assert.Error(t, check.Check(context.Background()))
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"mockapi/dtos"
+	"mockapi/models"
+)
+
+// The interfaces below describe what the controllers need from each service. Controllers depend on
+// them rather than on the GORM-backed structs, so tests can inject the hand-written mocks in this
+// package and alternative stores can be plugged in without touching the HTTP layer.
+
+// ProjectServiceInterface defines the methods of ProjectService used by controllers.
+type ProjectServiceInterface interface {
+	GetProjectByID(id uint) (*models.Project, error)
+	GetProjectBySlug(slug string) (*models.Project, error)
+	GetProjectByTeamSlugAndProjectSlug(ctx context.Context, teamSlug, projectSlug string) (*models.Project, error)
+	CreateProject(project *models.Project) error
+	CloneProject(sourceID uint, slug, name string) (*models.Project, error)
+	IsReadOnly(project *models.Project) bool
+	UpdateRateLimit(project *models.Project, dto dtos.RateLimitDTO) error
+	UpdateLogRetention(project *models.Project, dto dtos.LogRetentionDTO) error
+	UpdateForwardProxyActiveStatus(projectID uint, status bool) error
+	FreeProjectExpiry() *time.Time
+	AuthorizeProject(caller Caller, project *models.Project) error
+	ExtendProject(project *models.Project, caller Caller) error
+	ClaimProject(project *models.Project, teamSlug string, caller Caller) error
+}
+
+// URLServiceInterface defines the methods of URLService used by controllers.
+type URLServiceInterface interface {
+	GetURLByID(id uint) (*models.Url, error)
+	GetURLByTeamSlugProjectSlugAndPath(teamSlug, projectSlug, path string) (*models.Url, error)
+	ResolveURL(ctx context.Context, teamSlug, projectSlug, path string) (*models.Url, error)
+	FindByProjectIDAndURL(projectID uint, urlPath string) (*models.Url, error)
+	CreateURL(url *models.Url, projectID uint) error
+	CreateURLWithMockContents(url *models.Url, projectID uint, mockContents []models.MockContent) error
+	UpdateURL(urlID uint, dto dtos.URLDataDTO) (*models.Url, error)
+	DeleteURL(urlID uint) error
+	IncrementRequestStats(ctx context.Context, urlID uint) error
+}
+
+// MockContentServiceInterface defines the methods of MockContentService used by controllers.
+type MockContentServiceInterface interface {
+	SaveMockContentList(mockContents []models.MockContent, urlID uint) ([]models.MockContent, error)
+	UpdateMockContentList(mockContents []models.MockContent, urlID uint) ([]models.MockContent, error)
+	SelectRandomMockContent(mockContents []models.MockContent) *models.MockContent
+	SimulateLatency(latencyMillis int64)
+}
+
+// RequestLogServiceInterface defines the methods of RequestLogService used by controllers.
+type RequestLogServiceInterface interface {
+	SaveRequestLog(ctx context.Context, requestLog *models.RequestLog) error
+	GetLogsByProjectID(projectID uint, limit, offset int) ([]models.RequestLog, error)
+}
+
+// ProxyServiceInterface defines the methods of ProxyService used by controllers.
+type ProxyServiceInterface interface {
+	GetForwardProxyByProjectID(ctx context.Context, projectID uint) (*models.ForwardProxy, error)
+	CreateForwardProxy(proxy *models.ForwardProxy, projectID uint) (*models.ForwardProxy, error)
+}
+
+// FakerServiceInterface defines the methods of FakerService used by controllers.
+type FakerServiceInterface interface {
+	ProcessDSL(ctx context.Context, dslString string) (string, error)
+}
+
+// TeamServiceInterface defines the methods of TeamService used by controllers.
+type TeamServiceInterface interface {
+	GetDefaultTeam() (*models.Team, error)
+	GetTeamBySlug(slug string) (*models.Team, error)
+}
+
+// RandomWordsServiceInterface defines the methods of RandomWordsService used by controllers.
+type RandomWordsServiceInterface interface {
+	GetRandomSlug() string
+	IsSlugDisallowed(slug string) bool
+}
+
+// ScenarioServiceInterface defines the methods of ScenarioService used by controllers.
+type ScenarioServiceInterface interface {
+	SelectCandidates(ctx context.Context, projectID uint, sessionKey string, contents []models.MockContent) ([]models.MockContent, error)
+	ApplyTransition(ctx context.Context, projectID uint, sessionKey string, served *models.MockContent) error
+	ListScenarios(ctx context.Context, projectID uint, sessionKey string) ([]ScenarioState, error)
+	ResetScenarios(ctx context.Context, projectID uint, sessionKey, scenario string) (int64, error)
+}
+
+// SequenceServiceInterface defines the methods of SequenceService used by controllers.
+type SequenceServiceInterface interface {
+	NextMockContent(ctx context.Context, url *models.Url, clientKey string, candidates []models.MockContent) (*models.MockContent, error)
+	ResetSequence(ctx context.Context, urlID uint, clientKey string) (int64, error)
+}
+
+// ResourceServiceInterface defines the methods of ResourceService used by controllers.
+type ResourceServiceInterface interface {
+	CreateResource(resource *models.Resource, projectID uint) error
+	UpdateResource(resource *models.Resource) error
+	GetResourceByID(projectID, resourceID uint) (*models.Resource, error)
+	GetResourcesByProjectID(ctx context.Context, projectID uint) ([]models.Resource, error)
+	DeleteResource(ctx context.Context, resource *models.Resource) error
+	MatchResource(ctx context.Context, projectID uint, path string) (*models.Resource, string, error)
+	ListItems(ctx context.Context, resource *models.Resource, query ResourceQuery) ([]ResourceItem, int, error)
+	GetItem(ctx context.Context, resource *models.Resource, itemID string) (ResourceItem, error)
+	CreateItem(ctx context.Context, resource *models.Resource, item ResourceItem) (ResourceItem, error)
+	ReplaceItem(ctx context.Context, resource *models.Resource, itemID string, item ResourceItem) (ResourceItem, error)
+	PatchItem(ctx context.Context, resource *models.Resource, itemID string, patch ResourceItem) (ResourceItem, error)
+	DeleteItem(ctx context.Context, resource *models.Resource, itemID string) error
+	ResetResources(ctx context.Context, projectID, resourceID uint) (int64, error)
+}
+
+// RateLimitServiceInterface defines the methods of RateLimitService used by controllers.
+type RateLimitServiceInterface interface {
+	Check(ctx context.Context, scope string, scopeID uint, policy models.RateLimitPolicy, clientKey string) (*RateLimitResult, error)
+}
+
+// LogRetentionServiceInterface defines the methods of LogRetentionService used by controllers.
+type LogRetentionServiceInterface interface {
+	Purge() (*PurgeSummary, error)
+	PurgeProject(projectID uint) (*PurgeSummary, error)
+}
+
+// MockDefinitionServiceInterface defines the methods of MockDefinitionService used by controllers.
+type MockDefinitionServiceInterface interface {
+	Configured() bool
+	Reconcile() (*ReconcileSummary, error)
+}
+
+// HealthServiceInterface defines the methods of HealthService used by controllers.
+type HealthServiceInterface interface {
+	Readiness(ctx context.Context) *ReadinessReport
+}
+
+var (
+	_ ProjectServiceInterface        = (*ProjectService)(nil)
+	_ URLServiceInterface            = (*URLService)(nil)
+	_ MockContentServiceInterface    = (*MockContentService)(nil)
+	_ RequestLogServiceInterface     = (*RequestLogService)(nil)
+	_ ProxyServiceInterface          = (*ProxyService)(nil)
+	_ FakerServiceInterface          = (*FakerService)(nil)
+	_ TeamServiceInterface           = (*TeamService)(nil)
+	_ RandomWordsServiceInterface    = (*RandomWordsService)(nil)
+	_ ScenarioServiceInterface       = (*ScenarioService)(nil)
+	_ SequenceServiceInterface       = (*SequenceService)(nil)
+	_ ResourceServiceInterface       = (*ResourceService)(nil)
+	_ RateLimitServiceInterface      = (*RateLimitService)(nil)
+	_ LogRetentionServiceInterface   = (*LogRetentionService)(nil)
+	_ MockDefinitionServiceInterface = (*MockDefinitionService)(nil)
+	_ HealthServiceInterface         = (*HealthService)(nil)
+	_ AIUsageServiceInterface        = (*AIUsageService)(nil)
+
+	_ ProjectServiceInterface     = (*MockProjectService)(nil)
+	_ URLServiceInterface         = (*MockURLService)(nil)
+	_ MockContentServiceInterface = (*MockMockContentService)(nil)
+	_ RequestLogServiceInterface  = (*MockRequestLogService)(nil)
+	_ ProxyServiceInterface       = (*MockProxyService)(nil)
+	_ FakerServiceInterface       = (*MockFakerService)(nil)
+)
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// jsonSchemaURL names the schema being compiled. Schemas must be self-contained: references to
+// other documents are not loaded.
+const jsonSchemaURL = "mockapi:///schema.json"
+
+// ErrInvalidJSONSchema means a supplied JSON Schema cannot be used.
+var ErrInvalidJSONSchema = errors.New("invalid JSON Schema")
+
+// SchemaViolation is one place where a JSON document does not conform to a schema.
+type SchemaViolation struct {
+	Path    string `json:"path"` // JSON Pointer into the document, "" for the document itself
+	Message string `json:"message"`
+}
+
+// CompileJSONSchema parses and compiles a JSON Schema. Schemas without $schema are read as draft 2020-12.
+func CompileJSONSchema(schema string) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("%w: the schema is not valid JSON: %v", ErrInvalidJSONSchema, err)
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	compiler.UseLoader(jsonschema.SchemeURLLoader{}) // No files or URLs
+	if err := compiler.AddResource(jsonSchemaURL, doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJSONSchema, err)
+	}
+	compiled, err := compiler.Compile(jsonSchemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJSONSchema, err)
+	}
+	return compiled, nil
+}
+
+// ValidateJSONDocument checks that data is a JSON document conforming to schema and returns every
+// violation, or nil when it conforms. Data that is not JSON is a single violation of the document.
+func ValidateJSONDocument(schema *jsonschema.Schema, data string) []SchemaViolation {
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(data))
+	if err != nil {
+		return []SchemaViolation{{Message: "not valid JSON: " + err.Error()}}
+	}
+	err = schema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return []SchemaViolation{{Message: err.Error()}}
+	}
+	violations := schemaViolations(*validationErr.DetailedOutput(), nil)
+	if len(violations) == 0 {
+		violations = []SchemaViolation{{Message: validationErr.Error()}}
+	}
+	return violations
+}
+
+// schemaViolations collects the innermost errors of the output, which say what is wrong rather
+// than which keyword (e.g. $ref or allOf) failed.
+func schemaViolations(unit jsonschema.OutputUnit, violations []SchemaViolation) []SchemaViolation {
+	if len(unit.Errors) == 0 {
+		if unit.Error != nil {
+			violations = append(violations, SchemaViolation{Path: unit.InstanceLocation, Message: unit.Error.String()})
+		}
+		return violations
+	}
+	for _, cause := range unit.Errors {
+		violations = schemaViolations(cause, violations)
+	}
+	return violations
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"mockapi/config"
+	"mockapi/models"
+	"mockapi/tracing"
+)
+
+// Store backends selectable with STORE_BACKEND.
+const (
+	StoreBackendRedis  = "redis"
+	StoreBackendMemory = "memory"
+)
+
+// subscriptionBuffer is how many undelivered messages a subscription holds before new ones are dropped.
+const subscriptionBuffer = 64
+
+// KeyValueStore is the key-value, counter, rate limit and pub/sub storage shared by the services.
+// RedisService implements it for production; MemoryStore keeps everything in-process for local
+// development and CI, and FallbackStore switches between the two when Redis becomes unavailable.
+// Calls take the caller's context, so they are cancelled with the request and traced under it.
+type KeyValueStore interface {
+	CreateRedisKey(parts ...string) string
+	GetValue(ctx context.Context, key string) (string, error)
+	SetValue(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	DeleteValue(ctx context.Context, key string) error
+	DeleteKeysByPattern(ctx context.Context, pattern string) (int64, error)
+	Increment(ctx context.Context, key string, expiration time.Duration) (int64, error)
+	UpdateValue(ctx context.Context, key string, expiration time.Duration, update func(current string) (string, error)) error
+
+	RateLimit(ctx context.Context, key string, limit int, windowSeconds int64) (bool, error)
+	CheckRateLimit(ctx context.Context, algorithm models.RateLimitAlgorithm, key string, limit int, window time.Duration) (*RateLimitResult, error)
+
+	Publish(ctx context.Context, channel, message string) error
+	Subscribe(ctx context.Context, channel string) (<-chan string, func(), error)
+
+	Ping(ctx context.Context) error
+}
+
+// EscapeKeyPattern escapes the glob metacharacters of a key part, so it only matches itself when used in
+// a DeleteKeysByPattern pattern.
+func EscapeKeyPattern(part string) string {
+	var b strings.Builder
+	for _, ch := range part {
+		switch ch {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
+
+var (
+	_ KeyValueStore = (*RedisService)(nil)
+	_ KeyValueStore = (*MemoryStore)(nil)
+	_ KeyValueStore = (*FallbackStore)(nil)
+)
+
+// NewKeyValueStore builds the store selected by the configuration.
+// With REDIS_FALLBACK enabled an unreachable Redis does not prevent startup: the store starts
+// in memory and switches to Redis once it answers. The returned close function releases the store.
+func NewKeyValueStore(cfg config.Config) (KeyValueStore, func(), error) {
+	switch cfg.StoreBackend {
+	case StoreBackendMemory:
+		slog.Info("Using the in-memory store; state is not shared between instances")
+		memory := NewMemoryStore()
+		return memory, memory.Close, nil
+	case "", StoreBackendRedis:
+	default:
+		return nil, nil, fmt.Errorf("unknown STORE_BACKEND '%s'", cfg.StoreBackend)
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+	if tracing.Enabled(cfg) {
+		client.AddHook(tracing.RedisHook{})
+	}
+	redisService := NewRedisService(client)
+	pingErr := redisService.Ping(context.Background())
+
+	if !cfg.RedisFallback {
+		if pingErr != nil {
+			_ = client.Close()
+			return nil, nil, fmt.Errorf("could not connect to Redis: %w", pingErr)
+		}
+		slog.Info("Connected to Redis", "addr", cfg.RedisAddr)
+		return redisService, func() { _ = client.Close() }, nil
+	}
+
+	fallback := NewFallbackStore(redisService, NewMemoryStore(), 0)
+	if pingErr != nil {
+		fallback.degrade(pingErr)
+	} else {
+		slog.Info("Connected to Redis; falling back to memory if it becomes unavailable", "addr", cfg.RedisAddr)
+	}
+	return fallback, func() {
+		fallback.Close()
+		_ = client.Close()
+	}, nil
+}
+
+// RedisClientOf returns the Redis client behind a store, or nil when the store does not use Redis.
+func RedisClientOf(store KeyValueStore) *redis.Client {
+	switch s := store.(type) {
+	case *RedisService:
+		return s.Client
+	case *FallbackStore:
+		return s.Primary.Client
+	}
+	return nil
+}
+
+// isConnectionError reports whether err means Redis could not be reached, as opposed to a
+// failed command or an error returned by the caller's own logic.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, redis.ErrClosed) {
+		return true
+	}
+	return strings.Contains(err.Error(), "connection pool timeout")
+}
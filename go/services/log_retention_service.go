@@ -0,0 +1,179 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"mockapi/models"
+)
+
+// PurgeSummary reports what a request log purge removed.
+type PurgeSummary struct {
+	Projects      int   `json:"projects"`
+	DeletedByAge  int64 `json:"deleted_by_age"`
+	DeletedByRows int64 `json:"deleted_by_rows"`
+	DurationMs    int64 `json:"duration_ms"`
+}
+
+// Deleted returns the total number of removed logs.
+func (s PurgeSummary) Deleted() int64 {
+	return s.DeletedByAge + s.DeletedByRows
+}
+
+// LogRetentionService removes request logs that are older than the retention period or exceed the
+// number of rows kept per project. Projects can override both limits; logs of unknown or deleted
+// projects follow the global settings. Deletes run in batches of BatchSize rows so a large purge
+// does not hold long locks on the table.
+type LogRetentionService struct {
+	DB        *gorm.DB
+	MaxAge    time.Duration // Global maximum age; 0 keeps logs forever
+	MaxRows   int           // Global maximum number of logs per project; 0 means no limit
+	BatchSize int
+
+	// Now returns the current time; tests replace it.
+	Now func() time.Time
+
+	mu sync.Mutex // Serialises purges of the janitor and the admin endpoint
+}
+
+// NewLogRetentionService creates a LogRetentionService with the global limits.
+func NewLogRetentionService(db *gorm.DB, maxAge time.Duration, maxRows, batchSize int) *LogRetentionService {
+	if batchSize < 1 {
+		batchSize = 1000
+	}
+	return &LogRetentionService{DB: db, MaxAge: maxAge, MaxRows: maxRows, BatchSize: batchSize, Now: time.Now}
+}
+
+// EffectivePolicy returns the maximum age and row count that apply to a project's logs.
+func (s *LogRetentionService) EffectivePolicy(policy models.LogRetentionPolicy) (time.Duration, int) {
+	maxAge, maxRows := s.MaxAge, s.MaxRows
+	if policy.MaxAgeHours != nil {
+		maxAge = time.Duration(*policy.MaxAgeHours) * time.Hour
+	}
+	if policy.MaxRows != nil {
+		maxRows = *policy.MaxRows
+	}
+	return maxAge, maxRows
+}
+
+// Purge applies the retention to the logs of every project.
+func (s *LogRetentionService) Purge() (*PurgeSummary, error) {
+	var projectIDs []uint
+	if err := s.DB.Model(&models.RequestLog{}).Distinct("project_id").Pluck("project_id", &projectIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list projects with request logs: %w", err)
+	}
+	return s.purge(projectIDs)
+}
+
+// PurgeProject applies the retention to the logs of a single project.
+func (s *LogRetentionService) PurgeProject(projectID uint) (*PurgeSummary, error) {
+	return s.purge([]uint{projectID})
+}
+
+func (s *LogRetentionService) purge(projectIDs []uint) (*PurgeSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	summary := &PurgeSummary{}
+	policies, err := s.projectPolicies(projectIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, projectID := range projectIDs {
+		maxAge, maxRows := s.EffectivePolicy(policies[projectID])
+		deletedByAge, deletedByRows := int64(0), int64(0)
+
+		if maxAge > 0 {
+			cutoff := s.Now().Add(-maxAge)
+			deletedByAge, err = s.deleteInBatches(s.DB.Where("project_id = ? AND created_at < ?", projectID, cutoff))
+			if err != nil {
+				return summary, fmt.Errorf("failed to purge old request logs of project %d: %w", projectID, err)
+			}
+		}
+
+		if maxRows > 0 {
+			// Everything up to the newest log beyond the limit goes
+			var cutoffIDs []uint
+			err = s.DB.Model(&models.RequestLog{}).Where("project_id = ?", projectID).
+				Order("id DESC").Offset(maxRows).Limit(1).Pluck("id", &cutoffIDs).Error
+			if err != nil {
+				return summary, fmt.Errorf("failed to find the row limit of project %d: %w", projectID, err)
+			}
+			if len(cutoffIDs) > 0 {
+				deletedByRows, err = s.deleteInBatches(s.DB.Where("project_id = ? AND id <= ?", projectID, cutoffIDs[0]))
+				if err != nil {
+					return summary, fmt.Errorf("failed to purge excess request logs of project %d: %w", projectID, err)
+				}
+			}
+		}
+
+		if deletedByAge+deletedByRows > 0 {
+			summary.Projects++
+			summary.DeletedByAge += deletedByAge
+			summary.DeletedByRows += deletedByRows
+		}
+	}
+
+	summary.DurationMs = time.Since(start).Milliseconds()
+	return summary, nil
+}
+
+// projectPolicies loads the retention overrides of the projects, including soft-deleted ones.
+func (s *LogRetentionService) projectPolicies(projectIDs []uint) (map[uint]models.LogRetentionPolicy, error) {
+	policies := make(map[uint]models.LogRetentionPolicy, len(projectIDs))
+	if len(projectIDs) == 0 {
+		return policies, nil
+	}
+	var projects []models.Project
+	err := s.DB.Unscoped().Select("id", "log_retention_max_age_hours", "log_retention_max_rows").
+		Where("id IN ?", projectIDs).Find(&projects).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load log retention of projects: %w", err)
+	}
+	for _, project := range projects {
+		policies[project.ID] = project.LogRetention
+	}
+	return policies, nil
+}
+
+// deleteInBatches deletes the request logs matched by query, BatchSize rows at a time.
+// IDs are selected first because MySQL does not allow LIMIT in a DELETE subquery.
+func (s *LogRetentionService) deleteInBatches(query *gorm.DB) (int64, error) {
+	var total int64
+	for {
+		var ids []uint
+		if err := query.Session(&gorm.Session{}).Model(&models.RequestLog{}).Order("id").Limit(s.BatchSize).Pluck("id", &ids).Error; err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+		result := s.DB.Where("id IN ?", ids).Delete(&models.RequestLog{})
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+		if len(ids) < s.BatchSize {
+			return total, nil
+		}
+	}
+}
+
+// StartJanitor purges on every interval until the returned stop function is called.
+func (s *LogRetentionService) StartJanitor(interval time.Duration) (stop func()) {
+	return runPeriodically(interval, func() {
+		summary, err := s.Purge()
+		if err != nil {
+			slog.Error("Request log janitor failed", "error", err)
+		} else if summary.Deleted() > 0 {
+			slog.Info("Request log janitor removed logs", "deleted", summary.Deleted(), "projects", summary.Projects,
+				"deleted_by_age", summary.DeletedByAge, "deleted_by_rows", summary.DeletedByRows, "duration_ms", summary.DurationMs)
+		}
+	})
+}
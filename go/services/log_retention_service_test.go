@@ -0,0 +1,72 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"mockapi/database"
+	"mockapi/models"
+	"mockapi/services"
+)
+
+func seedRequestLogs(t *testing.T, db *gorm.DB, projectID uint, now time.Time, ages ...time.Duration) {
+	t.Helper()
+	logs := make([]models.RequestLog, len(ages))
+	for i, age := range ages {
+		logs[i] = models.RequestLog{ProjectID: projectID, Method: "GET", URL: "/", CreatedAt: now.Add(-age)}
+	}
+	require.NoError(t, db.Create(&logs).Error)
+}
+
+func countRequestLogs(t *testing.T, db *gorm.DB, projectID uint) int64 {
+	t.Helper()
+	var count int64
+	require.NoError(t, db.Model(&models.RequestLog{}).Where("project_id = ?", projectID).Count(&count).Error)
+	return count
+}
+
+func TestLogRetentionPurge(t *testing.T) {
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	oneHour, fiveRows := 1, 5
+	free := models.Project{Name: "Free", Slug: "free", ChannelID: "free", TeamID: team.ID,
+		LogRetention: models.LogRetentionPolicy{MaxAgeHours: &oneHour, MaxRows: &fiveRows}}
+	require.NoError(t, db.Create(&free).Error)
+	regular := models.Project{Name: "Regular", Slug: "regular", ChannelID: "regular", TeamID: team.ID}
+	require.NoError(t, db.Create(&regular).Error)
+
+	// Free project: 3 logs older than its 1h override and 8 recent ones, of which only 5 are kept
+	seedRequestLogs(t, db, free.ID, now, 3*time.Hour, 2*time.Hour, 90*time.Minute)
+	seedRequestLogs(t, db, free.ID, now, 8*time.Minute, 7*time.Minute, 6*time.Minute, 5*time.Minute, 4*time.Minute, 3*time.Minute, 2*time.Minute, time.Minute)
+	// Regular project and logs without a project follow the global 24h retention
+	seedRequestLogs(t, db, regular.ID, now, 48*time.Hour, 2*time.Hour, time.Minute)
+	seedRequestLogs(t, db, 0, now, 30*time.Hour)
+
+	service := services.NewLogRetentionService(db, 24*time.Hour, 0, 2)
+	service.Now = func() time.Time { return now }
+
+	summary, err := service.Purge()
+	require.NoError(t, err)
+	assert.Equal(t, 3, summary.Projects)
+	assert.Equal(t, int64(5), summary.DeletedByAge)
+	assert.Equal(t, int64(3), summary.DeletedByRows)
+	assert.Equal(t, int64(5), countRequestLogs(t, db, free.ID))
+	assert.Equal(t, int64(2), countRequestLogs(t, db, regular.ID))
+	assert.Equal(t, int64(0), countRequestLogs(t, db, 0))
+
+	var kept []time.Time
+	require.NoError(t, db.Model(&models.RequestLog{}).Where("project_id = ?", free.ID).Order("created_at").Pluck("created_at", &kept).Error)
+	assert.True(t, kept[0].Equal(now.Add(-5*time.Minute)), "the newest logs are kept, got %v", kept[0])
+
+	summary, err = service.PurgeProject(free.ID)
+	require.NoError(t, err)
+	assert.Zero(t, summary.Deleted())
+}
@@ -0,0 +1,412 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mockapi/models"
+)
+
+// memoryEntry is one key of the in-memory store. Plain values and counters use value;
+// the sliding window log and token bucket keep their state in the remaining fields.
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time // Zero means the key does not expire
+
+	requests []time.Time // Sliding window log
+	tokens   float64     // Token bucket
+	filledAt time.Time   // Token bucket
+}
+
+// MemoryStore is an in-process KeyValueStore. State lives in the process, so it is not shared between
+// instances and is lost on restart. It suits local development, CI and a single instance without Redis.
+// Calls never block on I/O, so the contexts passed to them are ignored.
+type MemoryStore struct {
+	// Now returns the current time; tests replace it to move the clock.
+	Now func() time.Time
+
+	mu          sync.Mutex
+	entries     map[string]*memoryEntry
+	subscribers map[string]map[chan string]struct{}
+	stop        chan struct{}
+	stopOnce    sync.Once
+}
+
+// memoryStoreSweepInterval is how often expired keys are removed from memory.
+const memoryStoreSweepInterval = time.Minute
+
+// NewMemoryStore creates a MemoryStore and starts the sweeper that removes expired keys.
+// Call Close to stop it.
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		Now:         time.Now,
+		entries:     make(map[string]*memoryEntry),
+		subscribers: make(map[string]map[chan string]struct{}),
+		stop:        make(chan struct{}),
+	}
+	go s.sweep()
+	return s
+}
+
+// Close stops the sweeper and closes all subscriptions.
+func (s *MemoryStore) Close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for channel, subs := range s.subscribers {
+			for sub := range subs {
+				close(sub)
+			}
+			delete(s.subscribers, channel)
+		}
+	})
+}
+
+func (s *MemoryStore) sweep() {
+	ticker := time.NewTicker(memoryStoreSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			now := s.Now()
+			for key, entry := range s.entries {
+				if entry.expired(now) {
+					delete(s.entries, key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// entry returns the live entry for key, dropping it if it has expired. The caller must hold mu.
+func (s *MemoryStore) entry(key string, now time.Time) *memoryEntry {
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if entry.expired(now) {
+		delete(s.entries, key)
+		return nil
+	}
+	return entry
+}
+
+func expiresAt(now time.Time, expiration time.Duration) time.Time {
+	if expiration <= 0 {
+		return time.Time{}
+	}
+	return now.Add(expiration)
+}
+
+// CreateRedisKey generates a key by joining parts with a colon, the same way RedisService does.
+func (s *MemoryStore) CreateRedisKey(parts ...string) string {
+	return strings.Join(parts, ":")
+}
+
+// Ping always succeeds; the store cannot be unreachable.
+func (s *MemoryStore) Ping(_ context.Context) error {
+	return nil
+}
+
+// GetValue returns the value stored at key, or "" when it does not exist.
+func (s *MemoryStore) GetValue(_ context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry := s.entry(key, s.Now()); entry != nil {
+		return entry.value, nil
+	}
+	return "", nil
+}
+
+// SetValue stores a value. If expiration is 0, the key does not expire.
+func (s *MemoryStore) SetValue(_ context.Context, key string, value interface{}, expiration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.Now()
+	s.entries[key] = &memoryEntry{value: fmt.Sprint(value), expiresAt: expiresAt(now, expiration)}
+	return nil
+}
+
+// DeleteValue deletes a key.
+func (s *MemoryStore) DeleteValue(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// DeleteKeysByPattern deletes all keys matching a Redis glob-style pattern and returns how many were removed.
+func (s *MemoryStore) DeleteKeysByPattern(_ context.Context, pattern string) (int64, error) {
+	matcher, err := globToRegexp(pattern)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.Now()
+	var deleted int64
+	for key := range s.entries {
+		if s.entry(key, now) == nil {
+			continue
+		}
+		if matcher.MatchString(key) {
+			delete(s.entries, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Increment atomically increments a counter and returns its new value.
+// The expiration is refreshed on every call when it is greater than 0.
+func (s *MemoryStore) Increment(_ context.Context, key string, expiration time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.Now()
+
+	var count int64
+	entry := s.entry(key, now)
+	if entry != nil {
+		n, err := strconv.ParseInt(entry.value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value of '%s' is not an integer", key)
+		}
+		count = n
+	} else {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+	count++
+	entry.value = strconv.FormatInt(count, 10)
+	if expiration > 0 {
+		entry.expiresAt = now.Add(expiration)
+	}
+	return count, nil
+}
+
+// UpdateValue atomically replaces a value. update receives the current value ("" when the key does
+// not exist) and returns the new one. Errors returned by update are passed through as is.
+func (s *MemoryStore) UpdateValue(_ context.Context, key string, expiration time.Duration, update func(current string) (string, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.Now()
+
+	var current string
+	if entry := s.entry(key, now); entry != nil {
+		current = entry.value
+	}
+	next, err := update(current)
+	if err != nil {
+		return err
+	}
+	s.entries[key] = &memoryEntry{value: next, expiresAt: expiresAt(now, expiration)}
+	return nil
+}
+
+// RateLimit implements a fixed window rate limiting algorithm.
+// It returns true if the request is rate-limited (i.e., exceeds the limit), false otherwise.
+func (s *MemoryStore) RateLimit(_ context.Context, key string, limit int, windowSeconds int64) (bool, error) {
+	result, err := s.FixedWindowRateLimit(key, limit, time.Duration(windowSeconds)*time.Second)
+	if err != nil {
+		return true, err // Fail closed
+	}
+	return result.Limited, nil
+}
+
+// CheckRateLimit counts a request with the given algorithm, with the same semantics as RedisService.
+func (s *MemoryStore) CheckRateLimit(_ context.Context, algorithm models.RateLimitAlgorithm, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	switch algorithm {
+	case models.RateLimitSlidingWindow:
+		return s.SlidingWindowRateLimit(key, limit, window)
+	case models.RateLimitTokenBucket:
+		return s.TokenBucketRateLimit(key, limit, window)
+	default:
+		return s.FixedWindowRateLimit(key, limit, window)
+	}
+}
+
+// FixedWindowRateLimit counts a request in a fixed window and reports the remaining quota.
+func (s *MemoryStore) FixedWindowRateLimit(key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.Now()
+
+	entry := s.entry(key, now)
+	if entry == nil {
+		entry = &memoryEntry{value: "0", expiresAt: now.Add(window)}
+		s.entries[key] = entry
+	}
+	count, err := strconv.ParseInt(entry.value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("value of '%s' is not an integer", key)
+	}
+	count++
+	entry.value = strconv.FormatInt(count, 10)
+	return NewRateLimitResult(limit, count, entry.expiresAt.Sub(now)), nil
+}
+
+// SlidingWindowRateLimit allows at most limit requests in any window-long period.
+func (s *MemoryStore) SlidingWindowRateLimit(key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.Now()
+
+	entry := s.entry(key, now)
+	if entry == nil {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+	requests := entry.requests[:0]
+	for _, at := range entry.requests {
+		if at.After(now.Add(-window)) {
+			requests = append(requests, at)
+		}
+	}
+	allowed := len(requests) < limit
+	if allowed {
+		requests = append(requests, now)
+	}
+	entry.requests = requests
+	entry.expiresAt = now.Add(window)
+
+	resetAfter := window
+	if len(requests) > 0 {
+		resetAfter = requests[0].Add(window).Sub(now)
+	}
+	return &RateLimitResult{
+		Limited:    !allowed,
+		Limit:      limit,
+		Remaining:  max(limit-len(requests), 0),
+		ResetAfter: resetAfter,
+	}, nil
+}
+
+// TokenBucketRateLimit allows bursts of up to limit requests and refills limit tokens per window.
+// ResetAfter is the wait for the next token when limited and the time until the bucket is full otherwise.
+func (s *MemoryStore) TokenBucketRateLimit(key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.Now()
+
+	capacity := float64(limit)
+	rate := capacity / float64(window.Milliseconds()) // Tokens per millisecond
+	entry := s.entry(key, now)
+	if entry == nil {
+		entry = &memoryEntry{tokens: capacity, filledAt: now}
+		s.entries[key] = entry
+	}
+	elapsed := float64(max(now.Sub(entry.filledAt).Milliseconds(), 0))
+	tokens := math.Min(capacity, entry.tokens+elapsed*rate)
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	entry.tokens = tokens
+	entry.filledAt = now
+	entry.expiresAt = now.Add(window)
+
+	result := &RateLimitResult{
+		Limited:    !allowed,
+		Limit:      limit,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration(math.Ceil((capacity-tokens)/rate)) * time.Millisecond,
+	}
+	if result.Limited {
+		result.ResetAfter = time.Duration(math.Ceil((1-tokens)/rate)) * time.Millisecond
+	}
+	return result, nil
+}
+
+// Publish sends a message to every current subscriber of a channel.
+// Subscribers that are not keeping up miss the message rather than blocking the publisher.
+func (s *MemoryStore) Publish(_ context.Context, channel, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers[channel] {
+		select {
+		case sub <- message:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe listens on a channel. Messages are delivered on the returned channel until
+// unsubscribe is called.
+func (s *MemoryStore) Subscribe(_ context.Context, channel string) (<-chan string, func(), error) {
+	sub := make(chan string, subscriptionBuffer)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscribers[channel] == nil {
+		s.subscribers[channel] = make(map[chan string]struct{})
+	}
+	s.subscribers[channel][sub] = struct{}{}
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[channel][sub]; ok {
+			delete(s.subscribers[channel], sub)
+			close(sub)
+		}
+	}
+	return sub, unsubscribe, nil
+}
+
+// globToRegexp converts a Redis glob-style pattern (*, ?, [...], and \ escapes) to a regular expression.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "^") {
+				class = "^" + regexp.QuoteMeta(class[1:])
+			} else {
+				class = regexp.QuoteMeta(class)
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid key pattern '%s': %w", pattern, err)
+	}
+	return re, nil
+}
@@ -0,0 +1,166 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mockapi/models"
+	"mockapi/services"
+)
+
+func newTestMemoryStore(t *testing.T) (*services.MemoryStore, *time.Time) {
+	t.Helper()
+	store := services.NewMemoryStore()
+	t.Cleanup(store.Close)
+	now := time.Unix(1700000000, 0)
+	store.Now = func() time.Time { return now }
+	return store, &now
+}
+
+func TestMemoryStoreValues(t *testing.T) {
+	store, now := newTestMemoryStore(t)
+
+	require.NoError(t, store.SetValue(context.Background(), "a", "1", time.Minute))
+	require.NoError(t, store.SetValue(context.Background(), "b", 2, 0))
+	value, err := store.GetValue(context.Background(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, "1", value)
+
+	*now = now.Add(time.Minute)
+	value, err = store.GetValue(context.Background(), "a")
+	require.NoError(t, err)
+	assert.Empty(t, value, "expired keys are gone")
+	value, err = store.GetValue(context.Background(), "b")
+	require.NoError(t, err)
+	assert.Equal(t, "2", value, "keys without expiration are kept")
+
+	require.NoError(t, store.DeleteValue(context.Background(), "b"))
+	value, err = store.GetValue(context.Background(), "b")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+}
+
+func TestMemoryStoreIncrementAndUpdate(t *testing.T) {
+	store, now := newTestMemoryStore(t)
+
+	for i := int64(1); i <= 3; i++ {
+		n, err := store.Increment(context.Background(), "counter", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, i, n)
+	}
+	*now = now.Add(time.Minute)
+	n, err := store.Increment(context.Background(), "counter", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "the counter restarts after it expires")
+
+	require.NoError(t, store.UpdateValue(context.Background(), "doc", time.Minute, func(current string) (string, error) {
+		assert.Empty(t, current)
+		return "v1", nil
+	}))
+	errStop := errors.New("stop")
+	err = store.UpdateValue(context.Background(), "doc", time.Minute, func(current string) (string, error) {
+		assert.Equal(t, "v1", current)
+		return "", errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	value, _ := store.GetValue(context.Background(), "doc")
+	assert.Equal(t, "v1", value, "a failed update leaves the value unchanged")
+}
+
+func TestMemoryStoreDeleteKeysByPattern(t *testing.T) {
+	store, _ := newTestMemoryStore(t)
+
+	for _, key := range []string{"scenario:1:a:login", "scenario:1:b:login", "scenario:2:a:login", "sequence:1:a/b"} {
+		require.NoError(t, store.SetValue(context.Background(), key, "x", time.Minute))
+	}
+	deleted, err := store.DeleteKeysByPattern(context.Background(), "scenario:1:*")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	deleted, err = store.DeleteKeysByPattern(context.Background(), "sequence:1:a/?")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted, "* and ? match slashes like in Redis")
+
+	value, _ := store.GetValue(context.Background(), "scenario:2:a:login")
+	assert.Equal(t, "x", value)
+}
+
+func TestMemoryStoreRateLimits(t *testing.T) {
+	t.Run("fixed window", func(t *testing.T) {
+		store, now := newTestMemoryStore(t)
+		for i := 0; i < 3; i++ {
+			result, err := store.CheckRateLimit(context.Background(), models.RateLimitFixedWindow, "fw", 3, time.Minute)
+			require.NoError(t, err)
+			assert.False(t, result.Limited)
+			assert.Equal(t, 2-i, result.Remaining)
+		}
+		*now = now.Add(20 * time.Second)
+		result, err := store.CheckRateLimit(context.Background(), models.RateLimitFixedWindow, "fw", 3, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, result.Limited)
+		assert.Equal(t, 40*time.Second, result.ResetAfter)
+
+		*now = now.Add(40 * time.Second)
+		limited, err := store.RateLimit(context.Background(), "fw", 3, 60)
+		require.NoError(t, err)
+		assert.False(t, limited)
+	})
+
+	t.Run("sliding window", func(t *testing.T) {
+		store, now := newTestMemoryStore(t)
+		for i := 0; i < 2; i++ {
+			result, err := store.SlidingWindowRateLimit("sw", 2, time.Minute)
+			require.NoError(t, err)
+			assert.False(t, result.Limited)
+		}
+		*now = now.Add(10 * time.Second)
+		result, err := store.SlidingWindowRateLimit("sw", 2, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, result.Limited)
+		assert.Equal(t, 50*time.Second, result.ResetAfter)
+
+		*now = now.Add(50 * time.Second)
+		result, err = store.SlidingWindowRateLimit("sw", 2, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, result.Limited)
+	})
+
+	t.Run("token bucket", func(t *testing.T) {
+		store, now := newTestMemoryStore(t)
+		for i := 0; i < 4; i++ {
+			result, err := store.TokenBucketRateLimit("tb", 4, time.Minute)
+			require.NoError(t, err)
+			assert.False(t, result.Limited)
+		}
+		result, err := store.TokenBucketRateLimit("tb", 4, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, result.Limited)
+		assert.Equal(t, 15*time.Second, result.ResetAfter)
+
+		*now = now.Add(15 * time.Second)
+		result, err = store.TokenBucketRateLimit("tb", 4, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, result.Limited)
+		assert.Equal(t, 0, result.Remaining)
+	})
+}
+
+func TestMemoryStorePubSub(t *testing.T) {
+	store, _ := newTestMemoryStore(t)
+
+	messages, unsubscribe, err := store.Subscribe(context.Background(), "events")
+	require.NoError(t, err)
+	require.NoError(t, store.Publish(context.Background(), "events", "hello"))
+	require.NoError(t, store.Publish(context.Background(), "other", "ignored"))
+	assert.Equal(t, "hello", <-messages)
+
+	unsubscribe()
+	_, open := <-messages
+	assert.False(t, open, "unsubscribing closes the channel")
+	require.NoError(t, store.Publish(context.Background(), "events", "after"))
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+
+	"mockapi/models"
+)
+
+// mockCachePrefix starts every key written by MockCache. Keys are laid out as
+// mockcache:<team>:<project>:project and mockcache:<team>:<project>:url:<method>:<path>,
+// so everything cached for a project is dropped with a single pattern delete.
+const mockCachePrefix = "mockcache"
+
+// mockCacheNotFound is cached for paths without a URL, so requests served by CRUD resources
+// or answered with 404 do not reach the database either.
+const mockCacheNotFound = "null"
+
+// MockCache is a read-through cache in the key-value store for resolving mock requests: the project
+// for (team, project) and the URL with its variants for (team, project, method, path).
+// Services invalidate it on every write, and concurrent misses for the same key share one
+// database load. A nil MockCache, or one with a TTL of zero, passes every lookup through.
+type MockCache struct {
+	DB    *gorm.DB
+	Store KeyValueStore
+	TTL   time.Duration
+
+	group singleflight.Group
+}
+
+// NewMockCache creates a MockCache whose entries expire after ttl.
+func NewMockCache(db *gorm.DB, store KeyValueStore, ttl time.Duration) *MockCache {
+	return &MockCache{DB: db, Store: store, TTL: ttl}
+}
+
+func (c *MockCache) enabled() bool {
+	return c != nil && c.Store != nil && c.TTL > 0
+}
+
+func (c *MockCache) projectKey(teamSlug, projectSlug string) string {
+	return c.Store.CreateRedisKey(mockCachePrefix, teamSlug, projectSlug, "project")
+}
+
+// urlKey does not include the method: URLs are matched by path alone, so every method resolves to the same entry.
+func (c *MockCache) urlKey(teamSlug, projectSlug, path string) string {
+	return c.Store.CreateRedisKey(mockCachePrefix, teamSlug, projectSlug, "url", path)
+}
+
+// Project returns the project cached for the slugs, calling load on a miss.
+// Lookups that fail are not cached, so a project becomes reachable as soon as it is created.
+func (c *MockCache) Project(ctx context.Context, teamSlug, projectSlug string, load func() (*models.Project, error)) (*models.Project, error) {
+	if !c.enabled() {
+		return load()
+	}
+	var project *models.Project
+	err := c.readThrough(ctx, c.projectKey(teamSlug, projectSlug), &project, func() (interface{}, error) {
+		return load()
+	})
+	return project, err
+}
+
+// URL returns the URL with its mock contents cached for the path, calling load on a miss.
+// A load failing with gorm.ErrRecordNotFound is cached as well and reported with that error.
+func (c *MockCache) URL(ctx context.Context, teamSlug, projectSlug, path string, load func() (*models.Url, error)) (*models.Url, error) {
+	if !c.enabled() {
+		return load()
+	}
+	var url *models.Url
+	err := c.readThrough(ctx, c.urlKey(teamSlug, projectSlug, path), &url, func() (interface{}, error) {
+		url, err := load()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return url, err
+	})
+	if err == nil && url == nil {
+		return nil, fmt.Errorf("url with path '%s' for project '%s' (team '%s') not found: %w", path, projectSlug, teamSlug, gorm.ErrRecordNotFound)
+	}
+	return url, err
+}
+
+// readThrough decodes the entry under key into target, loading and storing it on a miss.
+// The encoded entry is shared between concurrent callers and every caller decodes its own copy,
+// because the controllers modify the mock contents they are handed.
+func (c *MockCache) readThrough(ctx context.Context, key string, target interface{}, load func() (interface{}, error)) error {
+	encoded, err := c.Store.GetValue(ctx, key)
+	if err != nil {
+		slog.Warn("Mock cache read failed", "key", key, "error", err)
+	}
+	if encoded == "" {
+		result, loadErr, _ := c.group.Do(key, func() (interface{}, error) {
+			value, err := load()
+			if err != nil {
+				return "", err
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode mock cache entry: %w", err)
+			}
+			if err := c.Store.SetValue(ctx, key, string(encoded), c.TTL); err != nil {
+				slog.Warn("Mock cache write failed", "key", key, "error", err)
+			}
+			return string(encoded), nil
+		})
+		if loadErr != nil {
+			return loadErr
+		}
+		encoded = result.(string)
+	}
+	if err := json.Unmarshal([]byte(encoded), target); err != nil {
+		return fmt.Errorf("failed to decode mock cache entry '%s': %w", key, err)
+	}
+	return nil
+}
+
+// InvalidateProject drops every entry cached for the project.
+func (c *MockCache) InvalidateProject(projectID uint) {
+	c.invalidatePattern(c.projectPattern(projectID))
+}
+
+// projectPattern returns the key pattern of every entry cached for the project. Writes that change the
+// slugs or delete the project look it up before they commit and pass it to invalidatePattern afterwards,
+// so a concurrent read cannot cache the old state again. The pattern covers the whole cache when the
+// lookup fails, and is empty when the project does not exist or the cache is disabled.
+func (c *MockCache) projectPattern(projectID uint) string {
+	if !c.enabled() {
+		return ""
+	}
+	var slugs struct {
+		ProjectSlug string
+		TeamSlug    string
+	}
+	err := c.DB.Unscoped().Table("projects").
+		Select("projects.slug AS project_slug, teams.slug AS team_slug").
+		Joins("JOIN teams ON teams.id = projects.team_id").
+		Where("projects.id = ?", projectID).
+		Take(&slugs).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ""
+		}
+		slog.Warn("Failed to look up project for mock cache invalidation, flushing the cache", "project_id", projectID, "error", err)
+		return c.Store.CreateRedisKey(mockCachePrefix, "*")
+	}
+	return c.Store.CreateRedisKey(mockCachePrefix, EscapeKeyPattern(slugs.TeamSlug), EscapeKeyPattern(slugs.ProjectSlug), "*")
+}
+
+// invalidatePattern drops the entries matching a pattern returned by projectPattern.
+func (c *MockCache) invalidatePattern(pattern string) {
+	if pattern == "" || !c.enabled() {
+		return
+	}
+	c.deletePattern(pattern)
+}
+
+// InvalidateURL drops every entry cached for the project the URL belongs to.
+func (c *MockCache) InvalidateURL(urlID uint) {
+	if !c.enabled() {
+		return
+	}
+	var url models.Url
+	if err := c.DB.Unscoped().Select("project_id").Take(&url, urlID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.Warn("Failed to look up url for mock cache invalidation, flushing the cache", "url_id", urlID, "error", err)
+			c.InvalidateAll()
+		}
+		return
+	}
+	c.InvalidateProject(url.ProjectID)
+}
+
+// InvalidateAll drops every cached entry, e.g. after mock definitions were reconciled.
+func (c *MockCache) InvalidateAll() {
+	if !c.enabled() {
+		return
+	}
+	c.deletePattern(c.Store.CreateRedisKey(mockCachePrefix, "*"))
+}
+
+// deletePattern runs without a request context: invalidations follow writes that already committed,
+// so they must not be cut short when the request that made the write ends.
+func (c *MockCache) deletePattern(pattern string) {
+	if _, err := c.Store.DeleteKeysByPattern(context.Background(), pattern); err != nil {
+		// Entries still expire after the TTL, so a failed delete only delays the change
+		slog.Warn("Failed to invalidate mock cache entries", "pattern", pattern, "error", err)
+	}
+}
@@ -0,0 +1,141 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"mockapi/database"
+	"mockapi/models"
+	"mockapi/services"
+)
+
+func newTestMockCache(t *testing.T) (*services.MockCache, *gorm.DB, models.Project) {
+	t.Helper()
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	t.Cleanup(store.Close)
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	project := models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: team.ID}
+	require.NoError(t, db.Create(&project).Error)
+	return services.NewMockCache(db, store, time.Minute), db, project
+}
+
+func TestMockCacheInvalidatesOnWrites(t *testing.T) {
+	cache, db, project := newTestMockCache(t)
+	urlService := services.NewURLService(db, cache.Store)
+	urlService.Cache = cache
+	mockContentService := services.NewMockContentService(db)
+	mockContentService.Cache = cache
+
+	_, err := urlService.ResolveURL(context.Background(), "team", "shop", "/orders")
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	url := models.Url{Name: "Orders", URL: "/orders"}
+	require.NoError(t, urlService.CreateURL(&url, project.ID))
+	_, err = mockContentService.SaveMockContentList([]models.MockContent{{Name: "v1", Data: `"v1"`}}, url.ID)
+	require.NoError(t, err)
+
+	resolved, err := urlService.ResolveURL(context.Background(), "team", "shop", "/orders")
+	require.NoError(t, err, "creating the URL drops the cached miss")
+	require.Len(t, resolved.MockContents, 1)
+	assert.Equal(t, `"v1"`, resolved.MockContents[0].Data)
+
+	// Writes that bypass the services are not seen until the entry is invalidated
+	require.NoError(t, db.Model(&models.MockContent{}).Where("url_id = ?", url.ID).Update("data", `"direct"`).Error)
+	resolved, err = urlService.ResolveURL(context.Background(), "team", "shop", "/orders")
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, resolved.MockContents[0].Data)
+
+	_, err = mockContentService.UpdateMockContentList([]models.MockContent{{Name: "v2", Data: `"v2"`}}, url.ID)
+	require.NoError(t, err)
+	resolved, err = urlService.ResolveURL(context.Background(), "team", "shop", "/orders")
+	require.NoError(t, err)
+	assert.Equal(t, `"v2"`, resolved.MockContents[0].Data)
+
+	// Single-variant writes invalidate as well
+	extra := models.MockContent{Name: "v3", Data: `"v3"`, UrlID: url.ID}
+	require.NoError(t, mockContentService.CreateMockContent(&extra))
+	resolved, err = urlService.ResolveURL(context.Background(), "team", "shop", "/orders")
+	require.NoError(t, err)
+	require.Len(t, resolved.MockContents, 2)
+
+	extra.Data = `"v4"`
+	require.NoError(t, mockContentService.UpdateMockContent(&extra))
+	resolved, err = urlService.ResolveURL(context.Background(), "team", "shop", "/orders")
+	require.NoError(t, err)
+	assert.Contains(t, []string{resolved.MockContents[0].Data, resolved.MockContents[1].Data}, `"v4"`)
+
+	require.NoError(t, mockContentService.DeleteMockContent(extra.ID))
+	resolved, err = urlService.ResolveURL(context.Background(), "team", "shop", "/orders")
+	require.NoError(t, err)
+	assert.Len(t, resolved.MockContents, 1)
+
+	require.NoError(t, urlService.DeleteURL(url.ID))
+	_, err = urlService.ResolveURL(context.Background(), "team", "shop", "/orders")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestMockCacheProjectInvalidation(t *testing.T) {
+	cache, db, project := newTestMockCache(t)
+	projectService := services.NewProjectService(db)
+	projectService.Cache = cache
+
+	cached, err := projectService.GetProjectByTeamSlugAndProjectSlug(context.Background(), "team", "shop")
+	require.NoError(t, err)
+	assert.False(t, cached.IsForwardProxyActive)
+
+	require.NoError(t, projectService.UpdateForwardProxyActiveStatus(project.ID, true))
+	cached, err = projectService.GetProjectByTeamSlugAndProjectSlug(context.Background(), "team", "shop")
+	require.NoError(t, err)
+	assert.True(t, cached.IsForwardProxyActive)
+
+	_, err = projectService.GetProjectByTeamSlugAndProjectSlug(context.Background(), "team", "missing")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestMockCacheCollapsesConcurrentMisses(t *testing.T) {
+	cache, _, _ := newTestMockCache(t)
+
+	var loads int32
+	load := func() (*models.Url, error) {
+		atomic.AddInt32(&loads, 1)
+		time.Sleep(50 * time.Millisecond)
+		return &models.Url{URL: "/orders", MockContents: []models.MockContent{{Name: "v1", Randomness: -1}}}, nil
+	}
+
+	var wg sync.WaitGroup
+	urls := make([]*models.Url, 10)
+	for i := range urls {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			url, err := cache.URL(context.Background(), "team", "shop", "/orders", load)
+			assert.NoError(t, err)
+			urls[i] = url
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+	urls[0].MockContents[0].Randomness = 0
+	assert.Equal(t, int64(-1), urls[1].MockContents[0].Randomness, "every caller gets its own copy")
+}
+
+func TestMockCacheDisabled(t *testing.T) {
+	var cache *services.MockCache
+	loadErr := errors.New("boom")
+	_, err := cache.URL(context.Background(), "team", "shop", "/orders", func() (*models.Url, error) { return nil, loadErr })
+	assert.ErrorIs(t, err, loadErr)
+	cache.InvalidateAll() // No-op without a cache
+}
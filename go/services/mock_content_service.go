@@ -12,6 +12,8 @@ import (
 // MockContentService handles business logic related to mock contents.
 type MockContentService struct {
 	DB *gorm.DB
+	// Cache is invalidated whenever the mock contents of a URL change.
+	Cache *MockCache
 }
 
 // NewMockContentService creates a new MockContentService.
@@ -33,12 +35,21 @@ func (s *MockContentService) SaveMockContentList(mockContents []models.MockConte
 		mockContents[i].BaseModel.ID = 0
 	}
 
+	url, err := s.loadURL(urlID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateMockContents(url, mockContents); err != nil {
+		return nil, err
+	}
+
 	// Using CreateInBatches can be efficient for large lists.
 	// However, for simplicity and returning created objects with IDs, iterating or single Create might be okay.
 	// GORM's Create can handle a slice directly.
 	if err := s.DB.Create(&mockContents).Error; err != nil {
 		return nil, fmt.Errorf("failed to save mock content list for url ID %d: %w", urlID, err)
 	}
+	s.Cache.InvalidateURL(urlID)
 	return mockContents, nil
 }
 
@@ -49,6 +60,14 @@ func (s *MockContentService) SaveMockContentList(mockContents []models.MockConte
 // For this implementation, let's go with a "delete all then create all" strategy for simplicity,
 // which matches some of the behavior in the original Java `MockContentService.save(List<MockContent>, Url)`.
 func (s *MockContentService) UpdateMockContentList(mockContents []models.MockContent, urlID uint) ([]models.MockContent, error) {
+	url, err := s.loadURL(urlID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateMockContents(url, mockContents); err != nil {
+		return nil, err
+	}
+
 	// Start a transaction
 	tx := s.DB.Begin()
 	if tx.Error != nil {
@@ -79,10 +98,20 @@ func (s *MockContentService) UpdateMockContentList(mockContents []models.MockCon
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	s.Cache.InvalidateURL(urlID)
 
 	return mockContents, nil
 }
 
+// loadURL loads the URL whose response schema and strict mode the mock contents are validated against.
+func (s *MockContentService) loadURL(urlID uint) (*models.Url, error) {
+	var url models.Url
+	if err := s.DB.First(&url, urlID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load url ID %d: %w", urlID, err)
+	}
+	return &url, nil
+}
+
 // SimulateLatency introduces a delay.
 func (s *MockContentService) SimulateLatency(latencyMillis int64) {
 	if latencyMillis > 0 {
@@ -162,6 +191,13 @@ func (s *MockContentService) GetMockContentByID(id uint) (*models.MockContent, e
 
 // DeleteMockContent deletes a mock content by its ID.
 func (s *MockContentService) DeleteMockContent(id uint) error {
+	var content models.MockContent
+	if err := s.DB.Select("id", "url_id").First(&content, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("mock content with ID %d not found for deletion: %w", id, err)
+		}
+		return fmt.Errorf("failed to find mock content with ID %d for deletion: %w", id, err)
+	}
 	result := s.DB.Delete(&models.MockContent{}, id)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete mock content with ID %d: %w", id, result.Error)
@@ -169,6 +205,7 @@ func (s *MockContentService) DeleteMockContent(id uint) error {
 	if result.RowsAffected == 0 {
 		return fmt.Errorf("mock content with ID %d not found for deletion: %w", id, gorm.ErrRecordNotFound)
 	}
+	s.Cache.InvalidateURL(content.UrlID)
 	return nil
 }
 
@@ -184,6 +221,7 @@ func (s *MockContentService) CreateMockContent(content *models.MockContent) erro
     if err := s.DB.Create(content).Error; err != nil {
         return fmt.Errorf("failed to create mock content: %w", err)
     }
+    s.Cache.InvalidateURL(content.UrlID)
     return nil
 }
 
@@ -192,8 +230,17 @@ func (s *MockContentService) UpdateMockContent(content *models.MockContent) erro
     if content == nil || content.ID == 0 {
         return fmt.Errorf("mock content data is invalid or ID is missing for update")
     }
+    var previous models.MockContent
+    if err := s.DB.Select("id", "url_id").First(&previous, content.ID).Error; err != nil && err != gorm.ErrRecordNotFound {
+        return fmt.Errorf("failed to find mock content with ID %d for update: %w", content.ID, err)
+    }
     if err := s.DB.Save(content).Error; err != nil {
         return fmt.Errorf("failed to update mock content with ID %d: %w", content.ID, err)
     }
+    // A variant moved to another URL changes what both URLs serve
+    s.Cache.InvalidateURL(content.UrlID)
+    if previous.UrlID != 0 && previous.UrlID != content.UrlID {
+        s.Cache.InvalidateURL(previous.UrlID)
+    }
     return nil
 }
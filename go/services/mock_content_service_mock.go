@@ -4,8 +4,8 @@ import "mockapi/models"
 
 // MockMockContentService is a manual mock for MockContentService.
 type MockMockContentService struct {
-	SaveMockContentListFunc   func(mockContents []models.MockContent, urlID uint) ([]models.MockContent, error)
-	UpdateMockContentListFunc func(mockContents []models.MockContent, urlID uint) ([]models.MockContent, error)
+	SaveMockContentListFunc     func(mockContents []models.MockContent, urlID uint) ([]models.MockContent, error)
+	UpdateMockContentListFunc   func(mockContents []models.MockContent, urlID uint) ([]models.MockContent, error)
 	SelectRandomMockContentFunc func(contents []models.MockContent) *models.MockContent
 	SimulateLatencyFunc         func(latency int64)
 	// Add other methods used by MockContentController if any
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"mockapi/models"
+)
+
+// ErrInvalidMockContent means the data of a variant does not satisfy its URL's response schema or strict mode.
+var ErrInvalidMockContent = errors.New("invalid mock content")
+
+// VariantViolations lists what is wrong with the data of one variant.
+type VariantViolations struct {
+	Index      int               `json:"index"` // Position of the variant in the saved list
+	Name       string            `json:"name"`
+	Violations []SchemaViolation `json:"violations"`
+}
+
+// MockContentValidationError reports every variant that failed validation. It wraps ErrInvalidMockContent.
+type MockContentValidationError struct {
+	Variants []VariantViolations
+}
+
+func (e *MockContentValidationError) Error() string {
+	var details []string
+	for _, variant := range e.Variants {
+		for _, violation := range variant.Violations {
+			details = append(details, fmt.Sprintf("variant %d (%s) at '%s': %s", variant.Index, variant.Name, violation.Path, violation.Message))
+		}
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidMockContent, strings.Join(details, "; "))
+}
+
+func (e *MockContentValidationError) Unwrap() error {
+	return ErrInvalidMockContent
+}
+
+// ApplyResponseSchema sets the response schema of the URL from a request. An empty schema leaves it
+// unchanged and null removes it. Other schemas are compiled first and stored compacted.
+func ApplyResponseSchema(url *models.Url, schema json.RawMessage) error {
+	if len(schema) == 0 {
+		return nil
+	}
+	if string(schema) == "null" {
+		url.ResponseSchema = ""
+		return nil
+	}
+	if _, err := CompileJSONSchema(string(schema)); err != nil {
+		return err
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, schema); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJSONSchema, err)
+	}
+	url.ResponseSchema = compacted.String()
+	return nil
+}
+
+// ValidateMockContents checks the data of every variant against the URL's response schema, and that it
+// is JSON when the URL is strict. Variants without data are accepted when they answer 204 No Content.
+// It returns a *MockContentValidationError listing every violation, or an error wrapping
+// ErrInvalidJSONSchema when the URL's schema cannot be used.
+func ValidateMockContents(url *models.Url, contents []models.MockContent) error {
+	if url.ResponseSchema == "" && !url.StrictJSON {
+		return nil
+	}
+	var schema *jsonschema.Schema
+	if url.ResponseSchema != "" {
+		var err error
+		if schema, err = CompileJSONSchema(url.ResponseSchema); err != nil {
+			return err
+		}
+	}
+
+	validationErr := &MockContentValidationError{}
+	for i, content := range contents {
+		status := content.Status
+		if status == "" {
+			status = url.Status
+		}
+		if content.Data == "" && status == models.StatusNoContent {
+			continue
+		}
+		var violations []SchemaViolation
+		if schema != nil {
+			violations = ValidateJSONDocument(schema, content.Data)
+		} else if !json.Valid([]byte(content.Data)) {
+			violations = []SchemaViolation{{Message: "not valid JSON, which the URL's strict mode requires"}}
+		}
+		if len(violations) > 0 {
+			validationErr.Variants = append(validationErr.Variants, VariantViolations{Index: i, Name: content.Name, Violations: violations})
+		}
+	}
+	if len(validationErr.Variants) > 0 {
+		return validationErr
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package services_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mockapi/database"
+	"mockapi/dtos"
+	"mockapi/models"
+	"mockapi/services"
+)
+
+func TestValidateMockContents(t *testing.T) {
+	url := &models.Url{Status: models.StatusOK, ResponseSchema: ordersSchema}
+
+	err := services.ValidateMockContents(url, []models.MockContent{
+		{Name: "Valid", Data: `{"orders":[{"id":1}],"total":1}`},
+		{Name: "Bad id", Data: `{"orders":[{"id":"one"}],"total":-1}`},
+		{Name: "Stray comma", Data: `{"orders":[],"total":0,}`},
+		{Name: "Deleted", Status: models.StatusNoContent},
+	})
+	var validationErr *services.MockContentValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.ErrorIs(t, err, services.ErrInvalidMockContent)
+	require.Len(t, validationErr.Variants, 2, "empty 204 variants are not checked")
+
+	badID := validationErr.Variants[0]
+	assert.Equal(t, 1, badID.Index)
+	assert.Equal(t, "Bad id", badID.Name)
+	var paths []string
+	for _, violation := range badID.Violations {
+		paths = append(paths, violation.Path)
+	}
+	assert.ElementsMatch(t, []string{"/orders/0/id", "/total"}, paths)
+
+	assert.Equal(t, 2, validationErr.Variants[1].Index)
+	assert.Contains(t, validationErr.Variants[1].Violations[0].Message, "not valid JSON")
+
+	assert.NoError(t, services.ValidateMockContents(&models.Url{}, []models.MockContent{{Data: "plain text"}}),
+		"URLs without a schema accept anything")
+	strict := &models.Url{Status: models.StatusOK, StrictJSON: true}
+	assert.ErrorIs(t, services.ValidateMockContents(strict, []models.MockContent{{Data: "plain text"}}), services.ErrInvalidMockContent)
+	assert.NoError(t, services.ValidateMockContents(strict, []models.MockContent{{Data: `"plain text"`}}))
+
+	assert.ErrorIs(t, services.ValidateMockContents(&models.Url{ResponseSchema: `{"type": 12}`}, nil), services.ErrInvalidJSONSchema)
+}
+
+func TestMockContentService_ValidatesAgainstURLSchema(t *testing.T) {
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	store := services.NewMemoryStore()
+	t.Cleanup(store.Close)
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	project := models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: team.ID}
+	require.NoError(t, db.Create(&project).Error)
+
+	urlService := services.NewURLService(db, store)
+	mockContentService := services.NewMockContentService(db)
+	url := createOrdersURL(t, urlService, project.ID)
+
+	// Attaching a schema checks the existing variants
+	_, err = urlService.UpdateURL(url.ID, dtos.URLDataDTO{ResponseSchema: json.RawMessage(`{"type": "array"}`)})
+	assert.ErrorIs(t, err, services.ErrInvalidMockContent)
+	_, err = urlService.UpdateURL(url.ID, dtos.URLDataDTO{ResponseSchema: json.RawMessage(`{"type": "nope"}`)})
+	assert.ErrorIs(t, err, services.ErrInvalidJSONSchema)
+	updated, err := urlService.UpdateURL(url.ID, dtos.URLDataDTO{ResponseSchema: json.RawMessage(ordersSchema)})
+	require.NoError(t, err)
+	assert.NotContains(t, updated.ResponseSchema, "\n", "schemas are stored compacted")
+
+	_, err = mockContentService.SaveMockContentList([]models.MockContent{{Name: "No total", Data: `{"orders":[]}`}}, url.ID)
+	assert.ErrorIs(t, err, services.ErrInvalidMockContent)
+	_, err = mockContentService.UpdateMockContentList([]models.MockContent{{Name: "Text", Data: "oops"}}, url.ID)
+	assert.ErrorIs(t, err, services.ErrInvalidMockContent)
+
+	current, err := urlService.GetURLByID(url.ID)
+	require.NoError(t, err)
+	require.Len(t, current.MockContents, 1, "rejected lists change nothing")
+	assert.Equal(t, "Two orders", current.MockContents[0].Name)
+
+	cleared, err := urlService.UpdateURL(url.ID, dtos.URLDataDTO{ResponseSchema: json.RawMessage("null")})
+	require.NoError(t, err)
+	assert.Empty(t, cleared.ResponseSchema)
+	_, err = mockContentService.SaveMockContentList([]models.MockContent{{Name: "Text", Data: "oops"}}, url.ID)
+	assert.NoError(t, err)
+
+	// New URLs are validated once, before anything is written
+	users := &models.Url{Name: "Users", URL: "/users", ResponseSchema: `{"type":"object","required":["id"]}`}
+	err = urlService.CreateURLWithMockContents(users, project.ID, []models.MockContent{{Name: "No id", Data: `{}`}})
+	assert.ErrorIs(t, err, services.ErrInvalidMockContent)
+	_, err = urlService.FindByProjectIDAndURL(project.ID, "/users")
+	assert.Error(t, err, "a rejected URL is not created")
+	require.NoError(t, urlService.CreateURLWithMockContents(users, project.ID, []models.MockContent{{Name: "Ada", Data: `{"id":1}`}}))
+	assert.Len(t, users.MockContents, 1)
+}
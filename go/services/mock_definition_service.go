@@ -0,0 +1,687 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"mockapi/dtos"
+	"mockapi/models"
+	"mockapi/utils"
+)
+
+// MockDefinitionService reconciles the mocks-as-code directory (MOCKS_DIR) into the database.
+// Every file describes exactly one project; projects created from files are flagged ManagedByFile.
+type MockDefinitionService struct {
+	DB  *gorm.DB
+	Dir string
+	mu  sync.Mutex // Serialises reconciliations triggered at startup and via the API
+
+	// Cache is flushed after every reconciliation.
+	Cache *MockCache
+}
+
+// NewMockDefinitionService creates a new MockDefinitionService for the given directory.
+func NewMockDefinitionService(db *gorm.DB, dir string) *MockDefinitionService {
+	return &MockDefinitionService{DB: db, Dir: dir}
+}
+
+// ReconcileSummary reports what a reconciliation changed.
+type ReconcileSummary struct {
+	Files             int      `json:"files"`
+	ProjectsCreated   int      `json:"projects_created"`
+	ProjectsUpdated   int      `json:"projects_updated"`
+	ProjectsUnchanged int      `json:"projects_unchanged"`
+	ProjectsRemoved   int      `json:"projects_removed"`
+	URLsCreated       int      `json:"urls_created"`
+	URLsUpdated       int      `json:"urls_updated"`
+	URLsDeleted       int      `json:"urls_deleted"`
+	Errors            []string `json:"errors,omitempty"`
+}
+
+// String renders the summary as a single log line.
+func (s ReconcileSummary) String() string {
+	return fmt.Sprintf("files=%d projects(created=%d updated=%d unchanged=%d removed=%d) urls(created=%d updated=%d deleted=%d) errors=%d",
+		s.Files, s.ProjectsCreated, s.ProjectsUpdated, s.ProjectsUnchanged, s.ProjectsRemoved,
+		s.URLsCreated, s.URLsUpdated, s.URLsDeleted, len(s.Errors))
+}
+
+func (s *ReconcileSummary) add(other *ReconcileSummary) {
+	s.ProjectsCreated += other.ProjectsCreated
+	s.ProjectsUpdated += other.ProjectsUpdated
+	s.ProjectsUnchanged += other.ProjectsUnchanged
+	s.URLsCreated += other.URLsCreated
+	s.URLsUpdated += other.URLsUpdated
+	s.URLsDeleted += other.URLsDeleted
+}
+
+// LoadMockDefinitions parses every .yaml, .yml and .json file in dir (non-recursively).
+// The returned map is keyed by file path. Files that cannot be loaded are left out and reported in the
+// returned slice, one error per file, so the valid files can still be applied. A project slug defined in
+// more than one file is kept from the first file only. The error is only set when dir cannot be read.
+func LoadMockDefinitions(dir string) (map[string]dtos.MockDefinitionFileDTO, []error, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read mocks directory '%s': %w", dir, err)
+	}
+
+	definitions := make(map[string]dtos.MockDefinitionFileDTO)
+	slugToFile := make(map[string]string)
+	var fileErrs []error
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		def, err := ParseMockDefinitionFile(path)
+		if err != nil {
+			fileErrs = append(fileErrs, err)
+			continue
+		}
+		if other, exists := slugToFile[def.Slug]; exists {
+			fileErrs = append(fileErrs, fmt.Errorf("project slug '%s' is defined in both '%s' and '%s'", def.Slug, other, path))
+			continue
+		}
+		slugToFile[def.Slug] = path
+		definitions[path] = *def
+	}
+	return definitions, fileErrs, nil
+}
+
+// ParseMockDefinitionFile reads and validates a single project definition file.
+func ParseMockDefinitionFile(path string) (*dtos.MockDefinitionFileDTO, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock definition '%s': %w", path, err)
+	}
+
+	var def dtos.MockDefinitionFileDTO
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(raw, &def)
+	} else {
+		err = yaml.Unmarshal(raw, &def)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mock definition '%s': %w", path, err)
+	}
+
+	def.Slug = strings.ToLower(strings.TrimSpace(def.Slug))
+	if def.Slug == "" {
+		return nil, fmt.Errorf("mock definition '%s' is missing a project slug", path)
+	}
+
+	if _, err := mockDefinitionRateLimit(def.RateLimit); err != nil {
+		return nil, fmt.Errorf("mock definition '%s': %w", path, err)
+	}
+
+	seenURLs := make(map[string]bool)
+	for i := range def.URLs {
+		u := &def.URLs[i]
+		if u.URL == "" {
+			return nil, fmt.Errorf("mock definition '%s': url #%d is missing its path", path, i+1)
+		}
+		if !strings.HasPrefix(u.URL, "/") {
+			u.URL = "/" + u.URL
+		}
+		if seenURLs[u.URL] {
+			return nil, fmt.Errorf("mock definition '%s': url '%s' is defined more than once", path, u.URL)
+		}
+		seenURLs[u.URL] = true
+		if u.Name == "" {
+			u.Name = u.URL
+		}
+		if u.Status == "" {
+			u.Status = models.StatusOK
+		}
+		u.Status = models.StatusCode(strings.ToUpper(string(u.Status)))
+		if u.ResponseMode == "" {
+			u.ResponseMode = models.ResponseModeRandom
+		}
+		if u.ResponseMode != models.ResponseModeRandom && u.ResponseMode != models.ResponseModeSequence {
+			return nil, fmt.Errorf("mock definition '%s': url '%s' has unknown response_mode '%s'", path, u.URL, u.ResponseMode)
+		}
+		if u.SequenceEnd == "" {
+			u.SequenceEnd = models.SequenceEndRepeatLast
+		}
+		if u.SequenceEnd != models.SequenceEndRepeatLast && u.SequenceEnd != models.SequenceEndWrap {
+			return nil, fmt.Errorf("mock definition '%s': url '%s' has unknown sequence_end '%s'", path, u.URL, u.SequenceEnd)
+		}
+		if _, err := mockDefinitionRateLimit(u.RateLimit); err != nil {
+			return nil, fmt.Errorf("mock definition '%s': url '%s': %w", path, u.URL, err)
+		}
+		for j := range u.MockContents {
+			if u.MockContents[j].Name == "" {
+				u.MockContents[j].Name = fmt.Sprintf("%s #%d", u.Name, j+1)
+			}
+			u.MockContents[j].Status = models.StatusCode(strings.ToUpper(string(u.MockContents[j].Status)))
+			if _, err := mockDefinitionMatcher(u.MockContents[j].Matcher); err != nil {
+				return nil, fmt.Errorf("mock definition '%s': url '%s': mock content '%s': %w", path, u.URL, u.MockContents[j].Name, err)
+			}
+		}
+	}
+
+	seenResources := make(map[string]bool)
+	for i := range def.Resources {
+		r := &def.Resources[i]
+		if strings.TrimSpace(r.Path) == "" {
+			return nil, fmt.Errorf("mock definition '%s': resource #%d is missing its path", path, i+1)
+		}
+		r.Path = NormalizeResourcePath(r.Path)
+		if seenResources[r.Path] {
+			return nil, fmt.Errorf("mock definition '%s': resource '%s' is defined more than once", path, r.Path)
+		}
+		seenResources[r.Path] = true
+		if r.Name == "" {
+			r.Name = r.Path
+		}
+		if r.IDField == "" {
+			r.IDField = DefaultResourceIDField
+		}
+	}
+	return &def, nil
+}
+
+// mockDefinitionRateLimit converts a definition's rate limit to a validated policy.
+// A missing rate limit is the disabled zero policy, with the same defaults the API applies.
+func mockDefinitionRateLimit(def *dtos.MockDefinitionRateLimitDTO) (models.RateLimitPolicy, error) {
+	var policy models.RateLimitPolicy
+	if def != nil {
+		policy = models.RateLimitPolicy{
+			Limit:         def.Limit,
+			WindowSeconds: def.WindowSeconds,
+			KeyBy:         models.RateLimitKeyBy(def.KeyBy),
+			KeyHeader:     def.KeyHeader,
+			Algorithm:     models.RateLimitAlgorithm(def.Algorithm),
+		}
+	}
+	err := ValidateRateLimitPolicy(&policy)
+	return policy, err
+}
+
+// mockDefinitionMatcher converts a variant's matcher to a validated one, nil when it has no conditions.
+func mockDefinitionMatcher(def *dtos.MockDefinitionMatcherDTO) (*models.RequestMatcher, error) {
+	if def == nil {
+		return nil, nil
+	}
+	return ValidateRequestMatcher(&models.RequestMatcher{
+		Method:       def.Method,
+		Query:        def.Query,
+		Headers:      def.Headers,
+		BodyContains: def.BodyContains,
+	})
+}
+
+// mockDefinitionData converts a variant's data to the string stored in MockContent.Data.
+func mockDefinitionData(data interface{}) (string, error) {
+	switch v := data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode mock data as JSON: %w", err)
+		}
+		return string(encoded), nil
+	}
+}
+
+// Configured reports whether a mocks directory has been set.
+func (s *MockDefinitionService) Configured() bool {
+	return s.Dir != ""
+}
+
+// Reconcile loads all definitions from the directory and applies them to the database.
+// Each project is reconciled in its own transaction, so a broken file does not block the others.
+// Files that cannot be loaded are reported in the summary's errors. Projects are then not removed, as a
+// broken file may still define one of them.
+func (s *MockDefinitionService) Reconcile() (*ReconcileSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Dir == "" {
+		return nil, fmt.Errorf("mocks directory is not configured")
+	}
+
+	definitions, fileErrs, err := LoadMockDefinitions(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ReconcileSummary{Files: len(definitions) + len(fileErrs)}
+	for _, fileErr := range fileErrs {
+		summary.Errors = append(summary.Errors, fileErr.Error())
+	}
+
+	paths := make([]string, 0, len(definitions))
+	for path := range definitions {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	definedSlugs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		def := definitions[path]
+		definedSlugs = append(definedSlugs, def.Slug)
+		// Counts are collected per project and only merged once its transaction has committed.
+		projectSummary := &ReconcileSummary{}
+		if err := s.DB.Transaction(func(tx *gorm.DB) error {
+			return s.reconcileProject(tx, def, projectSummary)
+		}); err != nil {
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		summary.add(projectSummary)
+	}
+
+	// Projects whose file has been removed are soft-deleted with their contents so the files stay the source of truth.
+	if len(fileErrs) > 0 {
+		summary.Errors = append(summary.Errors, "projects without a definition were not removed, as some files could not be loaded")
+	} else if removed, err := s.removeUndefinedProjects(definedSlugs); err != nil {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("failed to remove projects without a definition: %v", err))
+	} else {
+		summary.ProjectsRemoved = removed
+	}
+
+	s.Cache.InvalidateAll()
+
+	slog.Info("Mock definitions reconciled", "dir", s.Dir, "summary", summary.String())
+	for _, e := range summary.Errors {
+		slog.Warn("Mock definition error", "dir", s.Dir, "error", e)
+	}
+	return summary, nil
+}
+
+// removeUndefinedProjects soft-deletes the file-managed projects whose slug is not defined, together with
+// their URLs, mock contents, forward proxy and resources, in one transaction.
+func (s *MockDefinitionService) removeUndefinedProjects(definedSlugs []string) (int, error) {
+	removed := 0
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.Project{}).Where("managed_by_file = ?", true)
+		if len(definedSlugs) > 0 {
+			query = query.Where("slug NOT IN ?", definedSlugs)
+		}
+		var projectIDs []uint
+		if err := query.Pluck("id", &projectIDs).Error; err != nil {
+			return fmt.Errorf("failed to list projects: %w", err)
+		}
+		if len(projectIDs) == 0 {
+			return nil
+		}
+
+		urlIDs := tx.Model(&models.Url{}).Select("id").Where("project_id IN ?", projectIDs)
+		if err := tx.Where("url_id IN (?)", urlIDs).Delete(&models.MockContent{}).Error; err != nil {
+			return fmt.Errorf("failed to delete mock contents: %w", err)
+		}
+		if err := tx.Where("project_id IN ?", projectIDs).Delete(&models.Url{}).Error; err != nil {
+			return fmt.Errorf("failed to delete urls: %w", err)
+		}
+		if err := tx.Where("project_id IN ?", projectIDs).Delete(&models.ForwardProxy{}).Error; err != nil {
+			return fmt.Errorf("failed to delete forward proxies: %w", err)
+		}
+		if err := tx.Where("project_id IN ?", projectIDs).Delete(&models.Resource{}).Error; err != nil {
+			return fmt.Errorf("failed to delete resources: %w", err)
+		}
+		result := tx.Delete(&models.Project{}, projectIDs)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete projects: %w", result.Error)
+		}
+		removed = int(result.RowsAffected)
+		return nil
+	})
+	return removed, err
+}
+
+// purgeDeletedProjectContents removes the soft-deleted URLs, mock contents, forward proxy and resources of a
+// project for good. They still hold their unique paths, so a restored project could not recreate them otherwise.
+func purgeDeletedProjectContents(tx *gorm.DB, projectID uint) error {
+	urlIDs := tx.Unscoped().Model(&models.Url{}).Select("id").Where("project_id = ? AND deleted_at IS NOT NULL", projectID)
+	if err := tx.Unscoped().Where("url_id IN (?) AND deleted_at IS NOT NULL", urlIDs).Delete(&models.MockContent{}).Error; err != nil {
+		return fmt.Errorf("failed to purge mock contents of project ID %d: %w", projectID, err)
+	}
+	for _, model := range []interface{}{&models.Url{}, &models.ForwardProxy{}, &models.Resource{}} {
+		if err := tx.Unscoped().Where("project_id = ? AND deleted_at IS NOT NULL", projectID).Delete(model).Error; err != nil {
+			return fmt.Errorf("failed to purge deleted contents of project ID %d: %w", projectID, err)
+		}
+	}
+	return nil
+}
+
+func (s *MockDefinitionService) reconcileProject(tx *gorm.DB, def dtos.MockDefinitionFileDTO, summary *ReconcileSummary) error {
+	teamID := uint(1) // Placeholder default team ID, matching ProjectController
+	if def.TeamID != nil {
+		teamID = *def.TeamID
+	}
+	name := def.Name
+	if name == "" {
+		name = utils.Unslug(def.Slug)
+	}
+
+	var project models.Project
+	err := tx.Unscoped().Where("slug = ?", def.Slug).First(&project).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return fmt.Errorf("failed to look up project '%s': %w", def.Slug, err)
+	}
+
+	rateLimit, rateLimitErr := mockDefinitionRateLimit(def.RateLimit)
+	if rateLimitErr != nil {
+		return rateLimitErr
+	}
+
+	created, projectChanged := false, false
+	if err == gorm.ErrRecordNotFound {
+		project = models.Project{
+			Name:          name,
+			Slug:          def.Slug,
+			Description:   def.Description,
+			TeamID:        teamID,
+			ManagedByFile: true,
+			RateLimit:     rateLimit,
+		}
+		if err := NewProjectService(tx).CreateProject(&project); err != nil {
+			return err
+		}
+		summary.ProjectsCreated++
+		created = true
+	} else {
+		if !project.ManagedByFile && !project.DeletedAt.Valid {
+			return fmt.Errorf("project '%s' already exists and is not managed by files", def.Slug)
+		}
+		if project.DeletedAt.Valid {
+			if err := purgeDeletedProjectContents(tx, project.ID); err != nil {
+				return err
+			}
+		}
+		if project.DeletedAt.Valid || !project.ManagedByFile || project.Name != name ||
+			project.Description != def.Description || project.TeamID != teamID || project.RateLimit != rateLimit {
+			project.DeletedAt = gorm.DeletedAt{}
+			project.ManagedByFile = true
+			project.Name = name
+			project.Description = def.Description
+			project.TeamID = teamID
+			project.RateLimit = rateLimit
+			projectChanged = true
+		}
+	}
+
+	proxyChanged, err := s.reconcileForwardProxy(tx, &project, def.ForwardProxy)
+	if err != nil {
+		return err
+	}
+	if projectChanged {
+		if err := tx.Unscoped().Save(&project).Error; err != nil {
+			return fmt.Errorf("failed to update project '%s': %w", def.Slug, err)
+		}
+	}
+
+	urlsChanged, err := s.reconcileURLs(tx, project.ID, def.URLs, summary)
+	if err != nil {
+		return err
+	}
+
+	resourcesChanged, err := s.reconcileResources(tx, project.ID, def.Resources)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case created:
+		// Already counted as created.
+	case projectChanged || proxyChanged || urlsChanged || resourcesChanged:
+		summary.ProjectsUpdated++
+	default:
+		summary.ProjectsUnchanged++
+	}
+	return nil
+}
+
+func (s *MockDefinitionService) reconcileForwardProxy(tx *gorm.DB, project *models.Project, def *dtos.MockDefinitionProxyDTO) (bool, error) {
+	var existing models.ForwardProxy
+	err := tx.Where("project_id = ?", project.ID).First(&existing).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, fmt.Errorf("failed to look up forward proxy for project '%s': %w", project.Slug, err)
+	}
+	found := err == nil
+
+	wantActive := def != nil && def.Active
+	changed := project.IsForwardProxyActive != wantActive
+	project.IsForwardProxyActive = wantActive
+
+	switch {
+	case def == nil || def.Domain == "":
+		if found {
+			if err := tx.Delete(&existing).Error; err != nil {
+				return false, fmt.Errorf("failed to delete forward proxy for project '%s': %w", project.Slug, err)
+			}
+			changed = true
+		}
+	case !found:
+		if err := tx.Create(&models.ForwardProxy{ProjectID: project.ID, Domain: def.Domain}).Error; err != nil {
+			return false, fmt.Errorf("failed to create forward proxy for project '%s': %w", project.Slug, err)
+		}
+		changed = true
+	case existing.Domain != def.Domain:
+		existing.Domain = def.Domain
+		if err := tx.Save(&existing).Error; err != nil {
+			return false, fmt.Errorf("failed to update forward proxy for project '%s': %w", project.Slug, err)
+		}
+		changed = true
+	}
+
+	if changed {
+		if err := tx.Model(&models.Project{}).Where("id = ?", project.ID).
+			Update("is_forward_proxy_active", wantActive).Error; err != nil {
+			return false, fmt.Errorf("failed to update forward proxy status for project '%s': %w", project.Slug, err)
+		}
+	}
+	return changed, nil
+}
+
+func (s *MockDefinitionService) reconcileURLs(tx *gorm.DB, projectID uint, defs []dtos.MockDefinitionURLDTO, summary *ReconcileSummary) (bool, error) {
+	var existingURLs []models.Url
+	if err := tx.Where("project_id = ?", projectID).Preload("MockContents").Find(&existingURLs).Error; err != nil {
+		return false, fmt.Errorf("failed to load urls for project ID %d: %w", projectID, err)
+	}
+	existingByPath := make(map[string]*models.Url, len(existingURLs))
+	for i := range existingURLs {
+		existingByPath[existingURLs[i].URL] = &existingURLs[i]
+	}
+
+	changed := false
+	for _, def := range defs {
+		contents, err := mockDefinitionContents(def)
+		if err != nil {
+			return false, fmt.Errorf("url '%s': %w", def.URL, err)
+		}
+
+		rateLimit, err := mockDefinitionRateLimit(def.RateLimit)
+		if err != nil {
+			return false, fmt.Errorf("url '%s': %w", def.URL, err)
+		}
+
+		existing, found := existingByPath[def.URL]
+		delete(existingByPath, def.URL)
+
+		if !found {
+			newURL := &models.Url{Name: def.Name, Description: def.Description, URL: def.URL, Status: def.Status,
+				ResponseMode: def.ResponseMode, SequenceEnd: def.SequenceEnd, SequencePerClient: def.SequencePerClient,
+				RateLimit: rateLimit}
+			if err := NewURLService(tx, nil).CreateURL(newURL, projectID); err != nil {
+				return false, err
+			}
+			if _, err := NewMockContentService(tx).SaveMockContentList(contents, newURL.ID); err != nil {
+				return false, err
+			}
+			summary.URLsCreated++
+			changed = true
+			continue
+		}
+
+		urlChanged := existing.Name != def.Name || existing.Description != def.Description || existing.Status != def.Status ||
+			existing.ResponseMode != def.ResponseMode || existing.SequenceEnd != def.SequenceEnd ||
+			existing.SequencePerClient != def.SequencePerClient || existing.RateLimit != rateLimit
+		if urlChanged {
+			existing.Name = def.Name
+			existing.Description = def.Description
+			existing.Status = def.Status
+			existing.ResponseMode = def.ResponseMode
+			existing.SequenceEnd = def.SequenceEnd
+			existing.SequencePerClient = def.SequencePerClient
+			existing.RateLimit = rateLimit
+			if err := tx.Omit("MockContents").Save(existing).Error; err != nil {
+				return false, fmt.Errorf("failed to update url '%s': %w", def.URL, err)
+			}
+		}
+		if !sameMockContents(existing.MockContents, contents) {
+			if err := replaceMockContents(tx, existing.ID, contents); err != nil {
+				return false, err
+			}
+			urlChanged = true
+		}
+		if urlChanged {
+			summary.URLsUpdated++
+			changed = true
+		}
+	}
+
+	// Removed URLs are deleted for good, as soft-deleted rows would still hold their path in idx_url_project
+	// and the path could not be defined again.
+	for _, stale := range existingByPath {
+		if err := tx.Unscoped().Where("url_id = ?", stale.ID).Delete(&models.MockContent{}).Error; err != nil {
+			return false, fmt.Errorf("failed to delete mock contents for url '%s': %w", stale.URL, err)
+		}
+		if err := tx.Unscoped().Delete(&models.Url{}, stale.ID).Error; err != nil {
+			return false, fmt.Errorf("failed to delete url '%s': %w", stale.URL, err)
+		}
+		summary.URLsDeleted++
+		changed = true
+	}
+	return changed, nil
+}
+
+// reconcileResources makes the project's resources match the definition, keyed by path.
+func (s *MockDefinitionService) reconcileResources(tx *gorm.DB, projectID uint, defs []dtos.MockDefinitionResourceDTO) (bool, error) {
+	var existingResources []models.Resource
+	if err := tx.Where("project_id = ?", projectID).Find(&existingResources).Error; err != nil {
+		return false, fmt.Errorf("failed to load resources for project ID %d: %w", projectID, err)
+	}
+	existingByPath := make(map[string]*models.Resource, len(existingResources))
+	for i := range existingResources {
+		existingByPath[existingResources[i].Path] = &existingResources[i]
+	}
+
+	resourceService := NewResourceService(tx, nil)
+	changed := false
+	for _, def := range defs {
+		seed := "[]"
+		if def.Data != nil {
+			encoded, err := json.Marshal(def.Data)
+			if err != nil {
+				return false, fmt.Errorf("resource '%s': failed to encode data as JSON: %w", def.Path, err)
+			}
+			seed = string(encoded)
+		}
+
+		existing, found := existingByPath[def.Path]
+		delete(existingByPath, def.Path)
+
+		if !found {
+			resource := &models.Resource{Path: def.Path, Name: def.Name, IDField: def.IDField, SeedData: seed}
+			if err := resourceService.CreateResource(resource, projectID); err != nil {
+				return false, err
+			}
+			changed = true
+			continue
+		}
+		if existing.Name != def.Name || existing.IDField != def.IDField || existing.SeedData != seed {
+			existing.Name = def.Name
+			existing.IDField = def.IDField
+			existing.SeedData = seed
+			if err := resourceService.UpdateResource(existing); err != nil {
+				return false, err
+			}
+			changed = true
+		}
+	}
+
+	// Stored collections of removed resources expire on their own, as there is no Redis client here.
+	// Like URLs, removed resources are deleted for good so their path can be defined again.
+	for _, stale := range existingByPath {
+		if err := tx.Unscoped().Delete(&models.Resource{}, stale.ID).Error; err != nil {
+			return false, fmt.Errorf("failed to delete resource '%s': %w", stale.Path, err)
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// replaceMockContents swaps the variants of a URL inside the caller's transaction.
+func replaceMockContents(tx *gorm.DB, urlID uint, contents []models.MockContent) error {
+	if err := tx.Where("url_id = ?", urlID).Delete(&models.MockContent{}).Error; err != nil {
+		return fmt.Errorf("failed to delete existing mock contents for url ID %d: %w", urlID, err)
+	}
+	_, err := NewMockContentService(tx).SaveMockContentList(contents, urlID)
+	return err
+}
+
+func mockDefinitionContents(def dtos.MockDefinitionURLDTO) ([]models.MockContent, error) {
+	contents := make([]models.MockContent, 0, len(def.MockContents))
+	for _, mc := range def.MockContents {
+		data, err := mockDefinitionData(mc.Data)
+		if err != nil {
+			return nil, fmt.Errorf("mock content '%s': %w", mc.Name, err)
+		}
+		matcher, err := mockDefinitionMatcher(mc.Matcher)
+		if err != nil {
+			return nil, fmt.Errorf("mock content '%s': %w", mc.Name, err)
+		}
+		contents = append(contents, models.MockContent{
+			Name:          mc.Name,
+			Description:   mc.Description,
+			Data:          data,
+			Randomness:    mc.Randomness,
+			Latency:       mc.Latency,
+			Status:        mc.Status,
+			ScenarioName:  mc.ScenarioName,
+			RequiredState: mc.RequiredState,
+			NewState:      mc.NewState,
+			Matcher:       matcher,
+		})
+	}
+	return contents, nil
+}
+
+// sameMockContents compares the variants of a URL by their user-defined fields, in order.
+func sameMockContents(existing, desired []models.MockContent) bool {
+	if len(existing) != len(desired) {
+		return false
+	}
+	sorted := make([]models.MockContent, len(existing))
+	copy(sorted, existing)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	for i := range sorted {
+		a, b := sorted[i], desired[i]
+		if a.Name != b.Name || a.Description != b.Description || a.Data != b.Data ||
+			a.Randomness != b.Randomness || a.Latency != b.Latency || a.Status != b.Status ||
+			a.ScenarioName != b.ScenarioName || a.RequiredState != b.RequiredState || a.NewState != b.NewState ||
+			!reflect.DeepEqual(a.Matcher, b.Matcher) {
+			return false
+		}
+	}
+	return true
+}
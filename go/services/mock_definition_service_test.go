@@ -0,0 +1,323 @@
+package services_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mockapi/database"
+	"mockapi/models"
+	"mockapi/services"
+)
+
+func writeDefinitionFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestParseMockDefinitionFile(t *testing.T) {
+	t.Run("yaml_with_defaults_and_structured_data", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeDefinitionFile(t, dir, "shop.yaml", `
+slug: Shop
+forward_proxy:
+  domain: api.example.com
+  active: true
+urls:
+  - url: cart
+    mock_contents:
+      - data:
+          items: []
+        latency: 50
+  - url: /orders
+    status: created
+    mock_contents:
+      - name: plain
+        data: "not json"
+`)
+
+		def, err := services.ParseMockDefinitionFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "shop", def.Slug)
+		require.NotNil(t, def.ForwardProxy)
+		assert.True(t, def.ForwardProxy.Active)
+		require.Len(t, def.URLs, 2)
+
+		cart := def.URLs[0]
+		assert.Equal(t, "/cart", cart.URL)
+		assert.Equal(t, "/cart", cart.Name)
+		assert.Equal(t, models.StatusOK, cart.Status)
+		require.Len(t, cart.MockContents, 1)
+		assert.Equal(t, "/cart #1", cart.MockContents[0].Name)
+		assert.Equal(t, int64(50), cart.MockContents[0].Latency)
+
+		assert.Equal(t, models.StatusCreated, def.URLs[1].Status)
+	})
+
+	t.Run("json_file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeDefinitionFile(t, dir, "billing.json", `{"slug":"billing","urls":[{"url":"/invoices","mock_contents":[{"name":"list","data":[1,2]}]}]}`)
+
+		def, err := services.ParseMockDefinitionFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "billing", def.Slug)
+		require.Len(t, def.URLs, 1)
+		assert.Equal(t, "list", def.URLs[0].MockContents[0].Name)
+	})
+
+	t.Run("resources", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeDefinitionFile(t, dir, "todo.yaml", `
+slug: todo
+resources:
+  - path: todos/
+    data:
+      - id: 1
+        title: Buy milk
+`)
+
+		def, err := services.ParseMockDefinitionFile(path)
+		require.NoError(t, err)
+		require.Len(t, def.Resources, 1)
+		assert.Equal(t, "/todos", def.Resources[0].Path)
+		assert.Equal(t, "/todos", def.Resources[0].Name)
+		assert.Equal(t, services.DefaultResourceIDField, def.Resources[0].IDField)
+		assert.Len(t, def.Resources[0].Data, 1)
+	})
+
+	t.Run("missing_slug", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeDefinitionFile(t, dir, "broken.yaml", "name: No Slug\n")
+
+		_, err := services.ParseMockDefinitionFile(path)
+		assert.ErrorContains(t, err, "missing a project slug")
+	})
+
+	t.Run("duplicate_url", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeDefinitionFile(t, dir, "dup.yaml", "slug: dup\nurls:\n  - url: /a\n  - url: a\n")
+
+		_, err := services.ParseMockDefinitionFile(path)
+		assert.ErrorContains(t, err, "defined more than once")
+	})
+}
+
+func TestLoadMockDefinitions(t *testing.T) {
+	t.Run("skips_other_files", func(t *testing.T) {
+		dir := t.TempDir()
+		writeDefinitionFile(t, dir, "a.yml", "slug: a\n")
+		writeDefinitionFile(t, dir, "b.json", `{"slug":"b"}`)
+		writeDefinitionFile(t, dir, "README.md", "# not a definition")
+
+		defs, fileErrs, err := services.LoadMockDefinitions(dir)
+		require.NoError(t, err)
+		assert.Empty(t, fileErrs)
+		assert.Len(t, defs, 2)
+	})
+
+	t.Run("duplicate_slug_across_files", func(t *testing.T) {
+		dir := t.TempDir()
+		writeDefinitionFile(t, dir, "a.yaml", "slug: same\n")
+		writeDefinitionFile(t, dir, "b.yaml", "slug: same\n")
+
+		defs, fileErrs, err := services.LoadMockDefinitions(dir)
+		require.NoError(t, err)
+		require.Len(t, fileErrs, 1)
+		assert.ErrorContains(t, fileErrs[0], "is defined in both")
+		assert.Contains(t, defs, filepath.Join(dir, "a.yaml"))
+		assert.Len(t, defs, 1)
+	})
+
+	t.Run("broken_file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeDefinitionFile(t, dir, "a.yaml", "slug: a\n")
+		writeDefinitionFile(t, dir, "b.yaml", "slug: [unterminated\n")
+
+		defs, fileErrs, err := services.LoadMockDefinitions(dir)
+		require.NoError(t, err)
+		require.Len(t, fileErrs, 1)
+		assert.ErrorContains(t, fileErrs[0], "b.yaml")
+		assert.Len(t, defs, 1, "the valid file is still loaded")
+	})
+}
+
+func TestMockDefinitionService_AppliesValidFilesDespiteBrokenOnes(t *testing.T) {
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.Team{Name: "Team", Slug: "team"}).Error) // Definitions default to team 1
+	dir := t.TempDir()
+	writeDefinitionFile(t, dir, "billing.yaml", "slug: billing\n")
+	service := services.NewMockDefinitionService(db, dir)
+	_, err = service.Reconcile()
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "billing.yaml")))
+	writeDefinitionFile(t, dir, "billing.yaml", "slug: billing\nurls: [unterminated\n")
+	writeDefinitionFile(t, dir, "shop.yaml", "slug: shop\nurls:\n  - url: /cart\n")
+	summary, err := service.Reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.Files)
+	assert.Equal(t, 1, summary.ProjectsCreated, "the valid file is applied")
+	assert.Equal(t, 0, summary.ProjectsRemoved, "a broken file does not remove its project")
+	require.Len(t, summary.Errors, 2)
+	assert.Contains(t, summary.Errors[0], "billing.yaml")
+
+	var projects int64
+	require.NoError(t, db.Model(&models.Project{}).Where("slug IN ?", []string{"billing", "shop"}).Count(&projects).Error)
+	assert.Equal(t, int64(2), projects)
+}
+
+func TestMockDefinitionService_RemovesAndRestoresProjects(t *testing.T) {
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.Team{Name: "Team", Slug: "team"}).Error) // Definitions default to team 1
+	dir := t.TempDir()
+	path := writeDefinitionFile(t, dir, "shop.yaml", `
+slug: shop
+forward_proxy:
+  domain: api.example.com
+urls:
+  - url: /cart
+    mock_contents:
+      - data: {"items": []}
+      - data: {"items": [1]}
+resources:
+  - path: /todos
+`)
+	service := services.NewMockDefinitionService(db, dir)
+
+	summary, err := service.Reconcile()
+	require.NoError(t, err)
+	require.Empty(t, summary.Errors)
+	var project models.Project
+	require.NoError(t, db.Where("slug = ?", "shop").First(&project).Error)
+
+	count := func(model interface{}, query string) int64 {
+		var n int64
+		require.NoError(t, db.Model(model).Where(query, project.ID).Count(&n).Error)
+		return n
+	}
+	urlsOfProject := "url_id IN (SELECT id FROM urls WHERE project_id = ?)"
+	assert.Equal(t, int64(1), count(&models.Url{}, "project_id = ?"))
+	assert.Equal(t, int64(2), count(&models.MockContent{}, urlsOfProject))
+
+	require.NoError(t, os.Remove(path))
+	summary, err = service.Reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.ProjectsRemoved)
+	assert.Equal(t, int64(0), count(&models.Project{}, "id = ?"))
+	assert.Equal(t, int64(0), count(&models.Url{}, "project_id = ?"), "urls are removed with their project")
+	assert.Equal(t, int64(0), count(&models.MockContent{}, urlsOfProject), "mock contents are removed with their project")
+	assert.Equal(t, int64(0), count(&models.ForwardProxy{}, "project_id = ?"))
+	assert.Equal(t, int64(0), count(&models.Resource{}, "project_id = ?"))
+
+	writeDefinitionFile(t, dir, "shop.yaml", `
+slug: shop
+forward_proxy:
+  domain: api.example.com
+urls:
+  - url: /cart
+    mock_contents:
+      - data: {"items": []}
+resources:
+  - path: /todos
+`)
+	summary, err = service.Reconcile()
+	require.NoError(t, err)
+	require.Empty(t, summary.Errors, "a restored project recreates its contents")
+	assert.Equal(t, int64(1), count(&models.Project{}, "id = ?"))
+	assert.Equal(t, int64(1), count(&models.Url{}, "project_id = ?"))
+	assert.Equal(t, int64(1), count(&models.MockContent{}, urlsOfProject))
+	assert.Equal(t, int64(1), count(&models.ForwardProxy{}, "project_id = ?"))
+	assert.Equal(t, int64(1), count(&models.Resource{}, "project_id = ?"))
+}
+
+func TestMockDefinitionService_ReaddsRemovedPaths(t *testing.T) {
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.Team{Name: "Team", Slug: "team"}).Error) // Definitions default to team 1
+	dir := t.TempDir()
+	withPaths := `
+slug: shop
+urls:
+  - url: /cart
+    mock_contents:
+      - data: {"items": []}
+  - url: /orders
+resources:
+  - path: /todos
+`
+	withoutPaths := `
+slug: shop
+urls:
+  - url: /cart
+    mock_contents:
+      - data: {"items": []}
+`
+	service := services.NewMockDefinitionService(db, dir)
+	for i, content := range []string{withPaths, withoutPaths, withPaths} {
+		writeDefinitionFile(t, dir, "shop.yaml", content)
+		summary, err := service.Reconcile()
+		require.NoError(t, err)
+		require.Empty(t, summary.Errors, "reconcile #%d", i+1)
+	}
+
+	var project models.Project
+	require.NoError(t, db.Where("slug = ?", "shop").First(&project).Error)
+	var urls, resources int64
+	require.NoError(t, db.Unscoped().Model(&models.Url{}).Where("project_id = ?", project.ID).Count(&urls).Error)
+	require.NoError(t, db.Unscoped().Model(&models.Resource{}).Where("project_id = ?", project.ID).Count(&resources).Error)
+	assert.Equal(t, int64(2), urls, "removed urls are not kept as soft-deleted rows")
+	assert.Equal(t, int64(1), resources)
+}
+
+func TestMockDefinitionService_Matchers(t *testing.T) {
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.Team{Name: "Team", Slug: "team"}).Error) // Definitions default to team 1
+	dir := t.TempDir()
+	writeDefinitionFile(t, dir, "shop.yaml", `
+slug: shop
+urls:
+  - url: /orders
+    mock_contents:
+      - name: list
+        data: []
+      - name: created
+        status: created
+        matcher:
+          method: post
+          headers: { X-Tenant: acme }
+`)
+	service := services.NewMockDefinitionService(db, dir)
+	_, err = service.Reconcile()
+	require.NoError(t, err)
+
+	var created models.MockContent
+	require.NoError(t, db.Where("name = ?", "created").First(&created).Error)
+	assert.Equal(t, &models.RequestMatcher{Method: "POST", Headers: map[string]string{"X-Tenant": "acme"}}, created.Matcher)
+	var list models.MockContent
+	require.NoError(t, db.Where("name = ?", "list").First(&list).Error)
+	assert.Nil(t, list.Matcher)
+
+	summary, err := service.Reconcile()
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.ProjectsUnchanged, "stored matchers compare equal to the file")
+
+	writeDefinitionFile(t, dir, "shop.yaml", `
+slug: shop
+urls:
+  - url: /orders
+    mock_contents:
+      - matcher: { method: FETCH }
+`)
+	summary, err = service.Reconcile()
+	require.NoError(t, err)
+	require.NotEmpty(t, summary.Errors)
+	assert.Contains(t, summary.Errors[0], "unknown method 'FETCH'")
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// runPeriodically calls task on every interval in a background goroutine until the returned stop
+// function is called. Stop waits for a running task to finish.
+func runPeriodically(interval time.Duration, task func()) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				task()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-stopped
+	}
+}
@@ -1,8 +1,13 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"mockapi/dtos"
 	"mockapi/models" // Assuming module name is mockapi
 	"mockapi/utils"  // For GenerateRandomString or similar if needed for ChannelID
 
@@ -12,6 +17,30 @@ import (
 // ProjectService handles business logic related to projects.
 type ProjectService struct {
 	DB *gorm.DB
+	// ReadOnlyManaged makes projects defined in mock definition files read-only through the API.
+	ReadOnlyManaged bool
+	// Cache serves project lookups of mock requests when set.
+	Cache *MockCache
+	// FreeProjectTTL is how long anonymous free projects live before the cleanup job removes them; 0 keeps them forever.
+	FreeProjectTTL time.Duration
+}
+
+// ErrFreeProjectsDoNotExpire is returned when extending a project while FreeProjectTTL is not configured.
+var ErrFreeProjectsDoNotExpire = errors.New("free projects do not expire")
+
+// ErrProjectDoesNotExpire is returned when extending or claiming a project that is not an expiring free project.
+var ErrProjectDoesNotExpire = errors.New("project does not expire")
+
+// ErrProjectSlugTaken is returned when a claim would put two projects with the same slug into one team.
+var ErrProjectSlugTaken = errors.New("the team already has a project with this slug")
+
+// ExpiredProjectsSummary reports what a cleanup of expired projects removed.
+type ExpiredProjectsSummary struct {
+	Projects     int   `json:"projects"`
+	URLs         int64 `json:"urls"`
+	MockContents int64 `json:"mock_contents"`
+	RequestLogs  int64 `json:"request_logs"`
+	DurationMs   int64 `json:"duration_ms"`
 }
 
 // NewProjectService creates a new ProjectService.
@@ -44,9 +73,16 @@ func (s *ProjectService) GetProjectBySlug(slug string) (*models.Project, error)
 }
 
 // GetProjectByTeamSlugAndProjectSlug retrieves a project by its team's slug and its own slug.
-func (s *ProjectService) GetProjectByTeamSlugAndProjectSlug(teamSlug, projectSlug string) (*models.Project, error) {
+// The context is passed on to the database query, so it is traced as part of the request.
+func (s *ProjectService) GetProjectByTeamSlugAndProjectSlug(ctx context.Context, teamSlug, projectSlug string) (*models.Project, error) {
+	return s.Cache.Project(ctx, teamSlug, projectSlug, func() (*models.Project, error) {
+		return s.loadProjectByTeamSlugAndProjectSlug(ctx, teamSlug, projectSlug)
+	})
+}
+
+func (s *ProjectService) loadProjectByTeamSlugAndProjectSlug(ctx context.Context, teamSlug, projectSlug string) (*models.Project, error) {
 	var project models.Project
-	err := s.DB.Joins("JOIN teams ON teams.id = projects.team_id").
+	err := s.DB.WithContext(ctx).Joins("JOIN teams ON teams.id = projects.team_id").
 		Where("teams.slug = ? AND projects.slug = ?", teamSlug, projectSlug).
 		First(&project).Error
 	if err != nil {
@@ -85,6 +121,166 @@ func (s *ProjectService) UpdateProject(project *models.Project) error {
 	return nil
 }
 
+// CloneProject deep-copies a project, its forward proxy settings, URLs and mock contents
+// into a new project with the given slug and name, all within a single transaction.
+// Request statistics are not copied, and the clone is never managed by mock definition files.
+// A clone of an expiring free project expires at the same time.
+func (s *ProjectService) CloneProject(sourceID uint, slug, name string) (*models.Project, error) {
+	var clone models.Project
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var source models.Project
+		if err := tx.Preload("ForwardProxy").First(&source, sourceID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("project with ID %d not found: %w", sourceID, err)
+			}
+			return fmt.Errorf("failed to retrieve project with ID %d: %w", sourceID, err)
+		}
+
+		var sourceURLs []models.Url
+		err := tx.Where("project_id = ?", sourceID).Order("id").
+			Preload("MockContents", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+			Find(&sourceURLs).Error
+		if err != nil {
+			return fmt.Errorf("failed to retrieve urls for project ID %d: %w", sourceID, err)
+		}
+
+		var sourceResources []models.Resource
+		if err := tx.Where("project_id = ?", sourceID).Find(&sourceResources).Error; err != nil {
+			return fmt.Errorf("failed to retrieve resources for project ID %d: %w", sourceID, err)
+		}
+
+		// A new channel ID is generated by CreateProject
+		clone = models.Project{
+			Name:                 name,
+			Slug:                 slug,
+			Description:          source.Description,
+			IsForwardProxyActive: source.IsForwardProxyActive,
+			TeamID:               source.TeamID,
+			RateLimit:            source.RateLimit,
+			LogRetention:         source.LogRetention,
+			ExpiresAt:            source.ExpiresAt,
+		}
+		if err := NewProjectService(tx).CreateProject(&clone); err != nil {
+			return err
+		}
+
+		if source.ForwardProxy != nil {
+			proxy := models.ForwardProxy{ProjectID: clone.ID, Domain: source.ForwardProxy.Domain}
+			if err := tx.Create(&proxy).Error; err != nil {
+				return fmt.Errorf("failed to clone forward proxy: %w", err)
+			}
+			clone.ForwardProxy = &proxy
+		}
+
+		for _, sourceURL := range sourceURLs {
+			newURL := models.Url{
+				Description:       sourceURL.Description,
+				Name:              sourceURL.Name,
+				URL:               sourceURL.URL,
+				Status:            sourceURL.Status,
+				ProjectID:         clone.ID,
+				ResponseMode:      sourceURL.ResponseMode,
+				SequenceEnd:       sourceURL.SequenceEnd,
+				SequencePerClient: sourceURL.SequencePerClient,
+				RateLimit:         sourceURL.RateLimit,
+				ResponseSchema:    sourceURL.ResponseSchema,
+				StrictJSON:        sourceURL.StrictJSON,
+			}
+			if err := tx.Create(&newURL).Error; err != nil {
+				return fmt.Errorf("failed to clone url '%s': %w", sourceURL.URL, err)
+			}
+
+			// Variants are created in their original order, so edge cases can point at their cloned source
+			cloneIDs := make(map[uint]uint, len(sourceURL.MockContents))
+			for _, mc := range sourceURL.MockContents {
+				content := models.MockContent{
+					Randomness:    mc.Randomness,
+					Latency:       mc.Latency,
+					Description:   mc.Description,
+					Name:          mc.Name,
+					Data:          mc.Data,
+					UrlID:         newURL.ID,
+					Status:        mc.Status,
+					ScenarioName:  mc.ScenarioName,
+					RequiredState: mc.RequiredState,
+					NewState:      mc.NewState,
+					Matcher:       mc.Matcher,
+					Origin:        mc.Origin,
+				}
+				if mc.SourceMockContentID != nil {
+					if cloned, ok := cloneIDs[*mc.SourceMockContentID]; ok {
+						content.SourceMockContentID = &cloned
+					}
+				}
+				if err := tx.Create(&content).Error; err != nil {
+					return fmt.Errorf("failed to clone mock contents for url '%s': %w", sourceURL.URL, err)
+				}
+				cloneIDs[mc.ID] = content.ID
+			}
+		}
+
+		// Resources start from their seed in the clone; the live collections are not copied.
+		for _, sourceResource := range sourceResources {
+			newResource := models.Resource{
+				Name:      sourceResource.Name,
+				Path:      sourceResource.Path,
+				IDField:   sourceResource.IDField,
+				SeedData:  sourceResource.SeedData,
+				ProjectID: clone.ID,
+			}
+			if err := tx.Create(&newResource).Error; err != nil {
+				return fmt.Errorf("failed to clone resource '%s': %w", sourceResource.Path, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone project with ID %d: %w", sourceID, err)
+	}
+	return &clone, nil
+}
+
+// IsReadOnly reports whether API writes to the given project must be rejected.
+func (s *ProjectService) IsReadOnly(project *models.Project) bool {
+	return s.ReadOnlyManaged && project != nil && project.ManagedByFile
+}
+
+// UpdateRateLimit applies the fields set in dto to the project's rate limit and saves it.
+func (s *ProjectService) UpdateRateLimit(project *models.Project, dto dtos.RateLimitDTO) error {
+	policy := project.RateLimit
+	if err := ApplyRateLimitDTO(&policy, dto); err != nil {
+		return err
+	}
+	err := s.DB.Model(&models.Project{}).Where("id = ?", project.ID).Updates(map[string]interface{}{
+		"rate_limit_limit":          policy.Limit,
+		"rate_limit_window_seconds": policy.WindowSeconds,
+		"rate_limit_key_by":         policy.KeyBy,
+		"rate_limit_key_header":     policy.KeyHeader,
+		"rate_limit_algorithm":      policy.Algorithm,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update rate limit of project with ID %d: %w", project.ID, err)
+	}
+	project.RateLimit = policy
+	s.Cache.InvalidateProject(project.ID)
+	return nil
+}
+
+// UpdateLogRetention replaces the project's request log retention overrides.
+func (s *ProjectService) UpdateLogRetention(project *models.Project, dto dtos.LogRetentionDTO) error {
+	policy := models.LogRetentionPolicy{MaxAgeHours: dto.MaxAgeHours, MaxRows: dto.MaxRows}
+	err := s.DB.Model(&models.Project{}).Where("id = ?", project.ID).Updates(map[string]interface{}{
+		"log_retention_max_age_hours": policy.MaxAgeHours,
+		"log_retention_max_rows":      policy.MaxRows,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update log retention of project with ID %d: %w", project.ID, err)
+	}
+	project.LogRetention = policy
+	s.Cache.InvalidateProject(project.ID)
+	return nil
+}
+
 // UpdateForwardProxyActiveStatus updates the IsForwardProxyActive status of a project.
 func (s *ProjectService) UpdateForwardProxyActiveStatus(projectID uint, status bool) error {
 	result := s.DB.Model(&models.Project{}).Where("id = ?", projectID).Update("is_forward_proxy_active", status)
@@ -94,5 +290,178 @@ func (s *ProjectService) UpdateForwardProxyActiveStatus(projectID uint, status b
 	if result.RowsAffected == 0 {
 		return fmt.Errorf("project with ID %d not found for updating forward proxy status: %w", projectID, gorm.ErrRecordNotFound)
 	}
+	s.Cache.InvalidateProject(projectID)
+	return nil
+}
+
+// FreeProjectExpiry returns the expiry of a free project created now, or nil when free projects do not expire.
+func (s *ProjectService) FreeProjectExpiry() *time.Time {
+	if s.FreeProjectTTL <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(s.FreeProjectTTL)
+	return &expiresAt
+}
+
+// AuthorizeProject returns ErrForbidden unless the caller may manage the project. Administrators and
+// members of the project's team may.
+func (s *ProjectService) AuthorizeProject(caller Caller, project *models.Project) error {
+	if caller.Admin {
+		return nil
+	}
+	if caller.TeamSlug != "" {
+		var team models.Team
+		err := s.DB.Select("id", "slug").First(&team, project.TeamID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to retrieve team of project with ID %d: %w", project.ID, err)
+		}
+		if err == nil && team.Slug == caller.TeamSlug {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: the caller may not manage project '%s'", ErrForbidden, project.Slug)
+}
+
+// ExtendProject restarts the lifetime of an expiring project, so it now expires FreeProjectTTL from now.
+// It fails with ErrFreeProjectsDoNotExpire when no TTL is configured, instead of making the project permanent.
+// Only callers that may manage the project can extend it.
+func (s *ProjectService) ExtendProject(project *models.Project, caller Caller) error {
+	if project.ExpiresAt == nil {
+		return fmt.Errorf("failed to extend project with ID %d: %w", project.ID, ErrProjectDoesNotExpire)
+	}
+	if err := s.AuthorizeProject(caller, project); err != nil {
+		return err
+	}
+	expiresAt := s.FreeProjectExpiry()
+	if expiresAt == nil {
+		return fmt.Errorf("failed to extend project with ID %d: %w", project.ID, ErrFreeProjectsDoNotExpire)
+	}
+	if err := s.DB.Model(&models.Project{}).Where("id = ?", project.ID).Update("expires_at", expiresAt).Error; err != nil {
+		return fmt.Errorf("failed to extend project with ID %d: %w", project.ID, err)
+	}
+	project.ExpiresAt = expiresAt
+	s.Cache.InvalidateProject(project.ID)
+	return nil
+}
+
+// ClaimProject moves an expiring project into the team with the given slug and removes its expiry.
+// The caller must belong to that team, or be an administrator, and the team must not have a project with
+// the same slug yet.
+func (s *ProjectService) ClaimProject(project *models.Project, teamSlug string, caller Caller) error {
+	if project.ExpiresAt == nil {
+		return fmt.Errorf("failed to claim project with ID %d: %w", project.ID, ErrProjectDoesNotExpire)
+	}
+	var team models.Team
+	if err := s.DB.Where("slug = ?", teamSlug).First(&team).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("team with slug '%s' not found: %w", teamSlug, err)
+		}
+		return fmt.Errorf("failed to retrieve team with slug '%s': %w", teamSlug, err)
+	}
+	if !caller.Admin && caller.TeamSlug != team.Slug {
+		return fmt.Errorf("%w: the caller does not belong to team '%s'", ErrForbidden, teamSlug)
+	}
+	var taken int64
+	err := s.DB.Model(&models.Project{}).Where("team_id = ? AND slug = ? AND id <> ?", team.ID, project.Slug, project.ID).Count(&taken).Error
+	if err != nil {
+		return fmt.Errorf("failed to check the projects of team '%s': %w", teamSlug, err)
+	}
+	if taken > 0 {
+		return fmt.Errorf("failed to claim project '%s' into team '%s': %w", project.Slug, teamSlug, ErrProjectSlugTaken)
+	}
+
+	// The cached entries are keyed by the old team's slug, so they are located before the move
+	cached := s.Cache.projectPattern(project.ID)
+	err = s.DB.Model(&models.Project{}).Where("id = ?", project.ID).Updates(map[string]interface{}{
+		"team_id":    team.ID,
+		"expires_at": nil,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to claim project with ID %d into team '%s': %w", project.ID, teamSlug, err)
+	}
+	s.Cache.invalidatePattern(cached)
+	project.TeamID = team.ID
+	project.Team = team
+	project.ExpiresAt = nil
 	return nil
 }
+
+// DeleteExpiredProjects soft-deletes the projects that expired by now together with their URLs, mock contents,
+// forward proxy and resources. Request logs have no soft delete and are removed for good.
+func (s *ProjectService) DeleteExpiredProjects(now time.Time) (*ExpiredProjectsSummary, error) {
+	start := time.Now()
+	summary := &ExpiredProjectsSummary{}
+
+	var projects []models.Project
+	if err := s.DB.Select("id", "slug").Where("expires_at IS NOT NULL AND expires_at <= ?", now).Find(&projects).Error; err != nil {
+		return nil, fmt.Errorf("failed to list expired projects: %w", err)
+	}
+
+	for _, project := range projects {
+		// The slug is renamed below, so the cached entries are located first and dropped once the delete commits
+		cached := s.Cache.projectPattern(project.ID)
+		err := s.DB.Transaction(func(tx *gorm.DB) error {
+			urlIDs := tx.Model(&models.Url{}).Select("id").Where("project_id = ?", project.ID)
+			result := tx.Where("url_id IN (?)", urlIDs).Delete(&models.MockContent{})
+			if result.Error != nil {
+				return fmt.Errorf("failed to delete mock contents: %w", result.Error)
+			}
+			mockContents := result.RowsAffected
+
+			result = tx.Where("project_id = ?", project.ID).Delete(&models.Url{})
+			if result.Error != nil {
+				return fmt.Errorf("failed to delete urls: %w", result.Error)
+			}
+			urls := result.RowsAffected
+
+			if err := tx.Where("project_id = ?", project.ID).Delete(&models.ForwardProxy{}).Error; err != nil {
+				return fmt.Errorf("failed to delete forward proxy: %w", err)
+			}
+			if err := tx.Where("project_id = ?", project.ID).Delete(&models.Resource{}).Error; err != nil {
+				return fmt.Errorf("failed to delete resources: %w", err)
+			}
+
+			result = tx.Where("project_id = ?", project.ID).Delete(&models.RequestLog{})
+			if result.Error != nil {
+				return fmt.Errorf("failed to delete request logs: %w", result.Error)
+			}
+			requestLogs := result.RowsAffected
+
+			// Soft-deleted rows keep their unique slug, so it is renamed to let a new project take it
+			err := tx.Model(&models.Project{}).Where("id = ?", project.ID).
+				Update("slug", fmt.Sprintf("%s-expired-%d", project.Slug, project.ID)).Error
+			if err != nil {
+				return fmt.Errorf("failed to release slug: %w", err)
+			}
+			if err := tx.Delete(&models.Project{}, project.ID).Error; err != nil {
+				return err
+			}
+
+			summary.Projects++
+			summary.URLs += urls
+			summary.MockContents += mockContents
+			summary.RequestLogs += requestLogs
+			return nil
+		})
+		if err != nil {
+			return summary, fmt.Errorf("failed to delete expired project '%s': %w", project.Slug, err)
+		}
+		s.Cache.invalidatePattern(cached)
+	}
+
+	summary.DurationMs = time.Since(start).Milliseconds()
+	return summary, nil
+}
+
+// StartExpiryJanitor deletes expired projects on every interval until the returned stop function is called.
+func (s *ProjectService) StartExpiryJanitor(interval time.Duration) (stop func()) {
+	return runPeriodically(interval, func() {
+		summary, err := s.DeleteExpiredProjects(time.Now())
+		if err != nil {
+			slog.Error("Expired project cleanup failed", "error", err)
+		} else if summary.Projects > 0 {
+			slog.Info("Expired project cleanup removed projects", "projects", summary.Projects, "urls", summary.URLs,
+				"mock_contents", summary.MockContents, "request_logs", summary.RequestLogs, "duration_ms", summary.DurationMs)
+		}
+	})
+}
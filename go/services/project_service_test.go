@@ -0,0 +1,182 @@
+package services_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"mockapi/database"
+	"mockapi/models"
+	"mockapi/services"
+)
+
+func TestDeleteExpiredProjects(t *testing.T) {
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	expiredAt, laterAt := now.Add(-time.Minute), now.Add(time.Hour)
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	expired := models.Project{Name: "Old", Slug: "old", ChannelID: "old", TeamID: team.ID, ExpiresAt: &expiredAt}
+	require.NoError(t, db.Create(&expired).Error)
+	active := models.Project{Name: "New", Slug: "new", ChannelID: "new", TeamID: team.ID, ExpiresAt: &laterAt}
+	require.NoError(t, db.Create(&active).Error)
+	permanent := models.Project{Name: "Kept", Slug: "kept", ChannelID: "kept", TeamID: team.ID}
+	require.NoError(t, db.Create(&permanent).Error)
+
+	for _, project := range []models.Project{expired, active} {
+		url := models.Url{Name: "Orders", URL: "/orders", Status: models.StatusOK, ProjectID: project.ID}
+		require.NoError(t, db.Create(&url).Error)
+		require.NoError(t, db.Create(&models.MockContent{Name: "ok", Data: "{}", UrlID: url.ID}).Error)
+		require.NoError(t, db.Create(&models.ForwardProxy{Domain: "https://example.com", ProjectID: project.ID}).Error)
+		require.NoError(t, db.Create(&models.RequestLog{ProjectID: project.ID, Method: "GET", URL: "/orders"}).Error)
+	}
+
+	service := services.NewProjectService(db)
+	summary, err := service.DeleteExpiredProjects(now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Projects)
+	assert.Equal(t, int64(1), summary.URLs)
+	assert.Equal(t, int64(1), summary.MockContents)
+	assert.Equal(t, int64(1), summary.RequestLogs)
+
+	var slugs []string
+	require.NoError(t, db.Model(&models.Project{}).Order("slug").Pluck("slug", &slugs).Error)
+	assert.Equal(t, []string{"kept", "new"}, slugs)
+	var urls, contents, proxies int64
+	require.NoError(t, db.Model(&models.Url{}).Count(&urls).Error)
+	require.NoError(t, db.Model(&models.MockContent{}).Count(&contents).Error)
+	require.NoError(t, db.Model(&models.ForwardProxy{}).Count(&proxies).Error)
+	assert.Equal(t, []int64{1, 1, 1}, []int64{urls, contents, proxies}, "only the expired project's rows are deleted")
+
+	// The deleted project is kept but releases its slug
+	var deleted models.Project
+	require.NoError(t, db.Unscoped().First(&deleted, expired.ID).Error)
+	assert.True(t, deleted.DeletedAt.Valid)
+	require.NoError(t, service.CreateProject(&models.Project{Name: "Old", Slug: "old", TeamID: team.ID}))
+
+	summary, err = service.DeleteExpiredProjects(now)
+	require.NoError(t, err)
+	assert.Zero(t, summary.Projects)
+}
+
+func TestExtendAndClaimProject(t *testing.T) {
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	free := models.Team{Name: "Free", Slug: "free"}
+	require.NoError(t, db.Create(&free).Error)
+	acme := models.Team{Name: "Acme", Slug: "acme"}
+	require.NoError(t, db.Create(&acme).Error)
+
+	store := services.NewMemoryStore()
+	t.Cleanup(store.Close)
+	cache := services.NewMockCache(db, store, time.Minute)
+	service := services.NewProjectService(db)
+	service.Cache = cache
+	soon := time.Now().Add(time.Minute)
+	project := models.Project{Name: "Shop", Slug: "shop", TeamID: free.ID, ExpiresAt: &soon}
+	require.NoError(t, service.CreateProject(&project))
+
+	member := services.Caller{UserID: "alice", TeamSlug: "free"}
+	outsider := services.Caller{UserID: "mallory", TeamSlug: "acme"}
+	assert.ErrorIs(t, service.ExtendProject(&project, member), services.ErrFreeProjectsDoNotExpire,
+		"without a TTL an extend would make the project permanent")
+	require.NotNil(t, project.ExpiresAt)
+	service.FreeProjectTTL = 24 * time.Hour
+	assert.ErrorIs(t, service.ExtendProject(&project, outsider), services.ErrForbidden)
+	assert.ErrorIs(t, service.ExtendProject(&project, services.Caller{UserID: "anonymous"}), services.ErrForbidden)
+	require.NoError(t, service.ExtendProject(&project, member))
+	require.NotNil(t, project.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), *project.ExpiresAt, time.Minute)
+
+	err = service.ClaimProject(&project, "missing", member)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	assert.ErrorIs(t, service.ClaimProject(&project, "acme", member), services.ErrForbidden,
+		"only members of the target team may claim into it")
+
+	loads := 0
+	load := func() (*models.Project, error) {
+		loads++
+		return &project, nil
+	}
+	_, err = cache.Project(context.Background(), "free", "shop", load)
+	require.NoError(t, err)
+	require.NoError(t, service.ClaimProject(&project, "acme", outsider))
+	_, err = cache.Project(context.Background(), "free", "shop", load)
+	require.NoError(t, err)
+	assert.Equal(t, 2, loads, "the entry under the old team's slug is dropped")
+	stored, err := service.GetProjectBySlug("shop")
+	require.NoError(t, err)
+	assert.Equal(t, acme.ID, stored.TeamID)
+	assert.Nil(t, stored.ExpiresAt, "claimed projects no longer expire")
+
+	assert.ErrorIs(t, service.ClaimProject(stored, "acme", outsider), services.ErrProjectDoesNotExpire)
+	assert.ErrorIs(t, service.ExtendProject(stored, outsider), services.ErrProjectDoesNotExpire)
+}
+
+func TestCloneProject(t *testing.T) {
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	source := models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: team.ID, ManagedByFile: true,
+		ExpiresAt: &expiresAt, RateLimit: models.RateLimitPolicy{Limit: 5, WindowSeconds: 60}}
+	require.NoError(t, db.Create(&source).Error)
+	require.NoError(t, db.Create(&models.ForwardProxy{Domain: "https://example.com", ProjectID: source.ID}).Error)
+	url := models.Url{Name: "Orders", URL: "/orders", Status: models.StatusOK, ProjectID: source.ID,
+		Requests: sql.NullInt64{Int64: 12, Valid: true}, StrictJSON: true}
+	require.NoError(t, db.Create(&url).Error)
+	original := models.MockContent{Name: "Orders", Data: `{"orders":[]}`, UrlID: url.ID, Randomness: 3}
+	require.NoError(t, db.Create(&original).Error)
+	edgeCase := models.MockContent{Name: "Nulls", Data: `{"orders":null}`, UrlID: url.ID,
+		Origin: models.MockContentOriginAIEdgeCase, SourceMockContentID: &original.ID}
+	require.NoError(t, db.Create(&edgeCase).Error)
+	deleted := models.Url{Name: "Old", URL: "/old", Status: models.StatusOK, ProjectID: source.ID}
+	require.NoError(t, db.Create(&deleted).Error)
+	require.NoError(t, db.Delete(&deleted).Error)
+
+	clone, err := services.NewProjectService(db).CloneProject(source.ID, "shop-copy", "Shop copy")
+	require.NoError(t, err)
+	assert.NotEqual(t, source.ID, clone.ID)
+	assert.Equal(t, "shop-copy", clone.Slug)
+	assert.NotEqual(t, source.ChannelID, clone.ChannelID)
+	assert.False(t, clone.ManagedByFile)
+	assert.Equal(t, source.RateLimit, clone.RateLimit)
+	require.NotNil(t, clone.ExpiresAt, "clones of free projects expire with them")
+	assert.True(t, expiresAt.Equal(*clone.ExpiresAt))
+	require.NotNil(t, clone.ForwardProxy)
+	assert.Equal(t, "https://example.com", clone.ForwardProxy.Domain)
+
+	var urls []models.Url
+	require.NoError(t, db.Where("project_id = ?", clone.ID).Preload("MockContents", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Find(&urls).Error)
+	require.Len(t, urls, 1, "deleted URLs are not cloned")
+	cloned := urls[0]
+	assert.NotEqual(t, url.ID, cloned.ID)
+	assert.Equal(t, "/orders", cloned.URL)
+	assert.True(t, cloned.StrictJSON)
+	assert.False(t, cloned.Requests.Valid, "request statistics are not copied")
+	require.Len(t, cloned.MockContents, 2)
+	assert.NotEqual(t, original.ID, cloned.MockContents[0].ID)
+	assert.Equal(t, original.Data, cloned.MockContents[0].Data)
+	assert.Equal(t, int64(3), cloned.MockContents[0].Randomness)
+	require.NotNil(t, cloned.MockContents[1].SourceMockContentID)
+	assert.Equal(t, cloned.MockContents[0].ID, *cloned.MockContents[1].SourceMockContentID, "edge cases point at the cloned source")
+
+	var sourceContents int64
+	require.NoError(t, db.Model(&models.MockContent{}).Where("url_id = ?", url.ID).Count(&sourceContents).Error)
+	assert.Equal(t, int64(2), sourceContents, "the source keeps its mock contents")
+
+	_, err = services.NewProjectService(db).CloneProject(9999, "nothing", "Nothing")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 
 	"gorm.io/gorm"
@@ -18,11 +19,11 @@ func NewProxyService(db *gorm.DB) *ProxyService {
 }
 
 // GetForwardProxyByProjectID retrieves the forward proxy settings for a given project ID.
-func (s *ProxyService) GetForwardProxyByProjectID(projectID uint) (*models.ForwardProxy, error) {
+func (s *ProxyService) GetForwardProxyByProjectID(ctx context.Context, projectID uint) (*models.ForwardProxy, error) {
 	var proxy models.ForwardProxy
 	// Assuming a project has one ForwardProxy. If it can exist or not, FirstOrInit or FirstOrCreate might be options
 	// or just First and check for ErrRecordNotFound.
-	if err := s.DB.Where("project_id = ?", projectID).First(&proxy).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Where("project_id = ?", projectID).First(&proxy).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			// It's okay for a project not to have a forward proxy configured.
 			return nil, nil // Or return a specific error like ErrForwardProxyNotConfigured
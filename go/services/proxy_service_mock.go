@@ -1,14 +1,19 @@
 package services
 
-import "mockapi/models"
+import (
+	"context"
+
+	"mockapi/models"
+)
 
 // MockProxyService is a manual mock for ProxyService.
 type MockProxyService struct {
 	GetForwardProxyByProjectIDFunc func(projectID uint) (*models.ForwardProxy, error)
+	CreateForwardProxyFunc         func(proxy *models.ForwardProxy, projectID uint) (*models.ForwardProxy, error)
 	// Add other methods used by MockContentController if any
 }
 
-func (m *MockProxyService) GetForwardProxyByProjectID(projectID uint) (*models.ForwardProxy, error) {
+func (m *MockProxyService) GetForwardProxyByProjectID(_ context.Context, projectID uint) (*models.ForwardProxy, error) {
 	if m.GetForwardProxyByProjectIDFunc != nil {
 		return m.GetForwardProxyByProjectIDFunc(projectID)
 	}
@@ -19,3 +24,10 @@ func (m *MockProxyService) GetForwardProxyByProjectID(projectID uint) (*models.F
 // GetMockedJSON uses: GetForwardProxyByProjectID
 // SaveMockContent and UpdateMockContent do not directly call ProxyService methods in the provided code.
 // The mock includes this. Add others if controller logic expands.
+
+func (m *MockProxyService) CreateForwardProxy(proxy *models.ForwardProxy, projectID uint) (*models.ForwardProxy, error) {
+	if m.CreateForwardProxyFunc != nil {
+		return m.CreateForwardProxyFunc(proxy, projectID)
+	}
+	panic("MockProxyService.CreateForwardProxyFunc is not set")
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"mockapi/dtos"
+	"mockapi/models"
+)
+
+// ErrInvalidRateLimit is returned when a rate limit policy is inconsistent.
+var ErrInvalidRateLimit = errors.New("invalid rate limit")
+
+// RateLimitResult describes the outcome of counting one request against a limit.
+type RateLimitResult struct {
+	Limited    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // Time until the window resets; the Retry-After value when Limited
+}
+
+// NewRateLimitResult builds a result from the number of requests counted in the current window.
+func NewRateLimitResult(limit int, count int64, resetAfter time.Duration) *RateLimitResult {
+	remaining := int64(limit) - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &RateLimitResult{
+		Limited:    count > int64(limit),
+		Limit:      limit,
+		Remaining:  int(remaining),
+		ResetAfter: resetAfter,
+	}
+}
+
+// MostRestrictiveRateLimit returns the result that should be reported to the client:
+// a limited result wins, otherwise the one with the fewest remaining requests. Nil results are ignored.
+func MostRestrictiveRateLimit(a, b *RateLimitResult) *RateLimitResult {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.Limited != b.Limited:
+		if a.Limited {
+			return a
+		}
+		return b
+	case b.Remaining < a.Remaining:
+		return b
+	}
+	return a
+}
+
+// RateLimitService enforces the per-project and per-URL rate limits configured through the API.
+type RateLimitService struct {
+	Store KeyValueStore
+}
+
+// NewRateLimitService creates a new RateLimitService.
+func NewRateLimitService(store KeyValueStore) *RateLimitService {
+	return &RateLimitService{Store: store}
+}
+
+// Check counts a request against a policy. scope ("project" or "url") and scopeID identify the
+// limited entity and clientKey the client, as chosen by the policy's KeyBy.
+// It returns nil when the policy is disabled.
+func (s *RateLimitService) Check(ctx context.Context, scope string, scopeID uint, policy models.RateLimitPolicy, clientKey string) (*RateLimitResult, error) {
+	if !policy.Enabled() {
+		return nil, nil
+	}
+	// The algorithm is part of the key because each algorithm stores a different Redis type.
+	key := s.Store.CreateRedisKey("ratelimit", scope, strconv.FormatUint(uint64(scopeID), 10), string(policy.Algorithm), clientKey)
+	result, err := s.Store.CheckRateLimit(ctx, policy.Algorithm, key, policy.Limit, time.Duration(policy.WindowSeconds)*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("error checking %s rate limit: %w", scope, err)
+	}
+	return result, nil
+}
+
+// ApplyRateLimitDTO copies the fields set in dto onto policy and validates the result.
+func ApplyRateLimitDTO(policy *models.RateLimitPolicy, dto dtos.RateLimitDTO) error {
+	if dto.Limit != nil {
+		policy.Limit = *dto.Limit
+	}
+	if dto.WindowSeconds != nil {
+		policy.WindowSeconds = *dto.WindowSeconds
+	}
+	if dto.KeyBy != nil {
+		policy.KeyBy = models.RateLimitKeyBy(*dto.KeyBy)
+	}
+	if dto.KeyHeader != nil {
+		policy.KeyHeader = *dto.KeyHeader
+	}
+	if dto.Algorithm != nil {
+		policy.Algorithm = models.RateLimitAlgorithm(*dto.Algorithm)
+	}
+	return ValidateRateLimitPolicy(policy)
+}
+
+// ValidateRateLimitPolicy fills in defaults and rejects inconsistent settings.
+func ValidateRateLimitPolicy(policy *models.RateLimitPolicy) error {
+	if policy.KeyBy == "" {
+		policy.KeyBy = models.RateLimitKeyByIP
+	}
+	if policy.WindowSeconds == 0 {
+		policy.WindowSeconds = 60
+	}
+	if policy.Algorithm == "" {
+		policy.Algorithm = models.RateLimitFixedWindow
+	}
+	switch {
+	case policy.Limit < 0:
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidRateLimit)
+	case policy.WindowSeconds < 0:
+		return fmt.Errorf("%w: window_seconds must be positive", ErrInvalidRateLimit)
+	case policy.KeyBy != models.RateLimitKeyByIP && policy.KeyBy != models.RateLimitKeyByHeader && policy.KeyBy != models.RateLimitKeyByToken:
+		return fmt.Errorf("%w: unknown key_by '%s'", ErrInvalidRateLimit, policy.KeyBy)
+	case policy.Algorithm != models.RateLimitFixedWindow && policy.Algorithm != models.RateLimitSlidingWindow && policy.Algorithm != models.RateLimitTokenBucket:
+		return fmt.Errorf("%w: unknown algorithm '%s'", ErrInvalidRateLimit, policy.Algorithm)
+	case policy.KeyBy == models.RateLimitKeyByHeader && policy.KeyHeader == "":
+		return fmt.Errorf("%w: key_header is required when key_by is 'header'", ErrInvalidRateLimit)
+	}
+	return nil
+}
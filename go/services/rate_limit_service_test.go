@@ -0,0 +1,61 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"mockapi/dtos"
+	"mockapi/models"
+	"mockapi/services"
+)
+
+func TestNewRateLimitResult(t *testing.T) {
+	within := services.NewRateLimitResult(3, 2, 10*time.Second)
+	assert.False(t, within.Limited)
+	assert.Equal(t, 1, within.Remaining)
+
+	atLimit := services.NewRateLimitResult(3, 3, time.Second)
+	assert.False(t, atLimit.Limited)
+	assert.Equal(t, 0, atLimit.Remaining)
+
+	over := services.NewRateLimitResult(3, 5, time.Second)
+	assert.True(t, over.Limited)
+	assert.Equal(t, 0, over.Remaining)
+}
+
+func TestMostRestrictiveRateLimit(t *testing.T) {
+	project := services.NewRateLimitResult(100, 10, time.Minute)
+	url := services.NewRateLimitResult(5, 4, time.Second)
+	limited := services.NewRateLimitResult(1000, 1001, time.Hour)
+
+	assert.Same(t, url, services.MostRestrictiveRateLimit(project, url))
+	assert.Same(t, limited, services.MostRestrictiveRateLimit(url, limited))
+	assert.Same(t, project, services.MostRestrictiveRateLimit(project, nil))
+	assert.Nil(t, services.MostRestrictiveRateLimit(nil, nil))
+}
+
+func TestApplyRateLimitDTO(t *testing.T) {
+	t.Run("fills_defaults", func(t *testing.T) {
+		var policy models.RateLimitPolicy
+		limit := 10
+		assert.NoError(t, services.ApplyRateLimitDTO(&policy, dtos.RateLimitDTO{Limit: &limit}))
+		assert.Equal(t, models.RateLimitPolicy{Limit: 10, WindowSeconds: 60, KeyBy: models.RateLimitKeyByIP, Algorithm: models.RateLimitFixedWindow}, policy)
+		assert.True(t, policy.Enabled())
+	})
+
+	t.Run("header_requires_key_header", func(t *testing.T) {
+		policy := models.RateLimitPolicy{Limit: 1, WindowSeconds: 1}
+		keyBy := "header"
+		err := services.ApplyRateLimitDTO(&policy, dtos.RateLimitDTO{KeyBy: &keyBy})
+		assert.ErrorIs(t, err, services.ErrInvalidRateLimit)
+	})
+
+	t.Run("zero_limit_disables", func(t *testing.T) {
+		policy := models.RateLimitPolicy{Limit: 5, WindowSeconds: 30, KeyBy: models.RateLimitKeyByIP}
+		limit := 0
+		assert.NoError(t, services.ApplyRateLimitDTO(&policy, dtos.RateLimitDTO{Limit: &limit}))
+		assert.False(t, policy.Enabled())
+	})
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"mockapi/models"
+)
+
+// The rate limit scripts run atomically in Redis, so a counter can never be left without an
+// expiry and concurrent requests cannot interleave between reading and updating the state.
+// Sliding window and token bucket read the clock with TIME so all app instances share one clock.
+
+// fixedWindowScript counts a request in the current window.
+// KEYS[1] = counter, ARGV[1] = window (ms). Returns {count, ms until the window resets}.
+var fixedWindowScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+// slidingWindowScript keeps a log of accepted requests in a sorted set scored by time.
+// KEYS[1] = log, ARGV[1] = limit, ARGV[2] = window (ms), ARGV[3] = unique member.
+// Returns {allowed, requests in the window, ms until the oldest request leaves the window}.
+var slidingWindowScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+local reset = window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+// tokenBucketScript refills a bucket of ARGV[1] tokens over ARGV[2] ms and takes one token.
+// KEYS[1] = bucket hash. Returns {allowed, tokens left, ms until the next token, ms until the bucket is full}.
+var tokenBucketScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local rate = capacity / window
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], window)
+local retry = 0
+if allowed == 0 then
+	retry = math.ceil((1 - tokens) / rate)
+end
+return {allowed, math.floor(tokens), retry, math.ceil((capacity - tokens) / rate)}
+`)
+
+// CheckRateLimit counts a request with the given algorithm. Each algorithm stores a different
+// Redis type, so callers should not share a key between algorithms.
+func (s *RedisService) CheckRateLimit(ctx context.Context, algorithm models.RateLimitAlgorithm, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	switch algorithm {
+	case models.RateLimitSlidingWindow:
+		return s.SlidingWindowRateLimit(ctx, key, limit, window)
+	case models.RateLimitTokenBucket:
+		return s.TokenBucketRateLimit(ctx, key, limit, window)
+	default:
+		return s.FixedWindowRateLimit(ctx, key, limit, window)
+	}
+}
+
+// FixedWindowRateLimit counts a request in a fixed window and reports the remaining quota.
+// Unlike RateLimit, the result carries what is needed for X-RateLimit-* response headers.
+func (s *RedisService) FixedWindowRateLimit(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	values, err := s.runRateLimitScript(ctx, fixedWindowScript, key, 2, window.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	return NewRateLimitResult(limit, values[0], time.Duration(values[1])*time.Millisecond), nil
+}
+
+// SlidingWindowRateLimit allows at most limit requests in any window-long period.
+// It avoids the bursts of up to twice the limit that a fixed window allows around its edges.
+func (s *RedisService) SlidingWindowRateLimit(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	member, err := rateLimitMember()
+	if err != nil {
+		return nil, err
+	}
+	values, err := s.runRateLimitScript(ctx, slidingWindowScript, key, 3, limit, window.Milliseconds(), member)
+	if err != nil {
+		return nil, err
+	}
+	return &RateLimitResult{
+		Limited:    values[0] == 0,
+		Limit:      limit,
+		Remaining:  int(max(int64(limit)-values[1], 0)),
+		ResetAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+// TokenBucketRateLimit allows bursts of up to limit requests and refills limit tokens per window.
+// ResetAfter is the wait for the next token when limited and the time until the bucket is full otherwise.
+func (s *RedisService) TokenBucketRateLimit(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	values, err := s.runRateLimitScript(ctx, tokenBucketScript, key, 4, limit, window.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	result := &RateLimitResult{
+		Limited:    values[0] == 0,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}
+	if result.Limited {
+		result.ResetAfter = time.Duration(values[2]) * time.Millisecond
+	}
+	return result, nil
+}
+
+func (s *RedisService) runRateLimitScript(ctx context.Context, script *redis.Script, key string, expected int, args ...interface{}) ([]int64, error) {
+	raw, err := script.Run(ctx, s.Client, []string{key}, args...).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run rate limit script: %w", err)
+	}
+	if len(raw) != expected {
+		return nil, fmt.Errorf("rate limit script returned %d values, expected %d", len(raw), expected)
+	}
+	values := make([]int64, len(raw))
+	for i, v := range raw {
+		n, ok := v.(int64)
+		if !ok {
+			return nil, fmt.Errorf("rate limit script returned unexpected value %v", v)
+		}
+		values[i] = n
+	}
+	return values, nil
+}
+
+// rateLimitMember returns a unique sorted set member for the sliding window log.
+func rateLimitMember() (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate rate limit entry: %w", err)
+	}
+	return strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + hex.EncodeToString(suffix), nil
+}
@@ -0,0 +1,123 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mockapi/models"
+	"mockapi/services"
+)
+
+// testClock drives a miniredis instance: the time seen by TIME and key expiry move together.
+type testClock struct {
+	mr  *miniredis.Miniredis
+	now time.Time
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.mr.SetTime(c.now)
+	c.mr.FastForward(d)
+}
+
+func newTestRedisService(t *testing.T) (*services.RedisService, *miniredis.Miniredis, *testClock) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	clock := &testClock{mr: mr, now: time.Unix(1700000000, 0)}
+	mr.SetTime(clock.now)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return services.NewRedisService(client), mr, clock
+}
+
+func TestFixedWindowRateLimit(t *testing.T) {
+	rs, mr, clock := newTestRedisService(t)
+
+	for i := 0; i < 3; i++ {
+		result, err := rs.FixedWindowRateLimit(context.Background(), "fw", 3, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, result.Limited)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+	result, err := rs.FixedWindowRateLimit(context.Background(), "fw", 3, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, result.Limited)
+	assert.Equal(t, time.Minute, result.ResetAfter)
+
+	// The counter always carries a TTL, so it cannot leak.
+	assert.Equal(t, time.Minute, mr.TTL("fw"))
+
+	clock.advance(time.Minute)
+	result, err = rs.FixedWindowRateLimit(context.Background(), "fw", 3, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, result.Limited)
+}
+
+func TestSlidingWindowRateLimit(t *testing.T) {
+	rs, _, clock := newTestRedisService(t)
+
+	// Two requests late in one minute and a third early in the next must not all pass,
+	// which is the edge burst a fixed window would allow.
+	for i := 0; i < 2; i++ {
+		result, err := rs.SlidingWindowRateLimit(context.Background(), "sw", 2, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, result.Limited)
+	}
+	clock.advance(10 * time.Second)
+	result, err := rs.SlidingWindowRateLimit(context.Background(), "sw", 2, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, result.Limited)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 50*time.Second, result.ResetAfter)
+
+	// Rejected requests are not logged, so the window frees up once the first requests age out.
+	clock.advance(50 * time.Second)
+	result, err = rs.SlidingWindowRateLimit(context.Background(), "sw", 2, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, result.Limited)
+	assert.Equal(t, 1, result.Remaining)
+}
+
+func TestTokenBucketRateLimit(t *testing.T) {
+	rs, _, clock := newTestRedisService(t)
+
+	// A full bucket allows a burst of the whole limit.
+	for i := 0; i < 4; i++ {
+		result, err := rs.TokenBucketRateLimit(context.Background(), "tb", 4, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, result.Limited)
+		assert.Equal(t, 3-i, result.Remaining)
+	}
+	result, err := rs.TokenBucketRateLimit(context.Background(), "tb", 4, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, result.Limited)
+	assert.Equal(t, 15*time.Second, result.ResetAfter) // One token every 15s
+
+	clock.advance(15 * time.Second)
+	result, err = rs.TokenBucketRateLimit(context.Background(), "tb", 4, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, result.Limited)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, time.Minute, result.ResetAfter)
+}
+
+func TestCheckRateLimitSelectsAlgorithm(t *testing.T) {
+	rs, mr, _ := newTestRedisService(t)
+
+	_, err := rs.CheckRateLimit(context.Background(), models.RateLimitSlidingWindow, "sliding", 1, time.Minute)
+	require.NoError(t, err)
+	_, err = rs.CheckRateLimit(context.Background(), models.RateLimitTokenBucket, "bucket", 1, time.Minute)
+	require.NoError(t, err)
+	_, err = rs.CheckRateLimit(context.Background(), "", "fixed", 1, time.Minute)
+	require.NoError(t, err)
+
+	assert.Equal(t, "zset", mr.Type("sliding"))
+	assert.Equal(t, "hash", mr.Type("bucket"))
+	assert.Equal(t, "string", mr.Type("fixed"))
+}
@@ -9,18 +9,15 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
-// RedisService handles operations with Redis.
+// RedisService handles operations with Redis. Every command runs with the caller's context, so it
+// is cancelled with the request and its span joins the request's trace.
 type RedisService struct {
 	Client *redis.Client
-	ctx    context.Context
 }
 
 // NewRedisService creates a new RedisService.
 func NewRedisService(client *redis.Client) *RedisService {
-	return &RedisService{
-		Client: client,
-		ctx:    context.Background(), // Or a more specific context if needed
-	}
+	return &RedisService{Client: client}
 }
 
 // CreateRedisKey generates a Redis key by joining parts with a colon.
@@ -30,32 +27,17 @@ func (s *RedisService) CreateRedisKey(parts ...string) string {
 
 // RateLimit implements a fixed window rate limiting algorithm.
 // It returns true if the request is rate-limited (i.e., exceeds the limit), false otherwise.
-func (s *RedisService) RateLimit(key string, limit int, windowSeconds int64) (bool, error) {
-	// Increment the counter for the key
-	count, err := s.Client.Incr(s.ctx, key).Result()
+func (s *RedisService) RateLimit(ctx context.Context, key string, limit int, windowSeconds int64) (bool, error) {
+	result, err := s.FixedWindowRateLimit(ctx, key, limit, time.Duration(windowSeconds)*time.Second)
 	if err != nil {
-		return true, fmt.Errorf("failed to increment rate limit counter: %w", err)
-	}
-
-	// If this is the first request in the window, set the expiration
-	if count == 1 {
-		if err := s.Client.Expire(s.ctx, key, time.Duration(windowSeconds)*time.Second).Err(); err != nil {
-			// If setting expire fails, it's safer to assume rate limited or handle cleanup
-			return true, fmt.Errorf("failed to set expiration for rate limit key: %w", err)
-		}
-	}
-
-	// Check if the count exceeds the limit
-	if count > int64(limit) {
-		return true, nil // Rate limited
+		return true, err // Fail closed
 	}
-
-	return false, nil // Not rate limited
+	return result.Limited, nil
 }
 
 // GetValue retrieves a value from Redis.
-func (s *RedisService) GetValue(key string) (string, error) {
-	val, err := s.Client.Get(s.ctx, key).Result()
+func (s *RedisService) GetValue(ctx context.Context, key string) (string, error) {
+	val, err := s.Client.Get(ctx, key).Result()
 	if err == redis.Nil {
 		return "", nil // Key does not exist
 	} else if err != nil {
@@ -66,8 +48,8 @@ func (s *RedisService) GetValue(key string) (string, error) {
 
 // SetValue sets a value in Redis with an optional expiration.
 // If expiration is 0, the key does not expire.
-func (s *RedisService) SetValue(key string, value interface{}, expiration time.Duration) error {
-	err := s.Client.Set(s.ctx, key, value, expiration).Err()
+func (s *RedisService) SetValue(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	err := s.Client.Set(ctx, key, value, expiration).Err()
 	if err != nil {
 		return fmt.Errorf("failed to set value in redis: %w", err)
 	}
@@ -75,10 +57,119 @@ func (s *RedisService) SetValue(key string, value interface{}, expiration time.D
 }
 
 // DeleteValue deletes a key from Redis.
-func (s *RedisService) DeleteValue(key string) error {
-	err := s.Client.Del(s.ctx, key).Err()
+func (s *RedisService) DeleteValue(ctx context.Context, key string) error {
+	err := s.Client.Del(ctx, key).Err()
 	if err != nil {
 		return fmt.Errorf("failed to delete value from redis: %w", err)
 	}
 	return nil
 }
+
+// DeleteKeysByPattern deletes all keys matching a glob-style pattern and returns how many were removed.
+// It iterates with SCAN rather than KEYS so large keyspaces do not block Redis.
+func (s *RedisService) DeleteKeysByPattern(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	iter := s.Client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		n, err := s.Client.Del(ctx, iter.Val()).Result()
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete key '%s' from redis: %w", iter.Val(), err)
+		}
+		deleted += n
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, fmt.Errorf("failed to scan redis keys matching '%s': %w", pattern, err)
+	}
+	return deleted, nil
+}
+
+// Increment atomically increments a counter and returns its new value.
+// The expiration is refreshed on every call when it is greater than 0.
+func (s *RedisService) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	pipe := s.Client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	if expiration > 0 {
+		pipe.Expire(ctx, key, expiration)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to increment counter in redis: %w", err)
+	}
+	return incr.Val(), nil
+}
+
+// maxUpdateRetries bounds how often UpdateValue retries after a concurrent write to the same key.
+const maxUpdateRetries = 10
+
+// UpdateValue atomically replaces a value using optimistic locking (WATCH/MULTI).
+// update receives the current value ("" when the key does not exist) and returns the new one;
+// it is called again if the key was modified concurrently. Errors returned by update are passed through as is.
+func (s *RedisService) UpdateValue(ctx context.Context, key string, expiration time.Duration, update func(current string) (string, error)) error {
+	var updateErr error
+	txf := func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, key).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		next, err := update(current)
+		if err != nil {
+			updateErr = err
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, next, expiration)
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < maxUpdateRetries; i++ {
+		updateErr = nil
+		err := s.Client.Watch(ctx, txf, key)
+		if err == redis.TxFailedErr {
+			continue // Key changed between GET and EXEC, try again
+		}
+		if updateErr != nil {
+			return updateErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update value in redis: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to update value in redis: too many concurrent updates of '%s'", key)
+}
+
+// Ping checks that Redis is reachable.
+func (s *RedisService) Ping(ctx context.Context) error {
+	if err := s.Client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
+}
+
+// Publish sends a message to every subscriber of a channel.
+func (s *RedisService) Publish(ctx context.Context, channel, message string) error {
+	if err := s.Client.Publish(ctx, channel, message).Err(); err != nil {
+		return fmt.Errorf("failed to publish to redis channel '%s': %w", channel, err)
+	}
+	return nil
+}
+
+// Subscribe listens on a channel. Messages are delivered on the returned channel until
+// unsubscribe is called.
+func (s *RedisService) Subscribe(ctx context.Context, channel string) (<-chan string, func(), error) {
+	pubsub := s.Client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, nil, fmt.Errorf("failed to subscribe to redis channel '%s': %w", channel, err)
+	}
+
+	messages := make(chan string, subscriptionBuffer)
+	go func() {
+		defer close(messages)
+		for msg := range pubsub.Channel() {
+			messages <- msg.Payload
+		}
+	}()
+	return messages, func() { _ = pubsub.Close() }, nil
+}
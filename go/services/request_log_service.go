@@ -1,8 +1,10 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log" // For placeholder Pusher event
+	"log/slog" // For placeholder Pusher event
 
 	"gorm.io/gorm"
 	"mockapi/models" // Assuming module name is mockapi
@@ -13,6 +15,9 @@ import (
 type RequestLogService struct {
 	DB *gorm.DB
 	// PusherService *PusherService // Uncomment and use when PusherService is implemented
+
+	// Writer queues logs for batched insertion when set; without it logs are written synchronously.
+	Writer *RequestLogWriter
 }
 
 // NewRequestLogService creates a new RequestLogService.
@@ -23,16 +28,21 @@ func NewRequestLogService(db *gorm.DB) *RequestLogService {
 	return &RequestLogService{DB: db}
 }
 
-// SaveRequestLog saves a new request log.
-func (s *RequestLogService) SaveRequestLog(requestLog *models.RequestLog) error {
+// SaveRequestLog saves a new request log, through the Writer when one is set.
+func (s *RequestLogService) SaveRequestLog(ctx context.Context, requestLog *models.RequestLog) error {
 	if requestLog == nil {
 		return fmt.Errorf("request log data cannot be nil")
 	}
+	if s.Writer != nil {
+		if err := s.Writer.Enqueue(requestLog); !errors.Is(err, ErrRequestLogWriterClosed) {
+			return err
+		}
+	}
 
 	// The RequestLog model has its own ID (uint `gorm:"primaryKey"`) and CreatedAt,
 	// so GORM should handle these automatically.
 
-	if err := s.DB.Create(requestLog).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Create(requestLog).Error; err != nil {
 		return fmt.Errorf("failed to save request log: %w", err)
 	}
 
@@ -45,6 +55,22 @@ func (s *RequestLogService) SaveRequestLog(requestLog *models.RequestLog) error
 	return nil
 }
 
+// SaveRequestLogs inserts a batch of request logs with batchSize rows per statement.
+func (s *RequestLogService) SaveRequestLogs(requestLogs []models.RequestLog, batchSize int) error {
+	if len(requestLogs) == 0 {
+		return nil
+	}
+	if err := s.DB.CreateInBatches(requestLogs, batchSize).Error; err != nil {
+		return fmt.Errorf("failed to save %d request logs: %w", len(requestLogs), err)
+	}
+	for i := range requestLogs {
+		if requestLogs[i].ProjectID != 0 {
+			s.EmitPusherEvent(requestLogs[i].ProjectID, "new_request", &requestLogs[i])
+		}
+	}
+	return nil
+}
+
 // GetLogsByProjectID retrieves request logs for a specific project with pagination.
 func (s *RequestLogService) GetLogsByProjectID(projectID uint, limit, offset int) ([]models.RequestLog, error) {
 	var logs []models.RequestLog
@@ -69,7 +95,7 @@ func (s *RequestLogService) EmitPusherEvent(projectID uint, eventName string, da
 	// Placeholder: Log the event instead of sending it via Pusher.
 	// In a real app, this would use the PusherService.
 	// Example: s.PusherService.Trigger(fmt.Sprintf("project-%d", projectID), eventName, data)
-	log.Printf("Pusher Event Emitted (Placeholder): ProjectID=%d, Event=%s, Data=%v\n", projectID, eventName, data)
+	slog.Debug("Pusher event emitted (placeholder)", "project_id", projectID, "event", eventName)
 }
 
 // GetLogByID retrieves a single request log by its ID.
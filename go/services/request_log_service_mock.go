@@ -1,14 +1,19 @@
 package services
 
-import "mockapi/models"
+import (
+	"context"
+
+	"mockapi/models"
+)
 
 // MockRequestLogService is a manual mock for RequestLogService.
 type MockRequestLogService struct {
-	SaveRequestLogFunc func(logEntry *models.RequestLog) error
+	SaveRequestLogFunc     func(logEntry *models.RequestLog) error
+	GetLogsByProjectIDFunc func(projectID uint, limit, offset int) ([]models.RequestLog, error)
 	// Add other methods used by MockContentController if any
 }
 
-func (m *MockRequestLogService) SaveRequestLog(logEntry *models.RequestLog) error {
+func (m *MockRequestLogService) SaveRequestLog(_ context.Context, logEntry *models.RequestLog) error {
 	if m.SaveRequestLogFunc != nil {
 		return m.SaveRequestLogFunc(logEntry)
 	}
@@ -20,3 +25,10 @@ func (m *MockRequestLogService) SaveRequestLog(logEntry *models.RequestLog) erro
 // SaveMockContent and UpdateMockContent do not directly call RequestLogService methods in the provided code,
 // but they might if extensive logging/auditing were added there.
 // The mock includes SaveRequestLog. Add others if controller logic expands.
+
+func (m *MockRequestLogService) GetLogsByProjectID(projectID uint, limit, offset int) ([]models.RequestLog, error) {
+	if m.GetLogsByProjectIDFunc != nil {
+		return m.GetLogsByProjectIDFunc(projectID, limit, offset)
+	}
+	panic("MockRequestLogService.GetLogsByProjectIDFunc is not set")
+}
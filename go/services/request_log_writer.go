@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mockapi/metrics"
+	"mockapi/models"
+)
+
+// Drop policies of RequestLogWriter, selectable with REQUEST_LOG_DROP_POLICY.
+const (
+	DropPolicyNewest = "drop_newest" // Discard the entry being enqueued when the queue is full (default)
+	DropPolicyOldest = "drop_oldest" // Discard the oldest queued entry to make room
+	DropPolicyBlock  = "block"       // Make the request wait until the queue has room
+)
+
+// ErrRequestLogWriterClosed is returned once the writer has been closed; callers then write synchronously.
+var ErrRequestLogWriterClosed = errors.New("request log writer is closed")
+
+// dropWarningInterval limits how often dropped entries are reported in the log.
+const dropWarningInterval = 10 * time.Second
+
+// RequestLogWriterStats is a snapshot of the writer's counters.
+type RequestLogWriterStats struct {
+	Enqueued      uint64 `json:"enqueued"`
+	Written       uint64 `json:"written"`
+	Dropped       uint64 `json:"dropped"`
+	Failed        uint64 `json:"failed"`
+	QueueLength   int    `json:"queue_length"`
+	QueueCapacity int    `json:"queue_capacity"`
+	DropPolicy    string `json:"drop_policy"`
+}
+
+// RequestLogWriter takes request logs and request counters off the response path. Logs go through a
+// bounded queue to a worker that inserts them in batches; counter increments are summed per URL and
+// applied with one UPDATE per URL on every flush. When the queue is full the drop policy decides
+// whether entries are discarded or the request waits.
+type RequestLogWriter struct {
+	Logs          *RequestLogService
+	URLs          *URLService
+	BatchSize     int
+	FlushInterval time.Duration
+	DropPolicy    string
+
+	queue   chan models.RequestLog
+	done    chan struct{}
+	closing chan struct{} // Closed when Close starts, to release enqueuers waiting under the block policy
+
+	closeOnce sync.Once
+
+	mu     sync.RWMutex // Guards closed; held for reading while enqueueing
+	closed bool
+
+	countsMu sync.Mutex
+	counts   map[uint]int64
+
+	enqueued, written, dropped, failed uint64
+	lastDropWarning                    int64 // Unix nanoseconds
+}
+
+// NewRequestLogWriter creates a writer with room for queueSize pending logs. Call Start to run it.
+func NewRequestLogWriter(logs *RequestLogService, urls *URLService, queueSize, batchSize int, flushInterval time.Duration, dropPolicy string) (*RequestLogWriter, error) {
+	switch dropPolicy {
+	case "":
+		dropPolicy = DropPolicyNewest
+	case DropPolicyNewest, DropPolicyOldest, DropPolicyBlock:
+	default:
+		return nil, fmt.Errorf("unsupported request log drop policy '%s' (use %s, %s or %s)", dropPolicy, DropPolicyNewest, DropPolicyOldest, DropPolicyBlock)
+	}
+	if queueSize < 1 {
+		return nil, fmt.Errorf("request log queue size must be positive, got %d", queueSize)
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+	return &RequestLogWriter{
+		Logs:          logs,
+		URLs:          urls,
+		BatchSize:     batchSize,
+		FlushInterval: flushInterval,
+		DropPolicy:    dropPolicy,
+		queue:         make(chan models.RequestLog, queueSize),
+		done:          make(chan struct{}),
+		closing:       make(chan struct{}),
+		counts:        make(map[uint]int64),
+	}, nil
+}
+
+// Start runs the worker in the background until Close is called.
+func (w *RequestLogWriter) Start() {
+	go w.run()
+}
+
+// Enqueue queues a copy of the log for writing. Entries discarded by the drop policy are counted
+// in the stats and not reported as errors.
+func (w *RequestLogWriter) Enqueue(requestLog *models.RequestLog) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return ErrRequestLogWriterClosed
+	}
+
+	entry := *requestLog
+	switch w.DropPolicy {
+	case DropPolicyBlock:
+		select {
+		case w.queue <- entry:
+		case <-w.closing:
+			return ErrRequestLogWriterClosed
+		}
+	case DropPolicyOldest:
+		for {
+			select {
+			case w.queue <- entry:
+				atomic.AddUint64(&w.enqueued, 1)
+				return nil
+			default:
+			}
+			select {
+			case <-w.queue:
+				w.recordDrop()
+			default:
+			}
+		}
+	default:
+		select {
+		case w.queue <- entry:
+		default:
+			w.recordDrop()
+			return nil
+		}
+	}
+	atomic.AddUint64(&w.enqueued, 1)
+	return nil
+}
+
+// AddRequestStat counts a served request for the URL. It returns false once the writer is closed.
+func (w *RequestLogWriter) AddRequestStat(urlID uint) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return false
+	}
+	w.countsMu.Lock()
+	w.counts[urlID]++
+	w.countsMu.Unlock()
+	return true
+}
+
+// Stats returns the current counters.
+func (w *RequestLogWriter) Stats() RequestLogWriterStats {
+	return RequestLogWriterStats{
+		Enqueued:      atomic.LoadUint64(&w.enqueued),
+		Written:       atomic.LoadUint64(&w.written),
+		Dropped:       atomic.LoadUint64(&w.dropped),
+		Failed:        atomic.LoadUint64(&w.failed),
+		QueueLength:   len(w.queue),
+		QueueCapacity: cap(w.queue),
+		DropPolicy:    w.DropPolicy,
+	}
+}
+
+// MetricsStats returns the counters in the form read by the Prometheus collector.
+func (w *RequestLogWriter) MetricsStats() metrics.RequestLogStats {
+	stats := w.Stats()
+	return metrics.RequestLogStats{
+		Enqueued:      stats.Enqueued,
+		Written:       stats.Written,
+		Dropped:       stats.Dropped,
+		Failed:        stats.Failed,
+		QueueLength:   stats.QueueLength,
+		QueueCapacity: stats.QueueCapacity,
+	}
+}
+
+// Close stops accepting entries and waits until everything queued has been written or ctx expires.
+// Requests waiting for room under the block policy give up and write their logs synchronously.
+func (w *RequestLogWriter) Close(ctx context.Context) error {
+	w.closeOnce.Do(func() { close(w.closing) })
+
+	stopped := make(chan struct{})
+	go func() {
+		w.mu.Lock()
+		if !w.closed {
+			w.closed = true
+			close(w.queue)
+		}
+		w.mu.Unlock()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return fmt.Errorf("request log writer did not stop accepting logs: %w", ctx.Err())
+	}
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("request log writer did not flush %d queued logs: %w", len(w.queue), ctx.Err())
+	}
+}
+
+func (w *RequestLogWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.RequestLog, 0, w.BatchSize)
+	for {
+		select {
+		case entry, ok := <-w.queue:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= w.BatchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			w.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush writes the batch and the request counters gathered since the last flush.
+func (w *RequestLogWriter) flush(batch []models.RequestLog) {
+	if len(batch) > 0 {
+		if err := w.Logs.SaveRequestLogs(batch, w.BatchSize); err != nil {
+			atomic.AddUint64(&w.failed, uint64(len(batch)))
+			slog.Error("Failed to write request logs", "count", len(batch), "error", err)
+		} else {
+			atomic.AddUint64(&w.written, uint64(len(batch)))
+		}
+	}
+
+	w.countsMu.Lock()
+	counts := w.counts
+	if len(counts) > 0 {
+		w.counts = make(map[uint]int64)
+	}
+	w.countsMu.Unlock()
+	if len(counts) > 0 {
+		if err := w.URLs.AddRequestStats(counts); err != nil {
+			slog.Error("Failed to update request stats", "urls", len(counts), "error", err)
+		}
+	}
+}
+
+func (w *RequestLogWriter) recordDrop() {
+	dropped := atomic.AddUint64(&w.dropped, 1)
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&w.lastDropWarning)
+	if now-last >= int64(dropWarningInterval) && atomic.CompareAndSwapInt64(&w.lastDropWarning, last, now) {
+		slog.Warn("Request log queue is full", "capacity", cap(w.queue), "dropped", dropped, "policy", w.DropPolicy)
+	}
+}
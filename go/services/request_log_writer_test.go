@@ -0,0 +1,109 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"mockapi/database"
+	"mockapi/models"
+	"mockapi/services"
+)
+
+func newTestRequestLogWriter(t *testing.T, queueSize int, dropPolicy string) (*services.RequestLogWriter, *gorm.DB) {
+	t.Helper()
+	db, err := database.OpenInMemory()
+	require.NoError(t, err)
+	writer, err := services.NewRequestLogWriter(services.NewRequestLogService(db), services.NewURLService(db, nil),
+		queueSize, 10, time.Hour, dropPolicy)
+	require.NoError(t, err)
+	return writer, db
+}
+
+func TestRequestLogWriterFlushesOnClose(t *testing.T) {
+	writer, db := newTestRequestLogWriter(t, 100, services.DropPolicyNewest)
+	team := models.Team{Name: "Team", Slug: "team"}
+	require.NoError(t, db.Create(&team).Error)
+	project := models.Project{Name: "Shop", Slug: "shop", ChannelID: "shop", TeamID: team.ID}
+	require.NoError(t, db.Create(&project).Error)
+	url := models.Url{Name: "Orders", URL: "/orders", Status: models.StatusOK, ProjectID: project.ID}
+	require.NoError(t, db.Create(&url).Error)
+
+	logService := services.NewRequestLogService(db)
+	logService.Writer = writer
+	urlService := services.NewURLService(db, nil)
+	urlService.Writer = writer
+	writer.Start()
+
+	for i := 0; i < 25; i++ {
+		require.NoError(t, logService.SaveRequestLog(context.Background(), &models.RequestLog{ProjectID: project.ID, Method: "GET", URL: "/orders", Status: 200}))
+		require.NoError(t, urlService.IncrementRequestStats(context.Background(), url.ID))
+	}
+	require.NoError(t, writer.Close(context.Background()))
+
+	var count int64
+	require.NoError(t, db.Model(&models.RequestLog{}).Count(&count).Error)
+	assert.Equal(t, int64(25), count)
+	require.NoError(t, db.First(&url, url.ID).Error)
+	assert.Equal(t, int64(25), url.Requests.Int64, "counts of a NULL counter start at zero")
+	stats := writer.Stats()
+	assert.Equal(t, uint64(25), stats.Written)
+	assert.Zero(t, stats.Dropped)
+
+	// Once closed, logs are written synchronously
+	require.NoError(t, logService.SaveRequestLog(context.Background(), &models.RequestLog{ProjectID: project.ID, Method: "GET", URL: "/late"}))
+	require.NoError(t, db.Model(&models.RequestLog{}).Count(&count).Error)
+	assert.Equal(t, int64(26), count)
+}
+
+func TestRequestLogWriterDropPolicies(t *testing.T) {
+	// The worker is not started, so the queue fills up
+	writer, _ := newTestRequestLogWriter(t, 2, services.DropPolicyNewest)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, writer.Enqueue(&models.RequestLog{URL: "/"}))
+	}
+	stats := writer.Stats()
+	assert.Equal(t, uint64(2), stats.Enqueued)
+	assert.Equal(t, uint64(3), stats.Dropped)
+	assert.Equal(t, 2, stats.QueueLength)
+
+	writer, db := newTestRequestLogWriter(t, 2, services.DropPolicyOldest)
+	for _, path := range []string{"/1", "/2", "/3"} {
+		require.NoError(t, writer.Enqueue(&models.RequestLog{URL: path}))
+	}
+	assert.Equal(t, uint64(1), writer.Stats().Dropped)
+	writer.Start()
+	require.NoError(t, writer.Close(context.Background()))
+	var urls []string
+	require.NoError(t, db.Model(&models.RequestLog{}).Order("id").Pluck("url", &urls).Error)
+	assert.Equal(t, []string{"/2", "/3"}, urls, "the oldest entry is discarded")
+
+	_, err := services.NewRequestLogWriter(nil, nil, 10, 10, time.Second, "sometimes")
+	assert.Error(t, err)
+}
+
+func TestRequestLogWriterCloseReleasesBlockedEnqueue(t *testing.T) {
+	// The worker is not started, so a second entry waits for room
+	writer, _ := newTestRequestLogWriter(t, 1, services.DropPolicyBlock)
+	require.NoError(t, writer.Enqueue(&models.RequestLog{URL: "/1"}))
+	blocked := make(chan error, 1)
+	go func() { blocked <- writer.Enqueue(&models.RequestLog{URL: "/2"}) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, writer.Close(ctx), context.DeadlineExceeded, "nothing drains the queue")
+	select {
+	case err := <-blocked:
+		assert.ErrorIs(t, err, services.ErrRequestLogWriterClosed, "the caller falls back to a synchronous write")
+	case <-time.After(time.Second):
+		t.Fatal("Enqueue still blocked after Close")
+	}
+
+	stats := writer.MetricsStats()
+	assert.Equal(t, uint64(1), stats.Enqueued)
+	assert.Equal(t, 1, stats.QueueLength)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"mockapi/models"
+)
+
+// ErrInvalidRequestMatcher is returned when a variant's matcher cannot be used.
+var ErrInvalidRequestMatcher = errors.New("invalid request matcher")
+
+// maxMatchedBodyBytes bounds how much of a request body is read to evaluate body_contains.
+const maxMatchedBodyBytes = 1 << 20
+
+// ValidateRequestMatcher normalizes a variant's matcher and checks its method.
+// It returns nil for a matcher without conditions, which matches every request.
+func ValidateRequestMatcher(matcher *models.RequestMatcher) (*models.RequestMatcher, error) {
+	if matcher.IsEmpty() {
+		return nil, nil
+	}
+	normalized := *matcher
+	normalized.Method = strings.ToUpper(strings.TrimSpace(matcher.Method))
+	if len(normalized.Query) == 0 {
+		normalized.Query = nil // Stored the same way however an empty map was written
+	}
+	if len(normalized.Headers) == 0 {
+		normalized.Headers = nil
+	}
+	if normalized.IsEmpty() {
+		return nil, nil
+	}
+	switch normalized.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		return nil, fmt.Errorf("%w: unknown method '%s'", ErrInvalidRequestMatcher, matcher.Method)
+	}
+	return &normalized, nil
+}
+
+// FilterMockContentsByRequest returns the mock contents eligible for the request.
+// Contents whose matcher matches take precedence; contents without a matcher are used as the fallback
+// when none of them match. The request body is only read when a matcher needs it, and is restored afterwards.
+func FilterMockContentsByRequest(contents []models.MockContent, req *http.Request) ([]models.MockContent, error) {
+	var body []byte
+	for _, mc := range contents {
+		if mc.Matcher != nil && mc.Matcher.BodyContains != "" {
+			var err error
+			if body, err = readRequestBody(req); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	var matched, unmatched []models.MockContent
+	for _, mc := range contents {
+		if mc.Matcher.IsEmpty() {
+			unmatched = append(unmatched, mc)
+		} else if RequestMatches(mc.Matcher, req, body) {
+			matched = append(matched, mc)
+		}
+	}
+	if len(matched) > 0 {
+		return matched, nil
+	}
+	return unmatched, nil
+}
+
+// RequestMatches reports whether the request satisfies every condition of the matcher.
+func RequestMatches(matcher *models.RequestMatcher, req *http.Request, body []byte) bool {
+	if matcher.IsEmpty() {
+		return true
+	}
+	if matcher.Method != "" && !strings.EqualFold(matcher.Method, req.Method) {
+		return false
+	}
+	query := req.URL.Query()
+	for name, value := range matcher.Query {
+		if !query.Has(name) || query.Get(name) != value {
+			return false
+		}
+	}
+	for name, value := range matcher.Headers {
+		if _, ok := req.Header[http.CanonicalHeaderKey(name)]; !ok || req.Header.Get(name) != value {
+			return false
+		}
+	}
+	return matcher.BodyContains == "" || bytes.Contains(body, []byte(matcher.BodyContains))
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxMatchedBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	// The rest of an oversized body stays readable behind the part that was read
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+	return body, nil
+}
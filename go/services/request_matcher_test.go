@@ -0,0 +1,71 @@
+package services_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mockapi/models"
+	"mockapi/services"
+)
+
+func TestFilterMockContentsByRequest(t *testing.T) {
+	contents := []models.MockContent{
+		{Name: "fallback"},
+		{Name: "created", Matcher: &models.RequestMatcher{Method: "POST"}},
+		{Name: "premium", Matcher: &models.RequestMatcher{Query: map[string]string{"tier": "premium"}}},
+		{Name: "german", Matcher: &models.RequestMatcher{Headers: map[string]string{"accept-language": "de"}}},
+		{Name: "express", Matcher: &models.RequestMatcher{Method: "POST", BodyContains: `"express":true`}},
+	}
+
+	t.Run("matching_contents_take_precedence", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/orders?tier=premium", nil)
+		req.Header.Set("Accept-Language", "de")
+		result, err := services.FilterMockContentsByRequest(contents, req)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"premium", "german"}, mockContentNames(result))
+	})
+
+	t.Run("every_condition_must_hold", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"express":true}`))
+		result, err := services.FilterMockContentsByRequest(contents, req)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"created", "express"}, mockContentNames(result))
+
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"express":true}`, string(body), "the body can still be read after matching")
+	})
+
+	t.Run("falls_back_to_contents_without_matcher", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/orders?tier=basic", nil)
+		result, err := services.FilterMockContentsByRequest(contents, req)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"fallback"}, mockContentNames(result))
+	})
+
+	t.Run("nothing_matches", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/orders", nil)
+		result, err := services.FilterMockContentsByRequest(contents[1:], req)
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+}
+
+func TestValidateRequestMatcher(t *testing.T) {
+	matcher, err := services.ValidateRequestMatcher(&models.RequestMatcher{Method: " post ", Query: map[string]string{}})
+	require.NoError(t, err)
+	assert.Equal(t, &models.RequestMatcher{Method: "POST"}, matcher)
+
+	matcher, err = services.ValidateRequestMatcher(&models.RequestMatcher{Headers: map[string]string{}})
+	require.NoError(t, err)
+	assert.Nil(t, matcher, "a matcher without conditions matches every request")
+
+	_, err = services.ValidateRequestMatcher(&models.RequestMatcher{Method: "FETCH"})
+	assert.ErrorIs(t, err, services.ErrInvalidRequestMatcher)
+}
@@ -0,0 +1,568 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"mockapi/models"
+)
+
+// DefaultResourceIDField is the item property used as identifier when a resource does not set one.
+const DefaultResourceIDField = "id"
+
+// resourceStateTTL bounds how long an idle resource keeps its modified collection in Redis.
+// Once it expires, the resource is served from its seed again.
+const resourceStateTTL = 24 * time.Hour
+
+// ErrResourceItemNotFound is returned when no item of a resource has the requested ID.
+var ErrResourceItemNotFound = errors.New("resource item not found")
+
+// ErrResourceItemExists is returned when creating an item whose ID is already taken.
+var ErrResourceItemExists = errors.New("resource item already exists")
+
+// ResourceItem is a single JSON object of a resource collection.
+// Numbers are kept as json.Number so IDs and values round-trip unchanged.
+type ResourceItem map[string]interface{}
+
+// ResourceFilter is a single filter parsed from the query string, e.g. views_gte=10.
+type ResourceFilter struct {
+	Field    string
+	Operator string // One of eq, ne, like, gte, lte
+	Value    string
+}
+
+// ResourceQuery holds the filtering, sorting and pagination options of a collection request.
+// The parameters follow json-server: field=value, field_ne, field_like, field_gte, field_lte,
+// q (full-text), _sort, _order, _page and _limit.
+type ResourceQuery struct {
+	Filters []ResourceFilter
+	Search  string
+	Sort    []string
+	Order   []string
+	Page    int
+	Limit   int
+}
+
+// ResourceService manages in-memory CRUD resources. Definitions live in the database and
+// each resource's current collection lives in the key-value store, seeded from the definition on first use.
+type ResourceService struct {
+	DB    *gorm.DB
+	Store KeyValueStore
+}
+
+// NewResourceService creates a new ResourceService.
+func NewResourceService(db *gorm.DB, store KeyValueStore) *ResourceService {
+	return &ResourceService{DB: db, Store: store}
+}
+
+// NormalizeResourcePath returns the path with a leading slash and without a trailing one.
+func NormalizeResourcePath(path string) string {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if len(path) > 1 {
+		path = strings.TrimRight(path, "/")
+	}
+	return path
+}
+
+// ParseResourceSeed validates that data is a JSON array of objects and returns its items.
+// Empty data is treated as an empty collection.
+func ParseResourceSeed(data string) ([]ResourceItem, error) {
+	if strings.TrimSpace(data) == "" {
+		return []ResourceItem{}, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.UseNumber()
+	var items []ResourceItem
+	if err := decoder.Decode(&items); err != nil {
+		return nil, fmt.Errorf("resource data must be a JSON array of objects: %w", err)
+	}
+	if items == nil {
+		items = []ResourceItem{}
+	}
+	return items, nil
+}
+
+// DecodeResourceItem decodes a request body into a single resource item.
+func DecodeResourceItem(body []byte) (ResourceItem, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var item ResourceItem
+	if err := decoder.Decode(&item); err != nil {
+		return nil, fmt.Errorf("request body must be a JSON object: %w", err)
+	}
+	if item == nil {
+		return nil, fmt.Errorf("request body must be a JSON object")
+	}
+	return item, nil
+}
+
+// CreateResource validates and saves a new resource for a project.
+func (s *ResourceService) CreateResource(resource *models.Resource, projectID uint) error {
+	resource.ProjectID = projectID
+	resource.Path = NormalizeResourcePath(resource.Path)
+	if resource.IDField == "" {
+		resource.IDField = DefaultResourceIDField
+	}
+	if resource.Name == "" {
+		resource.Name = resource.Path
+	}
+	if _, err := ParseResourceSeed(resource.SeedData); err != nil {
+		return err
+	}
+	if err := s.DB.Create(resource).Error; err != nil {
+		return fmt.Errorf("failed to create resource '%s': %w", resource.Path, err)
+	}
+	return nil
+}
+
+// UpdateResource saves changes to a resource definition.
+// Because the collection key includes UpdatedAt, a changed definition starts again from its seed.
+func (s *ResourceService) UpdateResource(resource *models.Resource) error {
+	resource.Path = NormalizeResourcePath(resource.Path)
+	if resource.IDField == "" {
+		resource.IDField = DefaultResourceIDField
+	}
+	if _, err := ParseResourceSeed(resource.SeedData); err != nil {
+		return err
+	}
+	if err := s.DB.Omit("Project").Save(resource).Error; err != nil {
+		return fmt.Errorf("failed to update resource ID %d: %w", resource.ID, err)
+	}
+	return nil
+}
+
+// GetResourceByID retrieves a resource of a project by its ID.
+func (s *ResourceService) GetResourceByID(projectID, resourceID uint) (*models.Resource, error) {
+	var resource models.Resource
+	if err := s.DB.Where("project_id = ?", projectID).First(&resource, resourceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to retrieve resource ID %d: %w", resourceID, err)
+	}
+	return &resource, nil
+}
+
+// GetResourcesByProjectID lists the resources of a project ordered by path.
+func (s *ResourceService) GetResourcesByProjectID(ctx context.Context, projectID uint) ([]models.Resource, error) {
+	var resources []models.Resource
+	if err := s.DB.WithContext(ctx).Where("project_id = ?", projectID).Order("path").Find(&resources).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve resources for project ID %d: %w", projectID, err)
+	}
+	return resources, nil
+}
+
+// DeleteResource deletes a resource definition and its stored collection.
+func (s *ResourceService) DeleteResource(ctx context.Context, resource *models.Resource) error {
+	if err := s.DB.WithContext(ctx).Delete(&models.Resource{}, resource.ID).Error; err != nil {
+		return fmt.Errorf("failed to delete resource ID %d: %w", resource.ID, err)
+	}
+	if _, err := s.ResetResources(ctx, resource.ProjectID, resource.ID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MatchResource finds the resource serving a request path.
+// It returns the resource and, for item paths such as /todos/3, the requested item ID.
+// gorm.ErrRecordNotFound is returned when no resource matches.
+func (s *ResourceService) MatchResource(ctx context.Context, projectID uint, path string) (*models.Resource, string, error) {
+	resources, err := s.GetResourcesByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, "", err
+	}
+	path = NormalizeResourcePath(path)
+	// Exact collection matches win, so /todos/archived can be its own resource next to /todos.
+	for i := range resources {
+		if path == resources[i].Path {
+			return &resources[i], "", nil
+		}
+	}
+	for i := range resources {
+		resourcePath := resources[i].Path
+		if rest, ok := strings.CutPrefix(path, strings.TrimRight(resourcePath, "/")+"/"); ok && rest != "" && !strings.Contains(rest, "/") {
+			itemID, err := url.PathUnescape(rest)
+			if err != nil {
+				itemID = rest
+			}
+			return &resources[i], itemID, nil
+		}
+	}
+	return nil, "", gorm.ErrRecordNotFound
+}
+
+func (s *ResourceService) stateKey(resource *models.Resource) string {
+	return s.Store.CreateRedisKey("resource",
+		strconv.FormatUint(uint64(resource.ProjectID), 10),
+		strconv.FormatUint(uint64(resource.ID), 10),
+		strconv.FormatInt(resource.UpdatedAt.UnixNano(), 10))
+}
+
+func (s *ResourceService) decodeState(resource *models.Resource, state string) ([]ResourceItem, error) {
+	if state == "" {
+		return ParseResourceSeed(resource.SeedData)
+	}
+	items, err := ParseResourceSeed(state)
+	if err != nil {
+		return nil, fmt.Errorf("stored collection of resource '%s' is corrupt: %w", resource.Path, err)
+	}
+	return items, nil
+}
+
+// ListItems returns the items of a resource matching the query and the total number of matches before pagination.
+func (s *ResourceService) ListItems(ctx context.Context, resource *models.Resource, query ResourceQuery) ([]ResourceItem, int, error) {
+	items, err := s.loadItems(ctx, resource)
+	if err != nil {
+		return nil, 0, err
+	}
+	page, total := QueryResourceItems(items, query)
+	return page, total, nil
+}
+
+// GetItem returns a single item of a resource by ID.
+func (s *ResourceService) GetItem(ctx context.Context, resource *models.Resource, itemID string) (ResourceItem, error) {
+	items, err := s.loadItems(ctx, resource)
+	if err != nil {
+		return nil, err
+	}
+	index := findResourceItem(items, resource.IDField, itemID)
+	if index < 0 {
+		return nil, ErrResourceItemNotFound
+	}
+	return items[index], nil
+}
+
+// CreateItem adds an item to a resource. When the item has no ID, the next numeric ID is assigned.
+func (s *ResourceService) CreateItem(ctx context.Context, resource *models.Resource, item ResourceItem) (ResourceItem, error) {
+	err := s.updateItems(ctx, resource, func(items []ResourceItem) ([]ResourceItem, error) {
+		if id, ok := item[resource.IDField]; ok && id != nil {
+			if findResourceItem(items, resource.IDField, resourceValueString(id)) >= 0 {
+				return nil, ErrResourceItemExists
+			}
+		} else {
+			item[resource.IDField] = nextResourceID(items, resource.IDField)
+		}
+		return append(items, item), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ReplaceItem replaces an item entirely (PUT). The item keeps its ID.
+func (s *ResourceService) ReplaceItem(ctx context.Context, resource *models.Resource, itemID string, item ResourceItem) (ResourceItem, error) {
+	err := s.updateItems(ctx, resource, func(items []ResourceItem) ([]ResourceItem, error) {
+		index := findResourceItem(items, resource.IDField, itemID)
+		if index < 0 {
+			return nil, ErrResourceItemNotFound
+		}
+		item[resource.IDField] = items[index][resource.IDField]
+		items[index] = item
+		return items, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// PatchItem merges the given fields into an item (PATCH). The item keeps its ID.
+func (s *ResourceService) PatchItem(ctx context.Context, resource *models.Resource, itemID string, patch ResourceItem) (ResourceItem, error) {
+	var patched ResourceItem
+	err := s.updateItems(ctx, resource, func(items []ResourceItem) ([]ResourceItem, error) {
+		index := findResourceItem(items, resource.IDField, itemID)
+		if index < 0 {
+			return nil, ErrResourceItemNotFound
+		}
+		for key, value := range patch {
+			if key == resource.IDField {
+				continue
+			}
+			items[index][key] = value
+		}
+		patched = items[index]
+		return items, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
+
+// DeleteItem removes an item from a resource.
+func (s *ResourceService) DeleteItem(ctx context.Context, resource *models.Resource, itemID string) error {
+	return s.updateItems(ctx, resource, func(items []ResourceItem) ([]ResourceItem, error) {
+		index := findResourceItem(items, resource.IDField, itemID)
+		if index < 0 {
+			return nil, ErrResourceItemNotFound
+		}
+		return append(items[:index], items[index+1:]...), nil
+	})
+}
+
+// ResetResources restores resources to their seed and returns how many stored collections were cleared.
+// A resourceID of 0 resets every resource of the project.
+func (s *ResourceService) ResetResources(ctx context.Context, projectID, resourceID uint) (int64, error) {
+	resourcePart := "*"
+	if resourceID != 0 {
+		resourcePart = strconv.FormatUint(uint64(resourceID), 10)
+	}
+	pattern := s.Store.CreateRedisKey("resource", strconv.FormatUint(uint64(projectID), 10), resourcePart, "*")
+	deleted, err := s.Store.DeleteKeysByPattern(ctx, pattern)
+	if err != nil {
+		return deleted, fmt.Errorf("failed to reset resources for project ID %d: %w", projectID, err)
+	}
+	return deleted, nil
+}
+
+func (s *ResourceService) loadItems(ctx context.Context, resource *models.Resource) ([]ResourceItem, error) {
+	state, err := s.Store.GetValue(ctx, s.stateKey(resource))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load resource '%s': %w", resource.Path, err)
+	}
+	return s.decodeState(resource, state)
+}
+
+func (s *ResourceService) updateItems(ctx context.Context, resource *models.Resource, mutate func([]ResourceItem) ([]ResourceItem, error)) error {
+	return s.Store.UpdateValue(ctx, s.stateKey(resource), resourceStateTTL, func(current string) (string, error) {
+		items, err := s.decodeState(resource, current)
+		if err != nil {
+			return "", err
+		}
+		items, err = mutate(items)
+		if err != nil {
+			return "", err
+		}
+		encoded, err := json.Marshal(items)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode resource '%s': %w", resource.Path, err)
+		}
+		return string(encoded), nil
+	})
+}
+
+func findResourceItem(items []ResourceItem, idField, itemID string) int {
+	for i, item := range items {
+		if id, ok := item[idField]; ok && resourceValueString(id) == itemID {
+			return i
+		}
+	}
+	return -1
+}
+
+func nextResourceID(items []ResourceItem, idField string) json.Number {
+	var maxID int64
+	for _, item := range items {
+		if id, err := strconv.ParseInt(resourceValueString(item[idField]), 10, 64); err == nil && id > maxID {
+			maxID = id
+		}
+	}
+	return json.Number(strconv.FormatInt(maxID+1, 10))
+}
+
+// ParseResourceQuery builds a ResourceQuery from query parameters, skipping the reserved ones.
+func ParseResourceQuery(values url.Values, reserved ...string) ResourceQuery {
+	skip := make(map[string]bool, len(reserved))
+	for _, name := range reserved {
+		skip[name] = true
+	}
+
+	var query ResourceQuery
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // Deterministic filter order
+
+	for _, key := range keys {
+		value := values.Get(key)
+		switch {
+		case skip[key]:
+		case key == "q":
+			query.Search = value
+		case key == "_sort":
+			query.Sort = strings.Split(value, ",")
+		case key == "_order":
+			query.Order = strings.Split(value, ",")
+		case key == "_page":
+			query.Page, _ = strconv.Atoi(value)
+		case key == "_limit":
+			query.Limit, _ = strconv.Atoi(value)
+		case strings.HasPrefix(key, "_"):
+			// Unknown control parameter, ignored
+		default:
+			filter := ResourceFilter{Field: key, Operator: "eq", Value: value}
+			for _, op := range []string{"ne", "like", "gte", "lte"} {
+				if field, ok := strings.CutSuffix(key, "_"+op); ok && field != "" {
+					filter.Field, filter.Operator = field, op
+					break
+				}
+			}
+			query.Filters = append(query.Filters, filter)
+		}
+	}
+	return query
+}
+
+// QueryResourceItems filters, sorts and paginates items. It returns the requested page
+// and the number of items that matched before pagination.
+func QueryResourceItems(items []ResourceItem, query ResourceQuery) ([]ResourceItem, int) {
+	matched := make([]ResourceItem, 0, len(items))
+	for _, item := range items {
+		if resourceItemMatches(item, query) {
+			matched = append(matched, item)
+		}
+	}
+
+	if len(query.Sort) > 0 {
+		sort.SliceStable(matched, func(i, j int) bool {
+			for k, field := range query.Sort {
+				a, aOK := resourceField(matched[i], field)
+				b, bOK := resourceField(matched[j], field)
+				cmp := compareResourceValues(a, aOK, b, bOK)
+				if cmp == 0 {
+					continue
+				}
+				if k < len(query.Order) && strings.EqualFold(query.Order[k], "desc") {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+			return false
+		})
+	}
+
+	total := len(matched)
+	if query.Limit > 0 || query.Page > 0 {
+		limit := query.Limit
+		if limit <= 0 {
+			limit = 10 // json-server's default page size
+		}
+		page := query.Page
+		if page <= 0 {
+			page = 1
+		}
+		start := (page - 1) * limit
+		if start >= total {
+			return []ResourceItem{}, total
+		}
+		end := start + limit
+		if end > total {
+			end = total
+		}
+		matched = matched[start:end]
+	}
+	return matched, total
+}
+
+func resourceItemMatches(item ResourceItem, query ResourceQuery) bool {
+	for _, filter := range query.Filters {
+		value, ok := resourceField(item, filter.Field)
+		actual := resourceValueString(value)
+		switch filter.Operator {
+		case "ne":
+			if ok && actual == filter.Value {
+				return false
+			}
+		case "like":
+			if !ok || !strings.Contains(strings.ToLower(actual), strings.ToLower(filter.Value)) {
+				return false
+			}
+		case "gte":
+			if !ok || compareResourceValues(value, true, filter.Value, true) < 0 {
+				return false
+			}
+		case "lte":
+			if !ok || compareResourceValues(value, true, filter.Value, true) > 0 {
+				return false
+			}
+		default:
+			if !ok || actual != filter.Value {
+				return false
+			}
+		}
+	}
+
+	if query.Search != "" {
+		needle := strings.ToLower(query.Search)
+		for _, value := range item {
+			if strings.Contains(strings.ToLower(resourceValueString(value)), needle) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// resourceField looks up a field, following dots into nested objects (e.g. author.name).
+func resourceField(item ResourceItem, field string) (interface{}, bool) {
+	var current interface{} = map[string]interface{}(item)
+	for _, part := range strings.Split(field, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = object[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// compareResourceValues orders two values numerically when both are numbers and as strings otherwise.
+// Missing values sort first.
+func compareResourceValues(a interface{}, aOK bool, b interface{}, bOK bool) int {
+	switch {
+	case !aOK && !bOK:
+		return 0
+	case !aOK:
+		return -1
+	case !bOK:
+		return 1
+	}
+	as, bs := resourceValueString(a), resourceValueString(b)
+	af, aErr := strconv.ParseFloat(as, 64)
+	bf, bErr := strconv.ParseFloat(bs, 64)
+	if aErr == nil && bErr == nil {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(as, bs)
+}
+
+func resourceValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case map[string]interface{}, []interface{}:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,153 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mockapi/models"
+	"mockapi/services"
+)
+
+func resourceTitles(items []services.ResourceItem) []string {
+	titles := make([]string, 0, len(items))
+	for _, item := range items {
+		titles = append(titles, item["title"].(string))
+	}
+	return titles
+}
+
+func TestParseResourceQuery(t *testing.T) {
+	values, err := url.ParseQuery("done=true&views_gte=10&title_like=go&author.name_ne=bob&q=x&_sort=views,title&_order=desc&_page=2&_limit=5&url=abc&_unknown=1")
+	require.NoError(t, err)
+
+	query := services.ParseResourceQuery(values, "url")
+	assert.Equal(t, []services.ResourceFilter{
+		{Field: "author.name", Operator: "ne", Value: "bob"},
+		{Field: "done", Operator: "eq", Value: "true"},
+		{Field: "title", Operator: "like", Value: "go"},
+		{Field: "views", Operator: "gte", Value: "10"},
+	}, query.Filters)
+	assert.Equal(t, "x", query.Search)
+	assert.Equal(t, []string{"views", "title"}, query.Sort)
+	assert.Equal(t, []string{"desc"}, query.Order)
+	assert.Equal(t, 2, query.Page)
+	assert.Equal(t, 5, query.Limit)
+}
+
+func TestQueryResourceItems(t *testing.T) {
+	items, err := services.ParseResourceSeed(`[
+		{"id": 1, "title": "Learn Go", "views": 9, "done": true, "author": {"name": "ann"}},
+		{"id": 2, "title": "Write tests", "views": 120, "done": false, "author": {"name": "bob"}},
+		{"id": 3, "title": "Go shopping", "views": 30, "done": false}
+	]`)
+	require.NoError(t, err)
+
+	t.Run("equality_filter", func(t *testing.T) {
+		result, total := services.QueryResourceItems(items, services.ResourceQuery{
+			Filters: []services.ResourceFilter{{Field: "done", Operator: "eq", Value: "false"}},
+		})
+		assert.Equal(t, 2, total)
+		assert.Equal(t, []string{"Write tests", "Go shopping"}, resourceTitles(result))
+	})
+
+	t.Run("numeric_range_and_like", func(t *testing.T) {
+		result, _ := services.QueryResourceItems(items, services.ResourceQuery{
+			Filters: []services.ResourceFilter{
+				{Field: "views", Operator: "gte", Value: "10"},
+				{Field: "title", Operator: "like", Value: "GO"},
+			},
+		})
+		assert.Equal(t, []string{"Go shopping"}, resourceTitles(result))
+	})
+
+	t.Run("nested_field", func(t *testing.T) {
+		result, _ := services.QueryResourceItems(items, services.ResourceQuery{
+			Filters: []services.ResourceFilter{{Field: "author.name", Operator: "eq", Value: "bob"}},
+		})
+		assert.Equal(t, []string{"Write tests"}, resourceTitles(result))
+	})
+
+	t.Run("numeric_sort_descending", func(t *testing.T) {
+		result, _ := services.QueryResourceItems(items, services.ResourceQuery{Sort: []string{"views"}, Order: []string{"desc"}})
+		assert.Equal(t, []string{"Write tests", "Go shopping", "Learn Go"}, resourceTitles(result))
+	})
+
+	t.Run("pagination_reports_total", func(t *testing.T) {
+		result, total := services.QueryResourceItems(items, services.ResourceQuery{Sort: []string{"id"}, Page: 2, Limit: 2})
+		assert.Equal(t, 3, total)
+		assert.Equal(t, []string{"Go shopping"}, resourceTitles(result))
+	})
+
+	t.Run("page_past_end", func(t *testing.T) {
+		result, total := services.QueryResourceItems(items, services.ResourceQuery{Page: 5, Limit: 2})
+		assert.Equal(t, 3, total)
+		assert.Empty(t, result)
+	})
+
+	t.Run("full_text_search", func(t *testing.T) {
+		result, _ := services.QueryResourceItems(items, services.ResourceQuery{Search: "tests"})
+		assert.Equal(t, []string{"Write tests"}, resourceTitles(result))
+	})
+}
+
+func TestParseResourceSeed(t *testing.T) {
+	items, err := services.ParseResourceSeed("")
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	_, err = services.ParseResourceSeed(`{"id": 1}`)
+	assert.ErrorContains(t, err, "JSON array of objects")
+}
+
+func TestNormalizeResourcePath(t *testing.T) {
+	assert.Equal(t, "/todos", services.NormalizeResourcePath("todos/"))
+	assert.Equal(t, "/", services.NormalizeResourcePath("/"))
+}
+
+func TestResourceService_ItemCRUD(t *testing.T) {
+	store := services.NewMemoryStore()
+	t.Cleanup(store.Close)
+	service := services.NewResourceService(nil, store)
+	resource := &models.Resource{Path: "/todos", IDField: "id", ProjectID: 1,
+		SeedData: `[{"id": 1, "title": "Learn Go"}, {"id": 2, "title": "Write tests"}]`}
+	resource.ID = 7
+
+	created, err := service.CreateItem(context.Background(), resource, services.ResourceItem{"title": "Go shopping"})
+	require.NoError(t, err)
+	assert.Equal(t, "3", fmt.Sprint(created["id"]), "the next numeric ID is assigned")
+	_, err = service.CreateItem(context.Background(), resource, services.ResourceItem{"id": 3, "title": "Duplicate"})
+	assert.ErrorIs(t, err, services.ErrResourceItemExists)
+
+	replaced, err := service.ReplaceItem(context.Background(), resource, "1", services.ResourceItem{"id": 99, "title": "Learn Rust"})
+	require.NoError(t, err)
+	assert.Equal(t, "1", fmt.Sprint(replaced["id"]), "replacing keeps the ID")
+	_, err = service.ReplaceItem(context.Background(), resource, "42", services.ResourceItem{"title": "Nothing"})
+	assert.ErrorIs(t, err, services.ErrResourceItemNotFound)
+
+	patched, err := service.PatchItem(context.Background(), resource, "2", services.ResourceItem{"id": 5, "done": true})
+	require.NoError(t, err)
+	assert.Equal(t, services.ResourceItem{"id": json.Number("2"), "title": "Write tests", "done": true}, patched)
+	_, err = service.PatchItem(context.Background(), resource, "42", services.ResourceItem{"done": true})
+	assert.ErrorIs(t, err, services.ErrResourceItemNotFound)
+
+	require.NoError(t, service.DeleteItem(context.Background(), resource, "3"))
+	assert.ErrorIs(t, service.DeleteItem(context.Background(), resource, "3"), services.ErrResourceItemNotFound)
+
+	items, total, err := service.ListItems(context.Background(), resource, services.ResourceQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []string{"Learn Rust", "Write tests"}, resourceTitles(items))
+
+	reset, err := service.ResetResources(context.Background(), resource.ProjectID, resource.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), reset)
+	item, err := service.GetItem(context.Background(), resource, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Learn Go", item["title"], "a reset restores the seed")
+}
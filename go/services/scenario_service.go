@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"mockapi/models"
+)
+
+// ScenarioStartedState is the state every scenario is in until a mock content moves it elsewhere.
+const ScenarioStartedState = "Started"
+
+// DefaultScenarioSessionKey is used when a request does not carry a session key.
+const DefaultScenarioSessionKey = "default"
+
+// scenarioStateTTL bounds how long an idle session keeps its scenario state in Redis.
+const scenarioStateTTL = 24 * time.Hour
+
+// ScenarioState is the current state of a named scenario for one session.
+type ScenarioState struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// ScenarioService keeps the state of stateful mock scenarios in the key-value store.
+// State is tracked per project, per client session key and per scenario name.
+type ScenarioService struct {
+	DB    *gorm.DB
+	Store KeyValueStore
+}
+
+// NewScenarioService creates a new ScenarioService.
+func NewScenarioService(db *gorm.DB, store KeyValueStore) *ScenarioService {
+	return &ScenarioService{DB: db, Store: store}
+}
+
+func (s *ScenarioService) stateKey(projectID uint, sessionKey, scenario string) string {
+	return s.Store.CreateRedisKey("scenario", strconv.FormatUint(uint64(projectID), 10), sessionKey, scenario)
+}
+
+// GetState returns the current state of a scenario, defaulting to ScenarioStartedState.
+func (s *ScenarioService) GetState(ctx context.Context, projectID uint, sessionKey, scenario string) (string, error) {
+	state, err := s.Store.GetValue(ctx, s.stateKey(projectID, sessionKey, scenario))
+	if err != nil {
+		return "", fmt.Errorf("failed to read state of scenario '%s': %w", scenario, err)
+	}
+	if state == "" {
+		return ScenarioStartedState, nil
+	}
+	return state, nil
+}
+
+// SetState moves a scenario to the given state for a session.
+func (s *ScenarioService) SetState(ctx context.Context, projectID uint, sessionKey, scenario, state string) error {
+	if err := s.Store.SetValue(ctx, s.stateKey(projectID, sessionKey, scenario), state, scenarioStateTTL); err != nil {
+		return fmt.Errorf("failed to set state of scenario '%s': %w", scenario, err)
+	}
+	return nil
+}
+
+// SelectCandidates returns the mock contents that may be served given the session's scenario states.
+func (s *ScenarioService) SelectCandidates(ctx context.Context, projectID uint, sessionKey string, contents []models.MockContent) ([]models.MockContent, error) {
+	states := make(map[string]string)
+	for _, mc := range contents {
+		if mc.ScenarioName == "" {
+			continue
+		}
+		if _, loaded := states[mc.ScenarioName]; loaded {
+			continue
+		}
+		state, err := s.GetState(ctx, projectID, sessionKey, mc.ScenarioName)
+		if err != nil {
+			return nil, err
+		}
+		states[mc.ScenarioName] = state
+	}
+	return FilterMockContentsByScenarioState(contents, states), nil
+}
+
+// ApplyTransition moves the scenario of a served mock content to its NewState, if it declares one.
+func (s *ScenarioService) ApplyTransition(ctx context.Context, projectID uint, sessionKey string, served *models.MockContent) error {
+	if served == nil || served.ScenarioName == "" || served.NewState == "" {
+		return nil
+	}
+	return s.SetState(ctx, projectID, sessionKey, served.ScenarioName, served.NewState)
+}
+
+// ListScenarios returns every scenario referenced by the project's mock contents with its current state.
+func (s *ScenarioService) ListScenarios(ctx context.Context, projectID uint, sessionKey string) ([]ScenarioState, error) {
+	var names []string
+	err := s.DB.WithContext(ctx).Model(&models.MockContent{}).
+		Joins("JOIN urls ON urls.id = mock_contents.url_id AND urls.deleted_at IS NULL").
+		Where("urls.project_id = ? AND mock_contents.scenario_name <> ''", projectID).
+		Distinct().
+		Order("mock_contents.scenario_name").
+		Pluck("mock_contents.scenario_name", &names).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scenarios for project ID %d: %w", projectID, err)
+	}
+
+	scenarios := make([]ScenarioState, 0, len(names))
+	for _, name := range names {
+		state, err := s.GetState(ctx, projectID, sessionKey, name)
+		if err != nil {
+			return nil, err
+		}
+		scenarios = append(scenarios, ScenarioState{Name: name, State: state})
+	}
+	return scenarios, nil
+}
+
+// ResetScenarios moves scenarios back to ScenarioStartedState and returns how many states were cleared.
+// An empty sessionKey resets every session, and an empty scenario resets every scenario of the project.
+func (s *ScenarioService) ResetScenarios(ctx context.Context, projectID uint, sessionKey, scenario string) (int64, error) {
+	// User input is escaped, so a session key like "*" cannot reset other sessions
+	sessionKey, scenario = EscapeKeyPattern(sessionKey), EscapeKeyPattern(scenario)
+	if sessionKey == "" {
+		sessionKey = "*"
+	}
+	if scenario == "" {
+		scenario = "*"
+	}
+	deleted, err := s.Store.DeleteKeysByPattern(ctx, s.stateKey(projectID, sessionKey, scenario))
+	if err != nil {
+		return deleted, fmt.Errorf("failed to reset scenarios for project ID %d: %w", projectID, err)
+	}
+	return deleted, nil
+}
+
+// FilterMockContentsByScenarioState returns the mock contents eligible for the given scenario states.
+// Scenario-bound contents whose required state matches take precedence; contents without a scenario
+// are used as the fallback when none of them match.
+func FilterMockContentsByScenarioState(contents []models.MockContent, states map[string]string) []models.MockContent {
+	var matched, unscoped []models.MockContent
+	for _, mc := range contents {
+		if mc.ScenarioName == "" {
+			unscoped = append(unscoped, mc)
+			continue
+		}
+		state, ok := states[mc.ScenarioName]
+		if !ok {
+			state = ScenarioStartedState
+		}
+		if mc.RequiredState == "" || mc.RequiredState == state {
+			matched = append(matched, mc)
+		}
+	}
+	if len(matched) > 0 {
+		return matched
+	}
+	return unscoped
+}
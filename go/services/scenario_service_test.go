@@ -0,0 +1,79 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mockapi/models"
+	"mockapi/services"
+)
+
+func mockContentNames(contents []models.MockContent) []string {
+	names := make([]string, 0, len(contents))
+	for _, mc := range contents {
+		names = append(names, mc.Name)
+	}
+	return names
+}
+
+func TestFilterMockContentsByScenarioState(t *testing.T) {
+	contents := []models.MockContent{
+		{Name: "fallback"},
+		{Name: "empty cart", ScenarioName: "cart", RequiredState: services.ScenarioStartedState, NewState: "has-item"},
+		{Name: "one item", ScenarioName: "cart", RequiredState: "has-item"},
+		{Name: "any cart state", ScenarioName: "cart"},
+	}
+
+	t.Run("defaults_to_started_state", func(t *testing.T) {
+		result := services.FilterMockContentsByScenarioState(contents, map[string]string{})
+		assert.Equal(t, []string{"empty cart", "any cart state"}, mockContentNames(result))
+	})
+
+	t.Run("matches_current_state", func(t *testing.T) {
+		result := services.FilterMockContentsByScenarioState(contents, map[string]string{"cart": "has-item"})
+		assert.Equal(t, []string{"one item", "any cart state"}, mockContentNames(result))
+	})
+
+	t.Run("falls_back_to_unscoped_contents", func(t *testing.T) {
+		scoped := []models.MockContent{
+			{Name: "fallback"},
+			{Name: "one item", ScenarioName: "cart", RequiredState: "has-item"},
+		}
+		result := services.FilterMockContentsByScenarioState(scoped, map[string]string{"cart": "checked-out"})
+		assert.Equal(t, []string{"fallback"}, mockContentNames(result))
+	})
+
+	t.Run("no_scenarios", func(t *testing.T) {
+		plain := []models.MockContent{{Name: "a"}, {Name: "b"}}
+		result := services.FilterMockContentsByScenarioState(plain, nil)
+		assert.Equal(t, []string{"a", "b"}, mockContentNames(result))
+	})
+}
+
+func TestScenarioService_ResetScenariosEscapesPatterns(t *testing.T) {
+	store := services.NewMemoryStore()
+	t.Cleanup(store.Close)
+	service := services.NewScenarioService(nil, store)
+	for _, session := range []string{"*", "alice", "bob"} {
+		require.NoError(t, service.SetState(context.Background(), 1, session, "cart", "has-item"))
+	}
+	require.NoError(t, service.SetState(context.Background(), 1, "alice", "c?rt", "has-item"))
+
+	deleted, err := service.ResetScenarios(context.Background(), 1, "*", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted, "a session key of '*' only resets that session")
+	state, err := service.GetState(context.Background(), 1, "alice", "cart")
+	require.NoError(t, err)
+	assert.Equal(t, "has-item", state)
+
+	deleted, err = service.ResetScenarios(context.Background(), 1, "alice", "c?rt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted, "metacharacters in scenario names are matched literally")
+
+	deleted, err = service.ResetScenarios(context.Background(), 1, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted, "an empty session key still resets every session")
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"mockapi/models"
+)
+
+// sharedSequenceKey is the cursor key used when a URL's sequence is shared by all clients.
+const sharedSequenceKey = "shared"
+
+// sequenceCursorTTL bounds how long an idle sequence keeps its position in Redis.
+const sequenceCursorTTL = 24 * time.Hour
+
+// SequenceService serves a URL's mock contents in order, keeping the cursor in the key-value store.
+type SequenceService struct {
+	Store KeyValueStore
+}
+
+// NewSequenceService creates a new SequenceService.
+func NewSequenceService(store KeyValueStore) *SequenceService {
+	return &SequenceService{Store: store}
+}
+
+func (s *SequenceService) cursorKey(urlID uint, clientKey string) string {
+	if clientKey == "" {
+		clientKey = sharedSequenceKey
+	}
+	return s.Store.CreateRedisKey("sequence", strconv.FormatUint(uint64(urlID), 10), clientKey)
+}
+
+// NextMockContent advances the URL's sequence and returns the mock content at the new position.
+// Candidates are ordered by ID, which matches the order they were created in.
+// An empty clientKey uses the cursor shared by all clients.
+func (s *SequenceService) NextMockContent(ctx context.Context, url *models.Url, clientKey string, candidates []models.MockContent) (*models.MockContent, error) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	cursor, err := s.Store.Increment(ctx, s.cursorKey(url.ID, clientKey), sequenceCursorTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to advance sequence for URL ID %d: %w", url.ID, err)
+	}
+
+	ordered := make([]models.MockContent, len(candidates))
+	copy(ordered, candidates)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].ID < ordered[j].ID })
+
+	return &ordered[SequenceIndex(cursor, len(ordered), url.SequenceEnd)], nil
+}
+
+// ResetSequence moves a URL's sequence back to its first response and returns how many cursors were cleared.
+// An empty clientKey resets the cursors of every client.
+func (s *SequenceService) ResetSequence(ctx context.Context, urlID uint, clientKey string) (int64, error) {
+	clientKey = EscapeKeyPattern(clientKey)
+	if clientKey == "" {
+		clientKey = "*"
+	}
+	deleted, err := s.Store.DeleteKeysByPattern(ctx, s.cursorKey(urlID, clientKey))
+	if err != nil {
+		return deleted, fmt.Errorf("failed to reset sequence for URL ID %d: %w", urlID, err)
+	}
+	return deleted, nil
+}
+
+// SequenceIndex maps a 1-based sequence cursor to an index into length responses.
+// Past the end, SequenceEndWrap starts over from the first response and any other
+// value keeps repeating the last one.
+func SequenceIndex(cursor int64, length int, end models.SequenceEnd) int {
+	if length <= 0 {
+		return 0
+	}
+	if cursor < 1 {
+		cursor = 1
+	}
+	position := cursor - 1
+	if end == models.SequenceEndWrap {
+		return int(position % int64(length))
+	}
+	if position >= int64(length) {
+		return length - 1
+	}
+	return int(position)
+}
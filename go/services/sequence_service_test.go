@@ -0,0 +1,35 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"mockapi/models"
+	"mockapi/services"
+)
+
+func TestSequenceIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor int64
+		length int
+		end    models.SequenceEnd
+		want   int
+	}{
+		{"first_response", 1, 3, models.SequenceEndRepeatLast, 0},
+		{"last_response", 3, 3, models.SequenceEndRepeatLast, 2},
+		{"repeat_last_past_end", 7, 3, models.SequenceEndRepeatLast, 2},
+		{"wrap_past_end", 4, 3, models.SequenceEndWrap, 0},
+		{"wrap_second_cycle", 8, 3, models.SequenceEndWrap, 1},
+		{"unknown_end_repeats_last", 5, 2, "", 1},
+		{"zero_cursor", 0, 3, models.SequenceEndWrap, 0},
+		{"empty_sequence", 3, 0, models.SequenceEndWrap, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, services.SequenceIndex(tt.cursor, tt.length, tt.end))
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -13,13 +14,18 @@ import (
 
 // URLService handles business logic related to URLs.
 type URLService struct {
-	DB           *gorm.DB
-	RedisService *RedisService
+	DB    *gorm.DB
+	Store KeyValueStore
+
+	// Cache serves URL resolution of mock requests when set. Every write below invalidates it.
+	Cache *MockCache
+	// Writer aggregates request counters and applies them in the background when set.
+	Writer *RequestLogWriter
 }
 
 // NewURLService creates a new URLService.
-func NewURLService(db *gorm.DB, redisService *RedisService) *URLService {
-	return &URLService{DB: db, RedisService: redisService}
+func NewURLService(db *gorm.DB, store KeyValueStore) *URLService {
+	return &URLService{DB: db, Store: store}
 }
 
 // GetURLByID retrieves a URL by its ID.
@@ -54,8 +60,12 @@ func (s *URLService) GetURLByProjectSlugAndPath(projectSlug, path string) (*mode
 
 // GetURLByTeamSlugProjectSlugAndPath retrieves a URL by team slug, project slug, and URL path.
 func (s *URLService) GetURLByTeamSlugProjectSlugAndPath(teamSlug, projectSlug, path string) (*models.Url, error) {
+	return s.findURLByTeamSlugProjectSlugAndPath(s.DB, teamSlug, projectSlug, path)
+}
+
+func (s *URLService) findURLByTeamSlugProjectSlugAndPath(db *gorm.DB, teamSlug, projectSlug, path string) (*models.Url, error) {
 	var url models.Url
-	err := s.DB.Joins("JOIN projects ON projects.id = urls.project_id").
+	err := db.Joins("JOIN projects ON projects.id = urls.project_id").
 		Joins("JOIN teams ON teams.id = projects.team_id").
 		Where("teams.slug = ? AND projects.slug = ? AND urls.url = ?", teamSlug, projectSlug, path).
 		Preload("MockContents").
@@ -70,8 +80,64 @@ func (s *URLService) GetURLByTeamSlugProjectSlugAndPath(teamSlug, projectSlug, p
 	return &url, nil
 }
 
+// ResolveURL returns the URL with its mock contents that answers a mock request, from the cache when possible.
+// The context is passed on to the database query, so it is traced as part of the request.
+func (s *URLService) ResolveURL(ctx context.Context, teamSlug, projectSlug, path string) (*models.Url, error) {
+	return s.Cache.URL(ctx, teamSlug, projectSlug, path, func() (*models.Url, error) {
+		return s.findURLByTeamSlugProjectSlugAndPath(s.DB.WithContext(ctx), teamSlug, projectSlug, path)
+	})
+}
+
 // CreateURL creates a new URL for a given project.
 func (s *URLService) CreateURL(url *models.Url, projectID uint) error {
+	if err := prepareURL(url, projectID); err != nil {
+		return err
+	}
+
+	if err := s.DB.Create(url).Error; err != nil {
+		// Consider checking for unique constraint violation errors specifically
+		return fmt.Errorf("failed to create url: %w", err)
+	}
+	s.Cache.InvalidateProject(projectID)
+	return nil
+}
+
+// CreateURLWithMockContents creates a new URL for a given project together with its mock contents, in one
+// transaction, so a failure leaves neither behind and the path stays free.
+func (s *URLService) CreateURLWithMockContents(url *models.Url, projectID uint, mockContents []models.MockContent) error {
+	if err := prepareURL(url, projectID); err != nil {
+		return err
+	}
+	if err := ValidateMockContents(url, mockContents); err != nil {
+		return err
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(url).Error; err != nil {
+			return fmt.Errorf("failed to create url: %w", err)
+		}
+		if len(mockContents) == 0 {
+			return nil
+		}
+		for i := range mockContents {
+			mockContents[i].UrlID = url.ID
+			mockContents[i].ID = 0
+		}
+		if err := tx.Create(&mockContents).Error; err != nil {
+			return fmt.Errorf("failed to save mock content list for url ID %d: %w", url.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	url.MockContents = mockContents
+	s.Cache.InvalidateProject(projectID)
+	return nil
+}
+
+// prepareURL assigns the URL to its project, fills in defaults and validates its rate limit policy.
+func prepareURL(url *models.Url, projectID uint) error {
 	if url == nil {
 		return fmt.Errorf("url data cannot be nil")
 	}
@@ -79,12 +145,13 @@ func (s *URLService) CreateURL(url *models.Url, projectID uint) error {
 	if url.Status == "" {
 		url.Status = models.StatusOK // Default status
 	}
-
-	if err := s.DB.Create(url).Error; err != nil {
-		// Consider checking for unique constraint violation errors specifically
-		return fmt.Errorf("failed to create url: %w", err)
+	if url.ResponseMode == "" {
+		url.ResponseMode = models.ResponseModeRandom
 	}
-	return nil
+	if url.SequenceEnd == "" {
+		url.SequenceEnd = models.SequenceEndRepeatLast
+	}
+	return ValidateRateLimitPolicy(&url.RateLimit)
 }
 
 // UpdateURL updates an existing URL using data from URLDataDTO.
@@ -120,33 +187,50 @@ func (s *URLService) UpdateURL(urlID uint, dto dtos.URLDataDTO) (*models.Url, er
             // Keep existing status or handle as an error if status update is invalid
         }
 	}
+	if dto.ResponseMode != nil {
+		urlToUpdate.ResponseMode = models.ResponseMode(*dto.ResponseMode)
+	}
+	if dto.SequenceEnd != nil {
+		urlToUpdate.SequenceEnd = models.SequenceEnd(*dto.SequenceEnd)
+	}
+	if dto.SequencePerClient != nil {
+		urlToUpdate.SequencePerClient = *dto.SequencePerClient
+	}
+	if dto.RateLimit != nil {
+		if err := ApplyRateLimitDTO(&urlToUpdate.RateLimit, *dto.RateLimit); err != nil {
+			return nil, err
+		}
+	}
+	if err := ApplyResponseSchema(&urlToUpdate, dto.ResponseSchema); err != nil {
+		return nil, err
+	}
+	if dto.StrictJSON != nil {
+		urlToUpdate.StrictJSON = *dto.StrictJSON
+	}
+	if len(dto.ResponseSchema) > 0 || dto.StrictJSON != nil || dto.Status != nil {
+		// The existing variants must satisfy the new rules
+		var mockContents []models.MockContent
+		if err := s.DB.Where("url_id = ?", urlID).Order("id").Find(&mockContents).Error; err != nil {
+			return nil, fmt.Errorf("failed to load mock contents of url with ID %d: %w", urlID, err)
+		}
+		if err := ValidateMockContents(&urlToUpdate, mockContents); err != nil {
+			return nil, err
+		}
+	}
 
 
 	if err := s.DB.Save(&urlToUpdate).Error; err != nil {
 		return nil, fmt.Errorf("failed to update url with ID %d: %w", urlID, err)
 	}
+	s.Cache.InvalidateProject(urlToUpdate.ProjectID)
 	return &urlToUpdate, nil
 }
 
-// IsRateLimited checks if a request for a given IP and path is rate-limited.
-// It uses the RedisService for the underlying rate-limiting logic.
-func (s *URLService) IsRateLimited(ip, path string, allowedRequests int, timeWindowSeconds int64) (bool, error) {
-	if s.RedisService == nil {
-		return false, fmt.Errorf("redis service is not initialized")
-	}
-	// Normalize path to be part of the key, e.g., remove leading/trailing slashes or case transform
-	normalizedPath := strings.ToLower(strings.Trim(path, "/"))
-	key := s.RedisService.CreateRedisKey("ratelimit", ip, normalizedPath)
-
-	limited, err := s.RedisService.RateLimit(key, allowedRequests, timeWindowSeconds)
-	if err != nil {
-		return true, fmt.Errorf("error checking rate limit: %w", err) // Fail closed
-	}
-	return limited, nil
-}
-
 // IncrementRequestStats increments the request count and updates last accessed time for a URL.
-func (s *URLService) IncrementRequestStats(urlID uint) error {
+func (s *URLService) IncrementRequestStats(ctx context.Context, urlID uint) error {
+	if s.Writer != nil && s.Writer.AddRequestStat(urlID) {
+		return nil
+	}
 	// Using .Updates to only update specified fields and trigger hooks if necessary
 	// It's generally safer and more explicit than .Save for partial updates.
 	// GORM handles nullable types like sql.NullInt64 correctly with Updates.
@@ -158,7 +242,7 @@ func (s *URLService) IncrementRequestStats(urlID uint) error {
 	// If it was 'average response time', it would be calculated differently.
 	// If it was 'last request processing time', it would be set per request.
 	// For now, just incrementing 'Requests'.
-	result := s.DB.Model(&models.Url{}).Where("id = ?", urlID).UpdateColumn("requests", gorm.Expr("requests + 1"))
+	result := s.DB.WithContext(ctx).Model(&models.Url{}).Where("id = ?", urlID).UpdateColumn("requests", gorm.Expr("COALESCE(requests, 0) + 1"))
 	// To update 'UpdatedAt' timestamp as well, use .Updates instead of .UpdateColumn
 	// result := s.DB.Model(&models.Url{}).Where("id = ?", urlID).Updates(map[string]interface{}{
 	// 	"requests": gorm.Expr("requests + 1"),
@@ -175,6 +259,22 @@ func (s *URLService) IncrementRequestStats(urlID uint) error {
 	return nil
 }
 
+// AddRequestStats adds the request counts aggregated per URL ID, with one UPDATE per URL.
+func (s *URLService) AddRequestStats(counts map[uint]int64) error {
+	var failed []string
+	for urlID, count := range counts {
+		err := s.DB.Model(&models.Url{}).Where("id = ?", urlID).
+			UpdateColumn("requests", gorm.Expr("COALESCE(requests, 0) + ?", count)).Error
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("url ID %d: %v", urlID, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to add request counts: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
 // FindByProjectIDAndURL finds a URL by its project ID and the exact URL string.
 func (s *URLService) FindByProjectIDAndURL(projectID uint, urlPath string) (*models.Url, error) {
     var url models.Url
@@ -206,6 +306,7 @@ func (s *URLService) DeleteURL(urlID uint) error {
     if result.RowsAffected == 0 {
         return fmt.Errorf("url with ID %d not found for deletion: %w", urlID, gorm.ErrRecordNotFound)
     }
+    s.Cache.InvalidateURL(urlID)
     return nil
 }
 
@@ -1,14 +1,22 @@
 package services
 
-import "mockapi/models"
+import (
+	"context"
+	"mockapi/dtos"
+	"mockapi/models"
+)
 
 // MockURLService is a manual mock for URLService.
 type MockURLService struct {
-	FindByProjectIDAndURLFunc        func(projectID uint, urlPath string) (*models.Url, error)
-	CreateURLFunc                    func(url *models.Url, projectID uint) error
-	GetURLByIDFunc                   func(id uint) (*models.Url, error)
+	FindByProjectIDAndURLFunc              func(projectID uint, urlPath string) (*models.Url, error)
+	CreateURLFunc                          func(url *models.Url, projectID uint) error
+	CreateURLWithMockContentsFunc          func(url *models.Url, projectID uint, mockContents []models.MockContent) error
+	GetURLByIDFunc                         func(id uint) (*models.Url, error)
 	GetURLByTeamSlugProjectSlugAndPathFunc func(teamSlug, projectSlug, path string) (*models.Url, error)
-	IncrementRequestStatsFunc        func(urlID uint) error
+	IncrementRequestStatsFunc              func(urlID uint) error
+	ResolveURLFunc                         func(teamSlug, projectSlug, path string) (*models.Url, error)
+	UpdateURLFunc                          func(urlID uint, dto dtos.URLDataDTO) (*models.Url, error)
+	DeleteURLFunc                          func(urlID uint) error
 	// Add other methods used by MockContentController if any
 }
 
@@ -26,6 +34,13 @@ func (m *MockURLService) CreateURL(url *models.Url, projectID uint) error {
 	panic("MockURLService.CreateURLFunc is not set")
 }
 
+func (m *MockURLService) CreateURLWithMockContents(url *models.Url, projectID uint, mockContents []models.MockContent) error {
+	if m.CreateURLWithMockContentsFunc != nil {
+		return m.CreateURLWithMockContentsFunc(url, projectID, mockContents)
+	}
+	panic("MockURLService.CreateURLWithMockContentsFunc is not set")
+}
+
 func (m *MockURLService) GetURLByID(id uint) (*models.Url, error) {
 	if m.GetURLByIDFunc != nil {
 		return m.GetURLByIDFunc(id)
@@ -40,7 +55,7 @@ func (m *MockURLService) GetURLByTeamSlugProjectSlugAndPath(teamSlug, projectSlu
 	panic("MockURLService.GetURLByTeamSlugProjectSlugAndPathFunc is not set")
 }
 
-func (m *MockURLService) IncrementRequestStats(urlID uint) error {
+func (m *MockURLService) IncrementRequestStats(_ context.Context, urlID uint) error {
 	if m.IncrementRequestStatsFunc != nil {
 		return m.IncrementRequestStatsFunc(urlID)
 	}
@@ -52,3 +67,24 @@ func (m *MockURLService) IncrementRequestStats(urlID uint) error {
 // UpdateMockContent uses: GetURLByID
 // GetMockedJSON uses: GetURLByTeamSlugProjectSlugAndPath, IncrementRequestStats
 // The mock includes these. Add others if controller logic expands.
+
+func (m *MockURLService) UpdateURL(urlID uint, dto dtos.URLDataDTO) (*models.Url, error) {
+	if m.UpdateURLFunc != nil {
+		return m.UpdateURLFunc(urlID, dto)
+	}
+	panic("MockURLService.UpdateURLFunc is not set")
+}
+
+func (m *MockURLService) DeleteURL(urlID uint) error {
+	if m.DeleteURLFunc != nil {
+		return m.DeleteURLFunc(urlID)
+	}
+	panic("MockURLService.DeleteURLFunc is not set")
+}
+
+func (m *MockURLService) ResolveURL(ctx context.Context, teamSlug, projectSlug, path string) (*models.Url, error) {
+	if m.ResolveURLFunc != nil {
+		return m.ResolveURLFunc(teamSlug, projectSlug, path)
+	}
+	panic("MockURLService.ResolveURLFunc is not set")
+}
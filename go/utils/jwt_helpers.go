@@ -11,15 +11,26 @@ import (
 type AppClaims struct {
 	UserID string `json:"userID"`
 	TeamID string `json:"teamID"`
+	Admin  bool   `json:"admin,omitempty"` // Grants access to every project and the administration endpoints
 	jwt.RegisteredClaims
 }
 
 // GenerateJWTToken creates a new JWT token with custom claims.
 func GenerateJWTToken(userID string, teamID string, secretKey string, expirationTime time.Duration) (string, error) {
+	return generateJWTToken(userID, teamID, false, secretKey, expirationTime)
+}
+
+// GenerateAdminJWTToken creates a new JWT token for an administrator.
+func GenerateAdminJWTToken(userID string, secretKey string, expirationTime time.Duration) (string, error) {
+	return generateJWTToken(userID, "", true, secretKey, expirationTime)
+}
+
+func generateJWTToken(userID string, teamID string, admin bool, secretKey string, expirationTime time.Duration) (string, error) {
 	// Create the claims
 	claims := AppClaims{
 		UserID: userID,
 		TeamID: teamID,
+		Admin:  admin,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expirationTime)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),